@@ -4,21 +4,46 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/aasoft24/golara/wpkg/auth"
 	"github.com/aasoft24/golara/wpkg/orm"
+	"github.com/aasoft24/golara/wpkg/rbac"
 	"gorm.io/gorm"
 )
 
 type User struct {
-	ID        int64 `gorm:"primaryKey;autoIncrement;column:id"`
-	Name      string
-	Mobile    string
-	Email     string
-	Password  string    `json:"--"`
-	Balance   float64   `gorm:"type:decimal(10,2);default:0"`
-	Status    string    `db:"status"`
-	CreatedAt time.Time `db:"created_at" gorm:"autoCreateTime"`
-	UpdatedAt time.Time `db:"updated_at" gorm:"autoUpdateTime"`
-	DeletedAt time.Time `db:"deleted_at" gorm:"autoDeleteTime"`
+	ID            int64 `gorm:"primaryKey;autoIncrement;column:id"`
+	Name          string
+	Mobile        string
+	Email         string
+	Password      string    `json:"-"`
+	RememberToken string    `json:"-" gorm:"type:text"` // hashed remember-me tokens, one per device
+	Balance       float64   `gorm:"type:decimal(10,2);default:0"`
+	Status        string    `db:"status"`
+	CreatedAt     time.Time `db:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt     time.Time `db:"updated_at" gorm:"autoUpdateTime"`
+	DeletedAt     time.Time `db:"deleted_at" gorm:"autoDeleteTime"`
+}
+
+// UserResource is the public JSON shape of a user, without credentials
+type UserResource struct {
+	ID        int64     `json:"id"`
+	Name      string    `json:"name"`
+	Email     string    `json:"email"`
+	Mobile    string    `json:"mobile"`
+	Status    string    `json:"status"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Resource returns the fields of u that API responses may expose
+func (u *User) Resource() UserResource {
+	return UserResource{
+		ID:        u.ID,
+		Name:      u.Name,
+		Email:     u.Email,
+		Mobile:    u.Mobile,
+		Status:    u.Status,
+		CreatedAt: u.CreatedAt,
+	}
 }
 
 func init() {
@@ -33,6 +58,52 @@ func (u *User) TableName() string {
 	return "users"
 }
 
+// GetAuthID and GetAuthPassword make User an auth.Authenticatable
+func (u *User) GetAuthID() uint {
+	return uint(u.ID)
+}
+
+func (u *User) GetAuthPassword() string {
+	return u.Password
+}
+
+// GetRememberToken enables "remember me" (auth.Rememberable)
+func (u *User) GetRememberToken() string {
+	return u.RememberToken
+}
+
+// CreateToken issues a personal access token for the API; the plain text
+// is only available now
+func (u *User) CreateToken(name string, abilities []string, expiresAt *time.Time) (*auth.NewAccessToken, error) {
+	return auth.CreateToken(u, name, abilities, expiresAt)
+}
+
+// Tokens lists the user's personal access tokens
+func (u *User) Tokens() ([]auth.PersonalAccessToken, error) {
+	return auth.Tokens.For(u)
+}
+
+// HasRole checks the user's roles in team, or the global ones without;
+// "admin|manager" matches either
+func (u *User) HasRole(role string, team ...uint) bool {
+	return rbac.HasRole(u, role, team...)
+}
+
+// HasPermissionTo checks permissions granted directly or through roles
+func (u *User) HasPermissionTo(permission string, team ...uint) bool {
+	return rbac.HasPermissionTo(u, permission, team...)
+}
+
+// AssignRole gives the user role, in team when given
+func (u *User) AssignRole(role string, team ...uint) error {
+	return rbac.AssignRole(u, role, team...)
+}
+
+// RemoveRole takes role from the user, in team when given
+func (u *User) RemoveRole(role string, team ...uint) error {
+	return rbac.RemoveRole(u, role, team...)
+}
+
 func (u *User) BeforeCreate(tx *gorm.DB) (err error) {
 	fmt.Println("Before creating user:", u.Name)
 	return
@@ -0,0 +1,31 @@
+package providers
+
+import (
+	"github.com/aasoft24/golara/wpkg/auth"
+	"github.com/aasoft24/golara/wpkg/authz"
+	"github.com/aasoft24/golara/wpkg/foundation"
+	"github.com/aasoft24/golara/wpkg/rbac"
+)
+
+// AuthServiceProvider defines the gates and registers the model policies
+type AuthServiceProvider struct{}
+
+func NewAuthServiceProvider() *AuthServiceProvider {
+	return &AuthServiceProvider{}
+}
+
+func (p *AuthServiceProvider) Register(app *foundation.Application) {}
+
+func (p *AuthServiceProvider) Boot(app *foundation.Application) {
+	// admins may do everything; roles are seeded by database/seeders
+	// (go run . db:seed)
+	authz.Before(func(user auth.Authenticatable, ability string, args ...interface{}) (bool, bool) {
+		return true, rbac.HasRole(user, "admin")
+	})
+	authz.Define("admin", func(user auth.Authenticatable, args ...interface{}) bool {
+		return rbac.HasRole(user, "admin")
+	})
+
+	// Policies, one per model:
+	// authz.Policy(&models.Post{}, &policies.PostPolicy{})
+}
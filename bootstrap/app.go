@@ -5,16 +5,24 @@ import (
 	"database/sql"
 	"fmt"
 	"log"
+	"net/http"
 
-	_ "your/module/path/app/models"
+	"your/module/path/app/models"
 
 	"your/module/path/app/providers"
 
+	"github.com/aasoft24/golara/wpkg/auth"
+	"github.com/aasoft24/golara/wpkg/authz"
 	"github.com/aasoft24/golara/wpkg/cache"
 	"github.com/aasoft24/golara/wpkg/configs"
 	"github.com/aasoft24/golara/wpkg/database"
+	"github.com/aasoft24/golara/wpkg/encryption"
 	"github.com/aasoft24/golara/wpkg/foundation"
 	"github.com/aasoft24/golara/wpkg/gola"
+	"github.com/aasoft24/golara/wpkg/i18n"
+	"github.com/aasoft24/golara/wpkg/logger"
+	"github.com/aasoft24/golara/wpkg/middleware"
+	"github.com/aasoft24/golara/wpkg/rbac"
 	"github.com/aasoft24/golara/wpkg/routing"
 	"github.com/aasoft24/golara/wpkg/session"
 	"github.com/aasoft24/golara/wpkg/view"
@@ -34,36 +42,110 @@ func Init() *routing.Router {
 	// 3️⃣ Init redis
 	cache.InitRedis()
 
-	// 4️⃣ Template engine
+	// Lang files (resources/lang)
+	if err := i18n.Init(); err != nil {
+		fmt.Println(err)
+	}
+
+	// 4️⃣ Template engine; can/cannot placeholders, bound per request by authz.Middleware
+	view.AddFuncs(authz.Funcs(nil))
 	templateEngine := view.NewTemplateEngine("resources/views", "app")
 	ctx := &gola.Context{TemplateEngine: templateEngine}
 	router := routing.NewRouter(ctx)
 
-	// 5️⃣ Session middleware
-	store := session.NewMemoryStore()
-	sessionManager := session.NewManager(store, "go_session")
+	// Encrypt cookies with APP_KEY; first, so every later cookie is covered
+	if _, err := encryption.Default(); err == nil {
+		router.Use(middleware.EncryptCookies())
+	} else {
+		fmt.Println(err) // cookies stay unencrypted until APP_KEY is set
+	}
+
+	// 5️⃣ Session middleware (driver from the session section of config.yaml)
+	sessionManager, err := session.FromConfig(configs.GConfig)
+	if err != nil {
+		fmt.Println(err) // fall back to memory sessions
+		sessionManager = session.NewManager(session.NewMemoryStore(), "go_session")
+	}
 
 	router.Use(func(next func(ctx *gola.Context)) func(ctx *gola.Context) {
 		return func(ctx *gola.Context) {
-			sess, _ := sessionManager.Start(ctx.Writer, ctx.Request)
+			sess, err := sessionManager.Start(ctx.Writer, ctx.Request)
+			if err != nil {
+				// e.g. session.ErrLockTimeout: another request holds the session
+				ctx.Error(http.StatusServiceUnavailable, "Session unavailable, please retry")
+				return
+			}
 			ctx.Session = sess
 			ctx.SessionManager = sessionManager
+			defer sess.Release() // saves and frees the per-session lock
 			next(ctx)
-			_ = sess.Save()
 		}
 	})
 
+	// XSRF-TOKEN cookie on every response, for JavaScript clients
+	router.Use(middleware.XSRFToken)
+
+	// 7️⃣ Cache
+	appCache := cache.NewMemoryCache()
+
+	// Auth guards: web logs in through the session, api with personal
+	// access tokens (user.CreateToken) sent as bearer tokens, jwt with
+	// access and refresh tokens when the jwt section of config.yaml has a key
+	users := auth.NewGormProvider[models.User](nil)
+	auth.Extend("web", auth.NewSessionGuard("web", users))
+	auth.Extend("api", auth.NewAccessTokenGuard("api", users, nil))
+	var jwtGuard *auth.JWTGuard
+	if j, err := auth.NewJWT(configs.GConfig.JWT); err == nil {
+		// logged-out tokens are denylisted in the app cache
+		jwtGuard = auth.NewJWTGuard("jwt", users, j, appCache)
+		auth.Extend("jwt", jwtGuard)
+	}
+	if database.DB != nil {
+		if err := auth.Tokens.Migrate(); err != nil {
+			fmt.Println(err)
+		}
+		if jwtGuard != nil {
+			if err := jwtGuard.RefreshTokens.Migrate(); err != nil {
+				fmt.Println(err)
+			}
+		}
+	}
+
+	// Roles and permissions, cached in the app cache; database permissions
+	// also answer gate checks (authz.Allows, can middleware)
+	rbac.Default = rbac.New(nil, appCache)
+	if database.DB != nil {
+		if err := rbac.Default.Migrate(); err != nil {
+			logger.Error("rbac migrate: " + err.Error())
+		}
+	}
+	rbac.RegisterGate(authz.Default)
+
+	// can/cannot in templates, checked for the request user
+	router.Use(authz.Middleware)
+
+	// Route middleware by name, e.g. router.Middleware("can:update,post")
+	router.AliasMiddleware("can", func(args ...string) func(next func(ctx *gola.Context)) func(ctx *gola.Context) {
+		if len(args) == 0 {
+			panic("routing: the can middleware needs an ability, e.g. can:update,post")
+		}
+		return authz.Can(args[0], args[1:]...)
+	})
+	router.AliasMiddleware("role", rbac.RoleMiddleware)             // role:admin|manager
+	router.AliasMiddleware("permission", rbac.PermissionMiddleware) // permission:orders.refund
+
+	// Locale middleware (needs the session)
+	router.Use(i18n.Middleware)
+
 	// 6️⃣ Logging middleware
 	router.Use(func(next func(ctx *gola.Context)) func(ctx *gola.Context) {
 		return func(ctx *gola.Context) {
-			log.Printf("%s %s", ctx.Request.Method, ctx.Request.URL.Path)
 			next(ctx)
+			log.Printf("%s %s %d %dB %v", ctx.Request.Method, ctx.Request.URL.Path,
+				ctx.Writer.Status(), max(ctx.Writer.Size(), 0), ctx.Elapsed())
 		}
 	})
 
-	// 7️⃣ Cache
-	appCache := cache.NewMemoryCache()
-
 	// 8️⃣ Application container
 	app := foundation.NewApplication()
 
@@ -80,7 +162,8 @@ func Init() *routing.Router {
 	app.Bind((*view.TemplateEngine)(nil), templateEngine)
 	app.Bind((*cache.Cache)(nil), appCache)
 
-	// 9️⃣ Register route service provider
+	// 9️⃣ Register gates and policies, then the routes
+	app.Register(providers.NewAuthServiceProvider())
 	app.Register(providers.NewRouteServiceProvider(router, templateEngine))
 	app.Boot()
 
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/aasoft24/golara/wpkg/encryption"
+)
+
+const usage = `Usage: golara <command> [options]
+
+Commands:
+  key:generate [--show] [--env=.env]   write a new APP_KEY; the old key moves to APP_PREVIOUS_KEYS`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Println(usage)
+		return
+	}
+
+	switch os.Args[1] {
+	case "key:generate":
+		if err := keyGenerate(os.Args[2:]); err != nil {
+			fmt.Printf("key:generate failed: %v\n", err)
+			os.Exit(1)
+		}
+	default:
+		fmt.Println(usage)
+		os.Exit(1)
+	}
+}
+
+// keyGenerate writes a new APP_KEY to the env file. The previous key is kept
+// in APP_PREVIOUS_KEYS, so cookies and values encrypted with it still decrypt.
+func keyGenerate(args []string) error {
+	show, envFile := false, ".env"
+	for _, arg := range args {
+		switch {
+		case arg == "--show":
+			show = true
+		case strings.HasPrefix(arg, "--env="):
+			envFile = strings.TrimPrefix(arg, "--env=")
+		default:
+			return fmt.Errorf("unknown option %q", arg)
+		}
+	}
+
+	key, err := encryption.GenerateKey()
+	if err != nil {
+		return err
+	}
+	if show {
+		fmt.Println(key)
+		return nil
+	}
+
+	data, err := os.ReadFile(envFile)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	lines := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	if len(data) == 0 {
+		lines = nil
+	}
+
+	oldKey, prevIdx, keyIdx := "", -1, -1
+	var previous []string
+	for i, line := range lines {
+		if v, ok := strings.CutPrefix(line, "APP_KEY="); ok {
+			oldKey, keyIdx = strings.Trim(v, `"`), i
+		} else if v, ok := strings.CutPrefix(line, "APP_PREVIOUS_KEYS="); ok {
+			prevIdx = i
+			for _, k := range strings.Split(strings.Trim(v, `"`), ",") {
+				if k != "" {
+					previous = append(previous, k)
+				}
+			}
+		}
+	}
+	if oldKey != "" {
+		previous = append([]string{oldKey}, previous...)
+	}
+
+	keyLine := "APP_KEY=" + key
+	if keyIdx >= 0 {
+		lines[keyIdx] = keyLine
+	} else {
+		lines = append(lines, keyLine)
+	}
+	if len(previous) > 0 {
+		prevLine := "APP_PREVIOUS_KEYS=" + strings.Join(previous, ",")
+		if prevIdx >= 0 {
+			lines[prevIdx] = prevLine
+		} else {
+			lines = append(lines, prevLine)
+		}
+	}
+
+	if err := os.WriteFile(envFile, []byte(strings.Join(lines, "\n")+"\n"), 0600); err != nil {
+		return err
+	}
+	fmt.Printf("✅ Application key set in %s\n", envFile)
+	if oldKey != "" {
+		fmt.Println("The previous key was moved to APP_PREVIOUS_KEYS; remove it once old sessions have expired.")
+	}
+	return nil
+}
@@ -0,0 +1,8 @@
+// database/seeders/database_seeder.go
+package seeders
+
+// Run seeds the database, called by `go run . db:seed`. Seeders must be
+// safe to run again.
+func Run() error {
+	return RoleSeeder()
+}
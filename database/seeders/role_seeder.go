@@ -0,0 +1,15 @@
+// database/seeders/role_seeder.go
+package seeders
+
+import "github.com/aasoft24/golara/wpkg/rbac"
+
+// RoleSeeder creates the application roles; permissions are granted at
+// runtime, e.g. rbac.Default.GivePermissionToRole("manager", "orders.refund")
+func RoleSeeder() error {
+	for _, role := range []string{"admin", "manager", "staff"} {
+		if _, err := rbac.Default.CreateRole(role); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -5,16 +5,66 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"os"
 
 	"your/module/path/bootstrap"
+	"your/module/path/database/seeders"
 
 	"github.com/aasoft24/golara/wpkg/configs"
+	"github.com/aasoft24/golara/wpkg/database"
+	"github.com/aasoft24/golara/wpkg/rbac"
 )
 
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "db:seed":
+			seed()
+			return
+		case "roles:copy-column", "roles:drop-column":
+			legacyRoles(os.Args[1])
+			return
+		}
+	}
 	startServer()
 }
 
+// seed runs the database seeders: go run . db:seed
+func seed() {
+	bootstrap.Init()
+	if database.DB == nil {
+		log.Fatal("db:seed failed: no database connection")
+	}
+	if err := seeders.Run(); err != nil {
+		log.Fatalf("db:seed failed: %v", err)
+	}
+	log.Println("✅ Database seeded")
+}
+
+// legacyRoles moves users.role of older installs to user_has_roles, in two
+// runs so the copied roles can be checked before the column is dropped:
+//
+//	go run . roles:copy-column   copies users.role, keeps the column
+//	go run . roles:drop-column   drops users.role once every role was copied
+func legacyRoles(command string) {
+	bootstrap.Init()
+	if database.DB == nil {
+		log.Fatalf("%s failed: no database connection", command)
+	}
+	if command == "roles:copy-column" {
+		n, err := rbac.Default.CopyRoleColumn("users", "role")
+		if err != nil {
+			log.Fatalf("%s failed: %v", command, err)
+		}
+		log.Printf("✅ %d user roles copied to user_has_roles; check them, then run roles:drop-column", n)
+		return
+	}
+	if err := rbac.Default.DropRoleColumn("users", "role"); err != nil {
+		log.Fatalf("%s failed: %v", command, err)
+	}
+	log.Println("✅ users.role dropped")
+}
+
 func startServer() {
 	router := bootstrap.Init() // router now returned from Init()
 
@@ -1,8 +1,35 @@
 package routes
 
 import (
+	"net/http"
+
+	"github.com/aasoft24/golara/app/models"
+	"github.com/aasoft24/golara/wpkg/auth"
+	"github.com/aasoft24/golara/wpkg/gola"
 	"github.com/aasoft24/golara/wpkg/routing"
 )
 
 func RegisterApiRoutes(router *routing.Router) {
+	// Personal access tokens: Authorization: Bearer <token>
+	api := router.Group("/api", auth.Authenticate("api"))
+
+	api.Get("/user", func(c *gola.Context) {
+		user, ok := auth.User(c).(*models.User)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, map[string]interface{}{"message": "Unauthenticated"})
+			return
+		}
+		c.JSON(http.StatusOK, user.Resource())
+	})
+
+	// revokes the token the request was made with
+	api.Delete("/token", func(c *gola.Context) {
+		if token := auth.CurrentAccessToken(c); token != nil {
+			if err := auth.Tokens.Revoke(auth.User(c), token.ID); err != nil {
+				c.JSON(http.StatusInternalServerError, map[string]interface{}{"message": "Token could not be revoked"})
+				return
+			}
+		}
+		c.JSON(http.StatusOK, map[string]interface{}{"message": "Token revoked"})
+	})
 }
@@ -0,0 +1,82 @@
+// pkg/auth/access_token_guard.go
+package auth
+
+import (
+	"github.com/aasoft24/golara/wpkg/gola"
+)
+
+// AccessTokenGuard authenticates API requests with personal access tokens
+// sent as "Authorization: Bearer <token>". The token is available from
+// c.AccessToken(), so handlers can check c.TokenCan("orders:write").
+type AccessTokenGuard struct {
+	name     string
+	provider UserProvider
+	tokens   *AccessTokens
+}
+
+// NewAccessTokenGuard looks tokens up in tokens, or auth.Tokens when nil
+func NewAccessTokenGuard(name string, provider UserProvider, tokens *AccessTokens) *AccessTokenGuard {
+	return &AccessTokenGuard{name: name, provider: provider, tokens: tokens}
+}
+
+func (g *AccessTokenGuard) store() *AccessTokens {
+	if g.tokens != nil {
+		return g.tokens
+	}
+	return Tokens
+}
+
+func (g *AccessTokenGuard) User(c *gola.Context) (Authenticatable, error) {
+	if r, ok := cached(c, g.name); ok {
+		return r.user, nil
+	}
+	token, user, err := g.resolve(BearerToken(c))
+	if err != nil {
+		return nil, err
+	}
+	if token != nil {
+		// a failed last_used_at write shouldn't fail the request
+		g.store().Touch(token)
+		c.SetAccessToken(token)
+	}
+	setUser(c, g.name, user)
+	return user, nil
+}
+
+// resolve returns a valid, unexpired token and its owner
+func (g *AccessTokenGuard) resolve(plain string) (*PersonalAccessToken, Authenticatable, error) {
+	token, err := g.store().Find(plain)
+	if err != nil || token == nil || token.Expired() {
+		return nil, nil, err
+	}
+	user, err := g.provider.RetrieveByID(token.TokenableID)
+	if err != nil || user == nil || tokenableType(user) != token.TokenableType {
+		return nil, nil, err
+	}
+	return token, user, nil
+}
+
+func (g *AccessTokenGuard) Check(c *gola.Context) bool {
+	user, _ := g.User(c)
+	return user != nil
+}
+
+func (g *AccessTokenGuard) ID(c *gola.Context) uint {
+	if user, _ := g.User(c); user != nil {
+		return user.GetAuthID()
+	}
+	return 0
+}
+
+// Validate checks credentials["token"]
+func (g *AccessTokenGuard) Validate(credentials map[string]string) bool {
+	_, user, err := g.resolve(credentials["token"])
+	return err == nil && user != nil
+}
+
+// CurrentAccessToken returns the personal access token of the request, nil
+// when it wasn't authenticated with one; revoke it to log the client out
+func CurrentAccessToken(c *gola.Context) *PersonalAccessToken {
+	token, _ := c.AccessToken().(*PersonalAccessToken)
+	return token
+}
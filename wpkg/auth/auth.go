@@ -0,0 +1,211 @@
+// pkg/auth/auth.go
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/aasoft24/golara/wpkg/configs"
+	"github.com/aasoft24/golara/wpkg/gola"
+)
+
+var (
+	ErrNotStateful    = errors.New("auth: the guard can't log users in or out")
+	ErrNoSession      = errors.New("auth: the session guard needs the session middleware")
+	ErrGuardUndefined = errors.New("auth: guard is not defined, register it with auth.Extend")
+)
+
+// Authenticatable is a user that guards can log in; implement it on the user model
+type Authenticatable interface {
+	GetAuthID() uint
+	GetAuthPassword() string
+}
+
+// Guard resolves the user of a request
+type Guard interface {
+	// User returns the authenticated user, nil for guests
+	User(c *gola.Context) (Authenticatable, error)
+	// Check reports whether the request is authenticated
+	Check(c *gola.Context) bool
+	// ID returns the user's ID, 0 for guests
+	ID(c *gola.Context) uint
+	// Validate checks credentials without logging in
+	Validate(credentials map[string]string) bool
+}
+
+// StatefulGuard keeps the user between requests, e.g. in the session
+type StatefulGuard interface {
+	Guard
+	// Attempt logs in the user matching credentials (password is checked);
+	// remember keeps the user logged in after the session expires
+	Attempt(c *gola.Context, credentials map[string]string, remember ...bool) (bool, error)
+	Login(c *gola.Context, user Authenticatable, remember ...bool) error
+	LoginUsingID(c *gola.Context, id uint, remember ...bool) (Authenticatable, error)
+	Logout(c *gola.Context) error
+	// ChangePassword stores a new password; other devices are logged out
+	ChangePassword(c *gola.Context, password string) error
+	// LogoutOtherDevices ends every other session and remember cookie
+	LogoutOtherDevices(c *gola.Context, password string) error
+}
+
+var (
+	mu     sync.RWMutex
+	guards = make(map[string]Guard)
+)
+
+// Extend registers a guard under name, e.g. "web" or "api"
+func Extend(name string, g Guard) {
+	mu.Lock()
+	defer mu.Unlock()
+	guards[name] = g
+}
+
+// Get returns the named guard, the default one (auth.guard) without a name.
+// A guard that was never registered, e.g. a typo in auth.guard, is
+// ErrGuardUndefined.
+func Get(name ...string) (Guard, error) {
+	n := defaultGuard()
+	if len(name) > 0 && name[0] != "" {
+		n = name[0]
+	}
+	mu.RLock()
+	g, ok := guards[n]
+	mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrGuardUndefined, n)
+	}
+	return g, nil
+}
+
+func defaultGuard() string {
+	if cfg := configs.GConfig; cfg != nil && cfg.Auth.Guard != "" {
+		return cfg.Auth.Guard
+	}
+	return "web"
+}
+
+func stateful() (StatefulGuard, error) {
+	guard, err := Get()
+	if err != nil {
+		return nil, err
+	}
+	g, ok := guard.(StatefulGuard)
+	if !ok {
+		return nil, ErrNotStateful
+	}
+	return g, nil
+}
+
+// User returns the user of the default guard, nil for guests
+func User(c *gola.Context) Authenticatable {
+	g, err := Get()
+	if err != nil {
+		return nil
+	}
+	user, _ := g.User(c)
+	return user
+}
+
+// ID returns the user ID of the default guard, 0 for guests
+func ID(c *gola.Context) uint {
+	g, err := Get()
+	if err != nil {
+		return 0
+	}
+	return g.ID(c)
+}
+
+// Check reports whether the default guard has a user
+func Check(c *gola.Context) bool {
+	g, err := Get()
+	return err == nil && g.Check(c)
+}
+
+// Guest reports whether the default guard has no user
+func Guest(c *gola.Context) bool {
+	return !Check(c)
+}
+
+// Validate checks credentials with the default guard without logging in
+func Validate(credentials map[string]string) bool {
+	g, err := Get()
+	return err == nil && g.Validate(credentials)
+}
+
+// Attempt logs in with the default guard. Redirect with
+// c.RedirectIntended(home) afterwards to return to the page that required login.
+func Attempt(c *gola.Context, credentials map[string]string, remember ...bool) (bool, error) {
+	g, err := stateful()
+	if err != nil {
+		return false, err
+	}
+	return g.Attempt(c, credentials, remember...)
+}
+
+// Login logs user in with the default guard
+func Login(c *gola.Context, user Authenticatable, remember ...bool) error {
+	g, err := stateful()
+	if err != nil {
+		return err
+	}
+	return g.Login(c, user, remember...)
+}
+
+// LoginUsingID logs in the user with id with the default guard
+func LoginUsingID(c *gola.Context, id uint, remember ...bool) (Authenticatable, error) {
+	g, err := stateful()
+	if err != nil {
+		return nil, err
+	}
+	return g.LoginUsingID(c, id, remember...)
+}
+
+// Logout logs out of the default guard
+func Logout(c *gola.Context) error {
+	g, err := stateful()
+	if err != nil {
+		return err
+	}
+	return g.Logout(c)
+}
+
+// ChangePassword stores a new password for the user of the default guard
+func ChangePassword(c *gola.Context, password string) error {
+	g, err := stateful()
+	if err != nil {
+		return err
+	}
+	return g.ChangePassword(c, password)
+}
+
+// LogoutOtherDevices logs the user of the default guard out everywhere else
+func LogoutOtherDevices(c *gola.Context, password string) error {
+	g, err := stateful()
+	if err != nil {
+		return err
+	}
+	return g.LogoutOtherDevices(c, password)
+}
+
+// resolved caches a guard's user for the request, guests included, so the
+// provider is asked once per request
+type resolved struct {
+	user Authenticatable
+}
+
+func userKey(guard string) gola.Key[*resolved] {
+	return gola.NewKey[*resolved]("auth.user." + guard)
+}
+
+func cached(c *gola.Context, guard string) (*resolved, bool) {
+	return gola.GetValue(c, userKey(guard))
+}
+
+// setUser caches user and exposes it to handlers and templates as "User"
+func setUser(c *gola.Context, guard string, user Authenticatable) {
+	gola.SetValue(c, userKey(guard), &resolved{user: user})
+	if user != nil {
+		c.Set("User", user)
+	}
+}
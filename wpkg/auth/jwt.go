@@ -0,0 +1,197 @@
+// pkg/auth/jwt.go
+package auth
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aasoft24/golara/wpkg/configs"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	ErrJWTKey     = errors.New("auth: the jwt key is missing or invalid")
+	ErrJWTInvalid = errors.New("auth: the jwt is invalid")
+)
+
+// reservedClaims are set by JWT and can't be replaced by custom claims
+var reservedClaims = []string{"sub", "iat", "nbf", "exp", "jti", "iss", "aud", "fam"}
+
+// JWT signs and verifies access tokens with one algorithm; tokens signed
+// with any other algorithm, "none" included, are rejected
+type JWT struct {
+	method     jwt.SigningMethod
+	signKey    interface{}
+	verifyKey  interface{}
+	TTL        time.Duration
+	RefreshTTL time.Duration
+	Issuer     string
+	Audience   string
+	// Claims adds custom claims to the tokens of user, e.g. a role
+	Claims func(user Authenticatable) map[string]interface{}
+}
+
+// NewJWT builds a JWT from the jwt section of config.yaml
+func NewJWT(cfg configs.JWTConfig) (*JWT, error) {
+	j := &JWT{
+		TTL:        minutes(cfg.TTL, 15),
+		RefreshTTL: minutes(cfg.RefreshTTL, 20160),
+		Issuer:     cfg.Issuer,
+		Audience:   cfg.Audience,
+	}
+	var err error
+	switch strings.ToUpper(cfg.Algorithm) {
+	case "", "HS256":
+		if len(cfg.Secret) < 32 {
+			return nil, fmt.Errorf("%w: HS256 needs a secret of at least 32 bytes", ErrJWTKey)
+		}
+		j.method = jwt.SigningMethodHS256
+		j.signKey, j.verifyKey = []byte(cfg.Secret), []byte(cfg.Secret)
+	case "RS256":
+		j.method = jwt.SigningMethodRS256
+		err = j.loadKeys(cfg, func(pem []byte) (crypto.Signer, error) {
+			return jwt.ParseRSAPrivateKeyFromPEM(pem)
+		}, func(pem []byte) (interface{}, error) {
+			return jwt.ParseRSAPublicKeyFromPEM(pem)
+		})
+	case "EDDSA":
+		j.method = jwt.SigningMethodEdDSA
+		err = j.loadKeys(cfg, func(pem []byte) (crypto.Signer, error) {
+			key, err := jwt.ParseEdPrivateKeyFromPEM(pem)
+			if err != nil {
+				return nil, err
+			}
+			signer, ok := key.(crypto.Signer)
+			if !ok {
+				return nil, errors.New("not an Ed25519 private key")
+			}
+			return signer, nil
+		}, func(pem []byte) (interface{}, error) {
+			return jwt.ParseEdPublicKeyFromPEM(pem)
+		})
+	default:
+		return nil, fmt.Errorf("auth: unsupported jwt algorithm %q", cfg.Algorithm)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return j, nil
+}
+
+func minutes(n, fallback int) time.Duration {
+	if n <= 0 {
+		n = fallback
+	}
+	return time.Duration(n) * time.Minute
+}
+
+// loadKeys reads the key pair; without a private key the JWT only verifies
+func (j *JWT) loadKeys(cfg configs.JWTConfig,
+	private func([]byte) (crypto.Signer, error), public func([]byte) (interface{}, error)) error {
+	if cfg.PrivateKey != "" {
+		pem, err := readPEM(cfg.PrivateKey)
+		if err != nil {
+			return err
+		}
+		signer, err := private(pem)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrJWTKey, err)
+		}
+		j.signKey, j.verifyKey = signer, signer.Public()
+	}
+	if cfg.PublicKey != "" {
+		pem, err := readPEM(cfg.PublicKey)
+		if err != nil {
+			return err
+		}
+		if j.verifyKey, err = public(pem); err != nil {
+			return fmt.Errorf("%w: %v", ErrJWTKey, err)
+		}
+	}
+	if j.verifyKey == nil {
+		return fmt.Errorf("%w: set jwt.private_key or jwt.public_key", ErrJWTKey)
+	}
+	if pub, ok := j.verifyKey.(*rsa.PublicKey); ok && pub.N.BitLen() < 2048 {
+		return fmt.Errorf("%w: RS256 keys need at least 2048 bits", ErrJWTKey)
+	}
+	return nil
+}
+
+// readPEM accepts PEM text or the path of a PEM file
+func readPEM(value string) ([]byte, error) {
+	if strings.Contains(value, "-----BEGIN") {
+		return []byte(value), nil
+	}
+	return os.ReadFile(value)
+}
+
+// Algorithm returns the JWT alg, e.g. HS256
+func (j *JWT) Algorithm() string {
+	return j.method.Alg()
+}
+
+// Issue signs an access token for user. family ties it to its refresh
+// token chain, so logging out revokes both.
+func (j *JWT) Issue(user Authenticatable, family string) (token string, expiresAt time.Time, err error) {
+	if j.signKey == nil {
+		return "", time.Time{}, fmt.Errorf("%w: no signing key, set jwt.private_key", ErrJWTKey)
+	}
+	now := time.Now()
+	expiresAt = now.Add(j.TTL)
+	claims := jwt.MapClaims{}
+	if j.Claims != nil {
+		for k, v := range j.Claims(user) {
+			claims[k] = v
+		}
+		for _, k := range reservedClaims {
+			delete(claims, k)
+		}
+	}
+	claims["sub"] = strconv.FormatUint(uint64(user.GetAuthID()), 10)
+	claims["iat"] = now.Unix()
+	claims["nbf"] = now.Unix()
+	claims["exp"] = expiresAt.Unix()
+	claims["jti"] = randomToken()
+	if family != "" {
+		claims["fam"] = family
+	}
+	if j.Issuer != "" {
+		claims["iss"] = j.Issuer
+	}
+	if j.Audience != "" {
+		claims["aud"] = j.Audience
+	}
+	token, err = jwt.NewWithClaims(j.method, claims).SignedString(j.signKey)
+	return token, expiresAt, err
+}
+
+// Parse verifies token's signature, algorithm, expiry, issuer and audience
+// and returns its claims
+func (j *JWT) Parse(token string) (map[string]interface{}, error) {
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{j.method.Alg()}),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(30 * time.Second),
+	}
+	if j.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(j.Issuer))
+	}
+	if j.Audience != "" {
+		opts = append(opts, jwt.WithAudience(j.Audience))
+	}
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(token, claims, func(*jwt.Token) (interface{}, error) {
+		return j.verifyKey, nil
+	}, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrJWTInvalid, err)
+	}
+	return claims, nil
+}
@@ -0,0 +1,185 @@
+// pkg/auth/jwt_guard.go
+package auth
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/aasoft24/golara/wpkg/cache"
+	"github.com/aasoft24/golara/wpkg/gola"
+)
+
+// TokenPair is what the jwt guard hands to clients on login and refresh
+type TokenPair struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"` // access token seconds
+}
+
+// JWTGuard authenticates stateless clients with short-lived access tokens
+// sent as bearer tokens, and long-lived refresh tokens in the database that
+// are replaced on every use. Verified claims are available from c.Claims();
+// c.TokenCan and the abilities middleware read the "scopes" claim, set it
+// with JWT().Claims.
+type JWTGuard struct {
+	name     string
+	provider UserProvider
+	jwt      *JWT
+	// RefreshTokens stores the refresh tokens, database.DB by default
+	RefreshTokens *RefreshTokens
+	// denylist holds the jti of logged-out access tokens until they expire
+	denylist cache.Cache
+}
+
+// NewJWTGuard uses denylist for logged-out tokens; with several app
+// instances it must be shared between them. nil keeps it in memory.
+func NewJWTGuard(name string, provider UserProvider, j *JWT, denylist cache.Cache) *JWTGuard {
+	if denylist == nil {
+		denylist = cache.NewMemoryCache()
+	}
+	return &JWTGuard{name: name, provider: provider, jwt: j, RefreshTokens: NewRefreshTokens(nil), denylist: denylist}
+}
+
+// JWT returns the guard's signer, e.g. to set custom Claims
+func (g *JWTGuard) JWT() *JWT {
+	return g.jwt
+}
+
+func denylistKey(jti string) string {
+	return "jwt:denylist:" + jti
+}
+
+func (g *JWTGuard) User(c *gola.Context) (Authenticatable, error) {
+	if r, ok := cached(c, g.name); ok {
+		return r.user, nil
+	}
+	claims, user, err := g.resolve(BearerToken(c))
+	if err != nil {
+		return nil, err
+	}
+	if user != nil {
+		c.SetClaims(claims)
+	}
+	setUser(c, g.name, user)
+	return user, nil
+}
+
+// resolve returns the claims and user of a valid, not logged-out token
+func (g *JWTGuard) resolve(token string) (map[string]interface{}, Authenticatable, error) {
+	if token == "" {
+		return nil, nil, nil
+	}
+	claims, err := g.jwt.Parse(token)
+	if err != nil {
+		return nil, nil, nil
+	}
+	if jti, _ := claims["jti"].(string); jti == "" || g.denylist.Has(denylistKey(jti)) {
+		return nil, nil, nil
+	}
+	sub, _ := claims["sub"].(string)
+	id, err := strconv.ParseUint(sub, 10, 64)
+	if err != nil {
+		return nil, nil, nil
+	}
+	user, err := g.provider.RetrieveByID(uint(id))
+	if err != nil || user == nil {
+		return nil, nil, err
+	}
+	return claims, user, nil
+}
+
+func (g *JWTGuard) Check(c *gola.Context) bool {
+	user, _ := g.User(c)
+	return user != nil
+}
+
+func (g *JWTGuard) ID(c *gola.Context) uint {
+	if user, _ := g.User(c); user != nil {
+		return user.GetAuthID()
+	}
+	return 0
+}
+
+// Validate checks credentials["token"], an access token
+func (g *JWTGuard) Validate(credentials map[string]string) bool {
+	_, user, err := g.resolve(credentials["token"])
+	return err == nil && user != nil
+}
+
+// Attempt checks credentials like the session guard and returns a new token
+// pair, nil when they don't match
+func (g *JWTGuard) Attempt(credentials map[string]string) (*TokenPair, error) {
+	user, err := g.provider.RetrieveByCredentials(credentials)
+	if err != nil || user == nil {
+		return nil, err
+	}
+	if !g.provider.ValidateCredentials(user, credentials) {
+		return nil, nil
+	}
+	if err := g.provider.RehashPasswordIfRequired(user, credentials); err != nil {
+		return nil, err
+	}
+	return g.Login(user)
+}
+
+// Login issues a token pair for user, starting a new refresh token family
+func (g *JWTGuard) Login(user Authenticatable) (*TokenPair, error) {
+	return g.issue(user, "")
+}
+
+// Refresh spends refreshToken and returns a new pair of the same family.
+// A refresh token that was already spent revokes the family (ErrRefreshReused).
+func (g *JWTGuard) Refresh(refreshToken string) (*TokenPair, error) {
+	token, err := g.RefreshTokens.Use(g.name, refreshToken)
+	if err != nil {
+		return nil, err
+	}
+	user, err := g.provider.RetrieveByID(token.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrRefreshInvalid
+	}
+	return g.issue(user, token.Family)
+}
+
+func (g *JWTGuard) issue(user Authenticatable, family string) (*TokenPair, error) {
+	refresh, family, err := g.RefreshTokens.Create(g.name, user, family, g.jwt.RefreshTTL)
+	if err != nil {
+		return nil, err
+	}
+	access, _, err := g.jwt.Issue(user, family)
+	if err != nil {
+		return nil, err
+	}
+	return &TokenPair{
+		AccessToken:  access,
+		RefreshToken: refresh,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(g.jwt.TTL.Seconds()),
+	}, nil
+}
+
+// Logout denylists the request's access token until it expires and
+// revokes its refresh token family
+func (g *JWTGuard) Logout(c *gola.Context) error {
+	claims := c.Claims()
+	if claims == nil {
+		return nil
+	}
+	if jti, _ := claims["jti"].(string); jti != "" {
+		exp, _ := claims["exp"].(float64)
+		// keep it past the parser's leeway
+		ttl := time.Until(time.Unix(int64(exp), 0)) + time.Minute
+		if err := g.denylist.Set(denylistKey(jti), true, ttl); err != nil {
+			return err
+		}
+	}
+	setUser(c, g.name, nil)
+	if family, _ := claims["fam"].(string); family != "" {
+		return g.RefreshTokens.RevokeFamily(family)
+	}
+	return nil
+}
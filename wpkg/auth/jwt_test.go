@@ -0,0 +1,240 @@
+// pkg/auth/jwt_test.go
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aasoft24/golara/wpkg/configs"
+	"github.com/aasoft24/golara/wpkg/gola"
+	"github.com/golang-jwt/jwt/v5"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+const testSecret = "0123456789abcdef0123456789abcdef"
+
+type testUser struct {
+	ID       uint
+	Email    string
+	Password string
+}
+
+func (u *testUser) GetAuthID() uint         { return u.ID }
+func (u *testUser) GetAuthPassword() string { return u.Password }
+
+func testDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: gormlogger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB.SetMaxOpenConns(1) // every connection would get its own memory database
+	t.Cleanup(func() { sqlDB.Close() })
+	return db
+}
+
+func testJWT(t *testing.T) *JWT {
+	t.Helper()
+	j, err := NewJWT(configs.JWTConfig{Secret: testSecret, Issuer: "golara"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return j
+}
+
+func sign(t *testing.T, method jwt.SigningMethod, key interface{}, claims jwt.MapClaims) string {
+	t.Helper()
+	token, err := jwt.NewWithClaims(method, claims).SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func TestJWTParse(t *testing.T) {
+	j := testJWT(t)
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	claims := func(exp time.Time) jwt.MapClaims {
+		return jwt.MapClaims{"sub": "1", "iss": "golara", "iat": now.Unix(), "exp": exp.Unix(), "jti": "a"}
+	}
+	issued, _, err := j.Issue(&testUser{ID: 1}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		valid bool
+	}{
+		{"issued", issued, true},
+		{"hs256", sign(t, jwt.SigningMethodHS256, []byte(testSecret), claims(now.Add(time.Minute))), true},
+		{"alg none", sign(t, jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, claims(now.Add(time.Minute))), false},
+		{"hs512 same secret", sign(t, jwt.SigningMethodHS512, []byte(testSecret), claims(now.Add(time.Minute))), false},
+		{"rs256", sign(t, jwt.SigningMethodRS256, rsaKey, claims(now.Add(time.Minute))), false},
+		{"other secret", sign(t, jwt.SigningMethodHS256, []byte(testSecret+"x"), claims(now.Add(time.Minute))), false},
+		{"expired", sign(t, jwt.SigningMethodHS256, []byte(testSecret), claims(now.Add(-time.Minute))), false},
+		{"within leeway", sign(t, jwt.SigningMethodHS256, []byte(testSecret), claims(now.Add(-10*time.Second))), true},
+		{"no exp", sign(t, jwt.SigningMethodHS256, []byte(testSecret), jwt.MapClaims{"sub": "1", "iss": "golara"}), false},
+		{"other issuer", sign(t, jwt.SigningMethodHS256, []byte(testSecret), jwt.MapClaims{"sub": "1", "iss": "evil", "exp": now.Add(time.Minute).Unix()}), false},
+		{"garbage", "not.a.jwt", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := j.Parse(tt.token)
+			if tt.valid && err != nil {
+				t.Fatalf("Parse() error = %v, want valid", err)
+			}
+			if !tt.valid && !errors.Is(err, ErrJWTInvalid) {
+				t.Fatalf("Parse() error = %v, want ErrJWTInvalid", err)
+			}
+		})
+	}
+}
+
+func TestRefreshTokensUse(t *testing.T) {
+	tests := []struct {
+		name string
+		// run returns the family to inspect and the error of the last Use
+		run     func(t *testing.T, s *RefreshTokens) (string, error)
+		wantErr error
+		revoked bool // whether the whole family must be revoked afterwards
+	}{
+		{
+			name: "first use",
+			run: func(t *testing.T, s *RefreshTokens) (string, error) {
+				plain, fam := create(t, s, "", time.Hour)
+				_, err := s.Use("jwt", plain)
+				return fam, err
+			},
+		},
+		{
+			name: "reuse revokes the family",
+			run: func(t *testing.T, s *RefreshTokens) (string, error) {
+				plain, fam := create(t, s, "", time.Hour)
+				if _, err := s.Use("jwt", plain); err != nil {
+					t.Fatal(err)
+				}
+				next, _ := create(t, s, fam, time.Hour) // the rotated token
+				_, err := s.Use("jwt", plain)
+				if _, nextErr := s.Use("jwt", next); !errors.Is(nextErr, ErrRefreshReused) {
+					t.Fatalf("rotated token: error = %v, want ErrRefreshReused", nextErr)
+				}
+				return fam, err
+			},
+			wantErr: ErrRefreshReused,
+			revoked: true,
+		},
+		{
+			name: "expired",
+			run: func(t *testing.T, s *RefreshTokens) (string, error) {
+				plain, fam := create(t, s, "", -time.Minute)
+				_, err := s.Use("jwt", plain)
+				return fam, err
+			},
+			wantErr: ErrRefreshInvalid,
+		},
+		{
+			name: "other guard",
+			run: func(t *testing.T, s *RefreshTokens) (string, error) {
+				plain, fam := create(t, s, "", time.Hour)
+				_, err := s.Use("api", plain)
+				return fam, err
+			},
+			wantErr: ErrRefreshInvalid,
+		},
+		{
+			name: "unknown",
+			run: func(t *testing.T, s *RefreshTokens) (string, error) {
+				_, err := s.Use("jwt", "unknown")
+				return "", err
+			},
+			wantErr: ErrRefreshInvalid,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewRefreshTokens(testDB(t))
+			if err := s.Migrate(); err != nil {
+				t.Fatal(err)
+			}
+			fam, err := tt.run(t, s)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Use() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.revoked {
+				var open int64
+				s.conn().Model(&RefreshToken{}).Where("family = ? AND revoked_at IS NULL", fam).Count(&open)
+				if open != 0 {
+					t.Fatalf("%d tokens of the family are still usable", open)
+				}
+			}
+		})
+	}
+}
+
+func create(t *testing.T, s *RefreshTokens, family string, ttl time.Duration) (plain, fam string) {
+	t.Helper()
+	plain, fam, err := s.Create("jwt", &testUser{ID: 1}, family, ttl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return plain, fam
+}
+
+func TestJWTGuardLogout(t *testing.T) {
+	db := testDB(t)
+	if err := db.AutoMigrate(&testUser{}); err != nil {
+		t.Fatal(err)
+	}
+	user := &testUser{Email: "a@example.com"}
+	db.Create(user)
+
+	g := NewJWTGuard("jwt", NewGormProvider[testUser](db), testJWT(t), nil)
+	g.RefreshTokens = NewRefreshTokens(db)
+	if err := g.RefreshTokens.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	pair, err := g.Login(user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	request := func() *gola.Context {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("Authorization", "Bearer "+pair.AccessToken)
+		return gola.NewContext(httptest.NewRecorder(), r)
+	}
+
+	c := request()
+	if u, err := g.User(c); err != nil || u == nil {
+		t.Fatalf("User() = %v, %v before logout", u, err)
+	}
+	if err := g.Logout(c); err != nil {
+		t.Fatal(err)
+	}
+
+	jti, _ := c.Claims()["jti"].(string)
+	if !g.denylist.Has(denylistKey(jti)) {
+		t.Fatalf("jti %q is not denylisted", jti)
+	}
+	if u, _ := g.User(request()); u != nil {
+		t.Fatal("the logged-out access token still authenticates")
+	}
+	if _, err := g.Refresh(pair.RefreshToken); !errors.Is(err, ErrRefreshReused) {
+		t.Fatalf("Refresh() error = %v, want ErrRefreshReused", err)
+	}
+}
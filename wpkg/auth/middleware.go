@@ -0,0 +1,132 @@
+// pkg/auth/middleware.go
+package auth
+
+import (
+	"net/http"
+
+	"github.com/aasoft24/golara/wpkg/configs"
+	"github.com/aasoft24/golara/wpkg/gola"
+	"github.com/aasoft24/golara/wpkg/logger"
+)
+
+// Authenticate lets the request through when one of guards (the default
+// guard without any) has a user, which becomes the default for auth.User.
+// Guests get 401 JSON, or are redirected to auth.login_path and sent back
+// after login by c.RedirectIntended.
+func Authenticate(guards ...string) func(next func(ctx *gola.Context)) func(ctx *gola.Context) {
+	if len(guards) == 0 {
+		guards = []string{""}
+	}
+	return func(next func(ctx *gola.Context)) func(ctx *gola.Context) {
+		return func(ctx *gola.Context) {
+			for _, name := range guards {
+				g, err := Get(name)
+				if err != nil {
+					guardError(ctx, err)
+					return
+				}
+				if user, err := g.User(ctx); err == nil && user != nil {
+					// a later auth.User(ctx) on the default guard sees this user too
+					setUser(ctx, defaultGuard(), user)
+					next(ctx)
+					return
+				}
+			}
+			unauthenticated(ctx)
+		}
+	}
+}
+
+// RedirectIfAuthenticated keeps logged-in users away from guest pages such
+// as login and register, sending them to auth.home
+func RedirectIfAuthenticated(guards ...string) func(next func(ctx *gola.Context)) func(ctx *gola.Context) {
+	if len(guards) == 0 {
+		guards = []string{""}
+	}
+	return func(next func(ctx *gola.Context)) func(ctx *gola.Context) {
+		return func(ctx *gola.Context) {
+			for _, name := range guards {
+				g, err := Get(name)
+				if err != nil {
+					guardError(ctx, err)
+					return
+				}
+				if g.Check(ctx) {
+					if ctx.WantsJSON() {
+						ctx.JSON(http.StatusForbidden, map[string]interface{}{"error": "Already authenticated"})
+						return
+					}
+					ctx.RedirectTo(home())
+					ctx.SendPending()
+					return
+				}
+			}
+			next(ctx)
+		}
+	}
+}
+
+// Abilities lets the request through when its token grants every one of
+// abilities (see c.TokenCan); use it after Authenticate
+func Abilities(abilities ...string) func(next func(ctx *gola.Context)) func(ctx *gola.Context) {
+	return requireAbilities(abilities, true)
+}
+
+// AnyAbility lets the request through when its token grants one of abilities
+func AnyAbility(abilities ...string) func(next func(ctx *gola.Context)) func(ctx *gola.Context) {
+	return requireAbilities(abilities, false)
+}
+
+func requireAbilities(abilities []string, all bool) func(next func(ctx *gola.Context)) func(ctx *gola.Context) {
+	return func(next func(ctx *gola.Context)) func(ctx *gola.Context) {
+		return func(ctx *gola.Context) {
+			if !ctx.Check() {
+				unauthenticated(ctx)
+				return
+			}
+			granted := 0
+			for _, a := range abilities {
+				if ctx.TokenCan(a) {
+					granted++
+				}
+			}
+			if (all && granted < len(abilities)) || (!all && granted == 0) {
+				ctx.JSON(http.StatusForbidden, map[string]interface{}{"error": "Invalid ability provided"})
+				return
+			}
+			next(ctx)
+		}
+	}
+}
+
+// guardError answers a misconfigured guard with 500 and logs the cause
+func guardError(ctx *gola.Context, err error) {
+	logger.Error(err.Error())
+	ctx.Error(http.StatusInternalServerError, "Internal Server Error")
+}
+
+func unauthenticated(ctx *gola.Context) {
+	if ctx.WantsJSON() || ctx.Session == nil {
+		ctx.JSON(http.StatusUnauthorized, map[string]interface{}{"error": "Unauthenticated"})
+		return
+	}
+	if ctx.Request.Method == http.MethodGet {
+		ctx.SetIntended()
+	}
+	ctx.RedirectTo(loginPath())
+	ctx.SendPending()
+}
+
+func loginPath() string {
+	if cfg := configs.GConfig; cfg != nil && cfg.Auth.LoginPath != "" {
+		return cfg.Auth.LoginPath
+	}
+	return "/login"
+}
+
+func home() string {
+	if cfg := configs.GConfig; cfg != nil && cfg.Auth.Home != "" {
+		return cfg.Auth.Home
+	}
+	return "/"
+}
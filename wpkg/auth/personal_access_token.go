@@ -0,0 +1,208 @@
+// pkg/auth/personal_access_token.go
+package auth
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aasoft24/golara/wpkg/configs"
+	"github.com/aasoft24/golara/wpkg/database"
+	"github.com/aasoft24/golara/wpkg/gola"
+	"gorm.io/gorm"
+)
+
+// PersonalAccessToken is a row of the personal_access_tokens table. Only the
+// SHA-256 of the token is kept; the plain text is shown once, by CreateToken.
+type PersonalAccessToken struct {
+	ID            uint       `gorm:"primaryKey" json:"id"`
+	TokenableType string     `gorm:"size:100;index:idx_tokenable" json:"-"`
+	TokenableID   uint       `gorm:"index:idx_tokenable" json:"-"`
+	Name          string     `gorm:"size:255" json:"name"`
+	Token         string     `gorm:"size:64;uniqueIndex" json:"-"`
+	Abilities     string     `gorm:"type:text" json:"-"` // JSON array
+	LastUsedAt    *time.Time `json:"last_used_at"`
+	ExpiresAt     *time.Time `gorm:"index" json:"expires_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+func (PersonalAccessToken) TableName() string {
+	return "personal_access_tokens"
+}
+
+// AbilityList returns the abilities the token was created with
+func (t *PersonalAccessToken) AbilityList() []string {
+	var abilities []string
+	json.Unmarshal([]byte(t.Abilities), &abilities)
+	return abilities
+}
+
+// Can reports whether the token grants ability: "*" grants all, and
+// "orders:*" every ability starting with "orders:"
+func (t *PersonalAccessToken) Can(ability string) bool {
+	return gola.AbilityGranted(t.AbilityList(), ability)
+}
+
+// Cant is the opposite of Can
+func (t *PersonalAccessToken) Cant(ability string) bool {
+	return !t.Can(ability)
+}
+
+// Expired reports whether the token passed its expiry or auth.token_expiration
+func (t *PersonalAccessToken) Expired() bool {
+	now := time.Now()
+	if t.ExpiresAt != nil && !now.Before(*t.ExpiresAt) {
+		return true
+	}
+	if cfg := configs.GConfig; cfg != nil && cfg.Auth.TokenExpiration > 0 {
+		return !now.Before(t.CreatedAt.Add(time.Duration(cfg.Auth.TokenExpiration) * time.Minute))
+	}
+	return false
+}
+
+// NewAccessToken is a freshly created token and its plain text, "<id>|<secret>"
+type NewAccessToken struct {
+	AccessToken    *PersonalAccessToken `json:"access_token"`
+	PlainTextToken string               `json:"plain_text_token"`
+}
+
+// AccessTokens stores personal access tokens
+type AccessTokens struct {
+	db *gorm.DB
+}
+
+// NewAccessTokens uses db, or database.DB when db is nil
+func NewAccessTokens(db *gorm.DB) *AccessTokens {
+	return &AccessTokens{db: db}
+}
+
+// Tokens is used by CreateToken and by guards without their own store
+var Tokens = NewAccessTokens(nil)
+
+// lastUsedInterval limits last_used_at writes to one per token and interval
+const lastUsedInterval = time.Minute
+
+func (s *AccessTokens) conn() *gorm.DB {
+	if s.db != nil {
+		return s.db
+	}
+	return database.DB
+}
+
+// Migrate creates or updates the personal_access_tokens table
+func (s *AccessTokens) Migrate() error {
+	return s.conn().AutoMigrate(&PersonalAccessToken{})
+}
+
+// tokenableType names the user's model, e.g. "models.User", so tokens of
+// different models with the same ID don't mix
+func tokenableType(user Authenticatable) string {
+	t := reflect.TypeOf(user)
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t.String()
+}
+
+// Create stores a token for user. Without abilities it gets "*"; a nil
+// expiresAt falls back to auth.token_expiration.
+func (s *AccessTokens) Create(user Authenticatable, name string, abilities []string, expiresAt *time.Time) (*NewAccessToken, error) {
+	if len(abilities) == 0 {
+		abilities = []string{"*"}
+	}
+	encoded, err := json.Marshal(abilities)
+	if err != nil {
+		return nil, err
+	}
+	secret := randomToken()
+	token := &PersonalAccessToken{
+		TokenableType: tokenableType(user),
+		TokenableID:   user.GetAuthID(),
+		Name:          name,
+		Token:         hashToken(secret),
+		Abilities:     string(encoded),
+		ExpiresAt:     expiresAt,
+	}
+	if err := s.conn().Create(token).Error; err != nil {
+		return nil, err
+	}
+	return &NewAccessToken{
+		AccessToken:    token,
+		PlainTextToken: strconv.FormatUint(uint64(token.ID), 10) + "|" + secret,
+	}, nil
+}
+
+// Find returns the token for plain, nil when it doesn't exist
+func (s *AccessTokens) Find(plain string) (*PersonalAccessToken, error) {
+	if plain == "" {
+		return nil, nil
+	}
+	var token PersonalAccessToken
+	var err error
+	if id, secret, ok := strings.Cut(plain, "|"); ok {
+		n, perr := strconv.ParseUint(id, 10, 64)
+		if perr != nil {
+			return nil, nil
+		}
+		err = s.conn().Take(&token, uint(n)).Error
+		if err == nil && subtle.ConstantTimeCompare([]byte(token.Token), []byte(hashToken(secret))) != 1 {
+			return nil, nil
+		}
+	} else {
+		err = s.conn().Where("token = ?", hashToken(plain)).Take(&token).Error
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// Touch records that token was used, at most once per lastUsedInterval
+func (s *AccessTokens) Touch(token *PersonalAccessToken) error {
+	now := time.Now()
+	if token.LastUsedAt != nil && now.Sub(*token.LastUsedAt) < lastUsedInterval {
+		return nil
+	}
+	token.LastUsedAt = &now
+	return s.conn().Model(token).UpdateColumn("last_used_at", now).Error
+}
+
+// For lists user's tokens, newest first
+func (s *AccessTokens) For(user Authenticatable) ([]PersonalAccessToken, error) {
+	var tokens []PersonalAccessToken
+	err := s.owned(user).Order("id DESC").Find(&tokens).Error
+	return tokens, err
+}
+
+// Revoke deletes one of user's tokens; other users' tokens are left alone
+func (s *AccessTokens) Revoke(user Authenticatable, id uint) error {
+	return s.owned(user).Where("id = ?", id).Delete(&PersonalAccessToken{}).Error
+}
+
+// RevokeAll deletes every token of user
+func (s *AccessTokens) RevokeAll(user Authenticatable) error {
+	return s.owned(user).Delete(&PersonalAccessToken{}).Error
+}
+
+// Prune deletes tokens that expired before cutoff, e.g. from a scheduled job
+func (s *AccessTokens) Prune(cutoff time.Time) (int64, error) {
+	res := s.conn().Where("expires_at < ?", cutoff).Delete(&PersonalAccessToken{})
+	return res.RowsAffected, res.Error
+}
+
+func (s *AccessTokens) owned(user Authenticatable) *gorm.DB {
+	return s.conn().Where("tokenable_type = ? AND tokenable_id = ?", tokenableType(user), user.GetAuthID())
+}
+
+// CreateToken creates a personal access token for user in Tokens. Show
+// PlainTextToken to the user now; it can't be recovered later.
+func CreateToken(user Authenticatable, name string, abilities []string, expiresAt *time.Time) (*NewAccessToken, error) {
+	return Tokens.Create(user, name, abilities, expiresAt)
+}
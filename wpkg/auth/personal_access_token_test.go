@@ -0,0 +1,292 @@
+// pkg/auth/personal_access_token_test.go
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aasoft24/golara/wpkg/gola"
+	"github.com/aasoft24/golara/wpkg/session"
+)
+
+type apiUser struct {
+	ID       uint
+	Password string
+	ApiToken string
+}
+
+func (u *apiUser) GetAuthID() uint         { return u.ID }
+func (u *apiUser) GetAuthPassword() string { return u.Password }
+
+func TestAccessTokenCan(t *testing.T) {
+	tests := []struct {
+		name      string
+		abilities []string
+		ability   string
+		want      bool
+	}{
+		{"no abilities grant all", nil, "orders:write", true},
+		{"wildcard", []string{"*"}, "orders:write", true},
+		{"exact", []string{"orders:read"}, "orders:read", true},
+		{"other ability", []string{"orders:read"}, "orders:write", false},
+		{"prefix wildcard", []string{"orders:*"}, "orders:write", true},
+		{"prefix wildcard other group", []string{"orders:*"}, "users:read", false},
+		{"group without wildcard", []string{"orders"}, "orders:read", false},
+		{"one of several", []string{"users:read", "orders:write"}, "orders:write", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewAccessTokens(testDB(t))
+			if err := s.Migrate(); err != nil {
+				t.Fatal(err)
+			}
+			created, err := s.Create(&testUser{ID: 1}, "api", tt.abilities, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			token, err := s.Find(created.PlainTextToken)
+			if err != nil || token == nil {
+				t.Fatalf("Find() = %v, %v", token, err)
+			}
+			if got := token.Can(tt.ability); got != tt.want {
+				t.Fatalf("Can(%q) = %v, want %v", tt.ability, got, tt.want)
+			}
+			if token.Cant(tt.ability) == tt.want {
+				t.Fatalf("Cant(%q) = %v, want %v", tt.ability, !tt.want, !tt.want)
+			}
+		})
+	}
+}
+
+func TestAccessTokenGuard(t *testing.T) {
+	past := time.Now().Add(-time.Minute)
+	future := time.Now().Add(time.Hour)
+	tests := []struct {
+		name    string
+		expires *time.Time
+		// bearer turns the plain text token into the header value
+		bearer func(plain string) string
+		valid  bool
+	}{
+		{"valid", nil, func(p string) string { return p }, true},
+		{"not yet expired", &future, func(p string) string { return p }, true},
+		{"secret only", nil, func(p string) string { _, s, _ := strings.Cut(p, "|"); return s }, true},
+		{"expired", &past, func(p string) string { return p }, false},
+		{"wrong secret", nil, func(p string) string { return p + "x" }, false},
+		{"unknown id", nil, func(p string) string { _, s, _ := strings.Cut(p, "|"); return "99|" + s }, false},
+		{"bad id", nil, func(p string) string { _, s, _ := strings.Cut(p, "|"); return "x|" + s }, false},
+		{"none", nil, func(string) string { return "" }, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := testDB(t)
+			if err := db.AutoMigrate(&testUser{}); err != nil {
+				t.Fatal(err)
+			}
+			user := &testUser{Email: "a@example.com"}
+			db.Create(user)
+			s := NewAccessTokens(db)
+			if err := s.Migrate(); err != nil {
+				t.Fatal(err)
+			}
+			created, err := s.Create(user, "api", []string{"orders:read"}, tt.expires)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			r := httptest.NewRequest("GET", "/", nil)
+			if bearer := tt.bearer(created.PlainTextToken); bearer != "" {
+				r.Header.Set("Authorization", "Bearer "+bearer)
+			}
+			c := gola.NewContext(httptest.NewRecorder(), r)
+			g := NewAccessTokenGuard("api", NewGormProvider[testUser](db), s)
+			u, err := g.User(c)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if (u != nil) != tt.valid {
+				t.Fatalf("User() = %v, want valid = %v", u, tt.valid)
+			}
+			if got := CurrentAccessToken(c) != nil; got != tt.valid {
+				t.Fatalf("CurrentAccessToken() set = %v, want %v", got, tt.valid)
+			}
+		})
+	}
+}
+
+func TestTokenCan(t *testing.T) {
+	db := testDB(t)
+	if err := db.AutoMigrate(&testUser{}, &apiUser{}); err != nil {
+		t.Fatal(err)
+	}
+	user := &testUser{Email: "a@example.com"}
+	db.Create(user)
+	db.Create(&apiUser{ApiToken: "legacy"})
+	tokens := NewAccessTokens(db)
+	if err := tokens.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	pat, err := tokens.Create(user, "api", []string{"orders:read"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessions := session.NewManager(session.NewMemoryStore(), "go_session")
+
+	request := func(bearer string) *gola.Context {
+		r := httptest.NewRequest("GET", "/", nil)
+		if bearer != "" {
+			r.Header.Set("Authorization", "Bearer "+bearer)
+		}
+		return gola.NewContext(httptest.NewRecorder(), r)
+	}
+	tests := []struct {
+		name string
+		// authenticate runs the guard of the request
+		authenticate func(t *testing.T) *gola.Context
+		want         map[string]bool
+	}{
+		{
+			name: "personal access token",
+			authenticate: func(t *testing.T) *gola.Context {
+				c := request(pat.PlainTextToken)
+				NewAccessTokenGuard("api", NewGormProvider[testUser](db), tokens).User(c)
+				return c
+			},
+			want: map[string]bool{"orders:read": true, "orders:write": false},
+		},
+		{
+			name: "jwt scopes string",
+			authenticate: func(t *testing.T) *gola.Context {
+				c := request("")
+				c.Set("User", user)
+				c.SetClaims(map[string]interface{}{"scopes": "orders:read users:*"})
+				return c
+			},
+			want: map[string]bool{"orders:read": true, "users:write": true, "orders:write": false},
+		},
+		{
+			name: "jwt scopes list",
+			authenticate: func(t *testing.T) *gola.Context {
+				c := request("")
+				c.Set("User", user)
+				c.SetClaims(map[string]interface{}{"scopes": []interface{}{"orders:read"}})
+				return c
+			},
+			want: map[string]bool{"orders:read": true, "orders:write": false},
+		},
+		{
+			name: "jwt oauth2 scope",
+			authenticate: func(t *testing.T) *gola.Context {
+				c := request("")
+				c.Set("User", user)
+				c.SetClaims(map[string]interface{}{"scope": "orders:write"})
+				return c
+			},
+			want: map[string]bool{"orders:write": true, "orders:read": false},
+		},
+		{
+			name: "jwt without scopes",
+			authenticate: func(t *testing.T) *gola.Context {
+				c := request("")
+				c.Set("User", user)
+				c.SetClaims(map[string]interface{}{"sub": "1"})
+				return c
+			},
+			want: map[string]bool{"orders:read": false},
+		},
+		{
+			name: "session user",
+			authenticate: func(t *testing.T) *gola.Context {
+				c := request("")
+				sess, err := sessions.Start(c.Writer, c.Request)
+				if err != nil {
+					t.Fatal(err)
+				}
+				c.Session, c.SessionManager = sess, sessions
+				if err := NewSessionGuard("web", NewGormProvider[testUser](db)).Login(c, user); err != nil {
+					t.Fatal(err)
+				}
+				return c
+			},
+			want: map[string]bool{"orders:read": true, "anything": true},
+		},
+		{
+			name: "api_token user",
+			authenticate: func(t *testing.T) *gola.Context {
+				c := request("legacy")
+				if u, err := NewTokenGuard("token", NewGormProvider[apiUser](db)).User(c); err != nil || u == nil {
+					t.Fatalf("User() = %v, %v", u, err)
+				}
+				return c
+			},
+			want: map[string]bool{"orders:read": false},
+		},
+		{
+			name:         "guest",
+			authenticate: func(t *testing.T) *gola.Context { return request("") },
+			want:         map[string]bool{"orders:read": false},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := tt.authenticate(t)
+			for ability, want := range tt.want {
+				if got := c.TokenCan(ability); got != want {
+					t.Errorf("TokenCan(%q) = %v, want %v", ability, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestAbilitiesMiddleware(t *testing.T) {
+	db := testDB(t)
+	if err := db.AutoMigrate(&testUser{}); err != nil {
+		t.Fatal(err)
+	}
+	user := &testUser{Email: "a@example.com"}
+	db.Create(user)
+	tokens := NewAccessTokens(db)
+	if err := tokens.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	pat, err := tokens.Create(user, "api", []string{"orders:read", "users:read"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := NewAccessTokenGuard("api", NewGormProvider[testUser](db), tokens)
+
+	tests := []struct {
+		name       string
+		bearer     string
+		middleware func(next func(ctx *gola.Context)) func(ctx *gola.Context)
+		want       int
+	}{
+		{"all granted", pat.PlainTextToken, Abilities("orders:read", "users:read"), http.StatusOK},
+		{"one missing", pat.PlainTextToken, Abilities("orders:read", "orders:write"), http.StatusForbidden},
+		{"any granted", pat.PlainTextToken, AnyAbility("orders:write", "users:read"), http.StatusOK},
+		{"none granted", pat.PlainTextToken, AnyAbility("orders:write"), http.StatusForbidden},
+		{"guest", "", Abilities("orders:read"), http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/", nil)
+			r.Header.Set("Accept", "application/json")
+			if tt.bearer != "" {
+				r.Header.Set("Authorization", "Bearer "+tt.bearer)
+			}
+			w := httptest.NewRecorder()
+			c := gola.NewContext(w, r)
+			if _, err := g.User(c); err != nil {
+				t.Fatal(err)
+			}
+			tt.middleware(func(ctx *gola.Context) { ctx.String(http.StatusOK, "ok") })(c)
+			if w.Code != tt.want {
+				t.Fatalf("status = %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
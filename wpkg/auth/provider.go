@@ -0,0 +1,97 @@
+// pkg/auth/provider.go
+package auth
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/aasoft24/golara/wpkg/database"
+	"github.com/aasoft24/golara/wpkg/hashing"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// UserProvider loads users for the guards
+type UserProvider interface {
+	// RetrieveByID returns nil, nil when there is no such user
+	RetrieveByID(id uint) (Authenticatable, error)
+	// RetrieveByCredentials finds a user by every credential except the password
+	RetrieveByCredentials(credentials map[string]string) (Authenticatable, error)
+	// ValidateCredentials checks credentials["password"] against the user's hash
+	ValidateCredentials(user Authenticatable, credentials map[string]string) bool
+	// RehashPasswordIfRequired stores a new hash when the hashing parameters changed
+	RehashPasswordIfRequired(user Authenticatable, credentials map[string]string) error
+}
+
+// GormProvider loads users of model T (*T must implement Authenticatable)
+type GormProvider[T any] struct {
+	db             *gorm.DB
+	PasswordColumn string // default password
+	RememberColumn string // hashed remember token, default remember_token
+}
+
+// NewGormProvider uses db, or database.DB when db is nil
+func NewGormProvider[T any](db *gorm.DB) *GormProvider[T] {
+	return &GormProvider[T]{db: db, PasswordColumn: "password", RememberColumn: "remember_token"}
+}
+
+func (p *GormProvider[T]) conn() *gorm.DB {
+	if p.db != nil {
+		return p.db
+	}
+	return database.DB
+}
+
+func (p *GormProvider[T]) find(query *gorm.DB, conds ...interface{}) (Authenticatable, error) {
+	var user T
+	err := query.Take(&user, conds...).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	a, ok := any(&user).(Authenticatable)
+	if !ok {
+		return nil, errors.New("auth: the user model does not implement auth.Authenticatable")
+	}
+	return a, nil
+}
+
+func (p *GormProvider[T]) RetrieveByID(id uint) (Authenticatable, error) {
+	if id == 0 {
+		return nil, nil
+	}
+	return p.find(p.conn(), id)
+}
+
+func (p *GormProvider[T]) RetrieveByCredentials(credentials map[string]string) (Authenticatable, error) {
+	query := p.conn()
+	n := 0
+	for key, value := range credentials {
+		if strings.Contains(key, "password") || key == p.RememberColumn {
+			continue
+		}
+		// clause.Eq quotes the column, so keys can't inject SQL
+		query = query.Where(clause.Eq{Column: clause.Column{Name: key}, Value: value})
+		n++
+	}
+	if n == 0 {
+		return nil, nil
+	}
+	return p.find(query)
+}
+
+func (p *GormProvider[T]) ValidateCredentials(user Authenticatable, credentials map[string]string) bool {
+	return hashing.Check(credentials["password"], user.GetAuthPassword())
+}
+
+func (p *GormProvider[T]) RehashPasswordIfRequired(user Authenticatable, credentials map[string]string) error {
+	if !hashing.NeedsRehash(user.GetAuthPassword()) {
+		return nil
+	}
+	hash, err := hashing.Make(credentials["password"])
+	if err != nil {
+		return err
+	}
+	return p.conn().Model(user).Update(p.PasswordColumn, hash).Error
+}
@@ -0,0 +1,125 @@
+// pkg/auth/refresh_token.go
+package auth
+
+import (
+	"errors"
+	"time"
+
+	"github.com/aasoft24/golara/wpkg/database"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrRefreshInvalid = errors.New("auth: the refresh token is invalid or expired")
+	// ErrRefreshReused means a rotated refresh token came back: it was
+	// probably stolen, so its whole family is revoked
+	ErrRefreshReused = errors.New("auth: the refresh token was already used")
+)
+
+// RefreshToken is a row of the refresh_tokens table. Every refresh replaces
+// the token with a new one of the same family; only the SHA-256 is kept.
+type RefreshToken struct {
+	ID        uint       `gorm:"primaryKey"`
+	Guard     string     `gorm:"size:50;index:idx_refresh_user"`
+	UserID    uint       `gorm:"index:idx_refresh_user"`
+	Family    string     `gorm:"size:64;index"`
+	Token     string     `gorm:"size:64;uniqueIndex"`
+	ExpiresAt time.Time  `gorm:"index"`
+	RevokedAt *time.Time `gorm:"index"`
+	CreatedAt time.Time
+}
+
+func (RefreshToken) TableName() string {
+	return "refresh_tokens"
+}
+
+// RefreshTokens stores refresh tokens
+type RefreshTokens struct {
+	db *gorm.DB
+}
+
+// NewRefreshTokens uses db, or database.DB when db is nil
+func NewRefreshTokens(db *gorm.DB) *RefreshTokens {
+	return &RefreshTokens{db: db}
+}
+
+func (s *RefreshTokens) conn() *gorm.DB {
+	if s.db != nil {
+		return s.db
+	}
+	return database.DB
+}
+
+// Migrate creates or updates the refresh_tokens table
+func (s *RefreshTokens) Migrate() error {
+	return s.conn().AutoMigrate(&RefreshToken{})
+}
+
+// Create stores a refresh token for user; an empty family starts a new one
+func (s *RefreshTokens) Create(guard string, user Authenticatable, family string, ttl time.Duration) (plain, fam string, err error) {
+	if family == "" {
+		family = randomToken()[:32]
+	}
+	plain = randomToken()
+	err = s.conn().Create(&RefreshToken{
+		Guard:     guard,
+		UserID:    user.GetAuthID(),
+		Family:    family,
+		Token:     hashToken(plain),
+		ExpiresAt: time.Now().Add(ttl),
+	}).Error
+	return plain, family, err
+}
+
+// Use spends plain, which can be used once, and returns its row. Using a
+// spent token revokes the family and returns ErrRefreshReused.
+func (s *RefreshTokens) Use(guard, plain string) (*RefreshToken, error) {
+	var token RefreshToken
+	err := s.conn().Where("token = ? AND guard = ?", hashToken(plain), guard).Take(&token).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrRefreshInvalid
+	} else if err != nil {
+		return nil, err
+	}
+	if token.RevokedAt != nil {
+		if err := s.RevokeFamily(token.Family); err != nil {
+			return nil, err
+		}
+		return nil, ErrRefreshReused
+	}
+	if time.Now().After(token.ExpiresAt) {
+		return nil, ErrRefreshInvalid
+	}
+	// the revoked_at condition lets only one of two concurrent refreshes win
+	res := s.conn().Model(&RefreshToken{}).
+		Where("id = ? AND revoked_at IS NULL", token.ID).
+		Update("revoked_at", time.Now())
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return nil, ErrRefreshReused
+	}
+	return &token, nil
+}
+
+// RevokeFamily ends a login: no token of the family can be refreshed
+func (s *RefreshTokens) RevokeFamily(family string) error {
+	return s.conn().Model(&RefreshToken{}).
+		Where("family = ? AND revoked_at IS NULL", family).
+		Update("revoked_at", time.Now()).Error
+}
+
+// RevokeUser ends every login of user with guard
+func (s *RefreshTokens) RevokeUser(guard string, user Authenticatable) error {
+	return s.conn().Model(&RefreshToken{}).
+		Where("guard = ? AND user_id = ? AND revoked_at IS NULL", guard, user.GetAuthID()).
+		Update("revoked_at", time.Now()).Error
+}
+
+// Prune deletes tokens that expired before cutoff. Spent tokens are kept
+// until then, so their reuse is still detected.
+func (s *RefreshTokens) Prune(cutoff time.Time) (int64, error) {
+	res := s.conn().Where("expires_at < ?", cutoff).Delete(&RefreshToken{})
+	return res.RowsAffected, res.Error
+}
@@ -0,0 +1,161 @@
+// pkg/auth/remember.go
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aasoft24/golara/wpkg/configs"
+	"github.com/aasoft24/golara/wpkg/gola"
+)
+
+// maxRememberTokens is how many devices a user can stay remembered on
+const maxRememberTokens = 5
+
+// Rememberable is implemented by user models with a remember token column
+type Rememberable interface {
+	// GetRememberToken returns the stored token hashes, space separated
+	GetRememberToken() string
+}
+
+// RememberProvider is implemented by providers that support remember-me
+type RememberProvider interface {
+	// RetrieveByToken returns the user if token is one of theirs, nil otherwise
+	RetrieveByToken(id uint, token string) (Authenticatable, error)
+	// AddRememberToken stores the hash of a new device token, dropping the
+	// oldest beyond maxRememberTokens
+	AddRememberToken(user Authenticatable, token string) error
+	// RemoveRememberToken revokes one device token
+	RemoveRememberToken(user Authenticatable, token string) error
+	// RevokeRememberTokens revokes the tokens of every device
+	RevokeRememberTokens(user Authenticatable) error
+	// UpdatePassword stores a new password hash
+	UpdatePassword(user Authenticatable, hash string) error
+}
+
+func rememberHashes(user Authenticatable) []string {
+	if r, ok := user.(Rememberable); ok {
+		return strings.Fields(r.GetRememberToken())
+	}
+	return nil
+}
+
+func (p *GormProvider[T]) RetrieveByToken(id uint, token string) (Authenticatable, error) {
+	user, err := p.RetrieveByID(id)
+	if err != nil || user == nil {
+		return nil, err
+	}
+	want := []byte(hashToken(token))
+	for _, h := range rememberHashes(user) {
+		if subtle.ConstantTimeCompare([]byte(h), want) == 1 {
+			return user, nil
+		}
+	}
+	return nil, nil
+}
+
+func (p *GormProvider[T]) AddRememberToken(user Authenticatable, token string) error {
+	hashes := append(rememberHashes(user), hashToken(token))
+	if len(hashes) > maxRememberTokens {
+		hashes = hashes[len(hashes)-maxRememberTokens:]
+	}
+	return p.setRememberHashes(user, hashes)
+}
+
+func (p *GormProvider[T]) RemoveRememberToken(user Authenticatable, token string) error {
+	revoked := hashToken(token)
+	var kept []string
+	for _, h := range rememberHashes(user) {
+		if h != revoked {
+			kept = append(kept, h)
+		}
+	}
+	return p.setRememberHashes(user, kept)
+}
+
+func (p *GormProvider[T]) RevokeRememberTokens(user Authenticatable) error {
+	return p.setRememberHashes(user, nil)
+}
+
+func (p *GormProvider[T]) setRememberHashes(user Authenticatable, hashes []string) error {
+	return p.conn().Model(user).Update(p.RememberColumn, strings.Join(hashes, " ")).Error
+}
+
+func (p *GormProvider[T]) UpdatePassword(user Authenticatable, hash string) error {
+	return p.conn().Model(user).Update(p.PasswordColumn, hash).Error
+}
+
+// hashToken is what the database keeps, so a leaked table can't log anyone in
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomToken() string {
+	b := make([]byte, 40)
+	rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// passwordFingerprint changes whenever the password hash does, so sessions
+// and remember cookies issued before a password change stop working
+func passwordFingerprint(user Authenticatable) string {
+	sum := sha256.Sum256([]byte(user.GetAuthPassword()))
+	return hex.EncodeToString(sum[:16])
+}
+
+// recaller is the parsed remember cookie: id|token|fingerprint
+type recaller struct {
+	id          uint
+	token       string
+	fingerprint string
+}
+
+func parseRecaller(value string) (recaller, bool) {
+	parts := strings.Split(value, "|")
+	if len(parts) != 3 || parts[1] == "" {
+		return recaller{}, false
+	}
+	id, err := strconv.ParseUint(parts[0], 10, 64)
+	if err != nil || id == 0 {
+		return recaller{}, false
+	}
+	return recaller{id: uint(id), token: parts[1], fingerprint: parts[2]}, true
+}
+
+// rememberLifetime is auth.remember_days, 30 days by default
+func rememberLifetime() time.Duration {
+	days := 30
+	if cfg := configs.GConfig; cfg != nil && cfg.Auth.RememberDays > 0 {
+		days = cfg.Auth.RememberDays
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// rememberCookie builds the cookie with the session cookie's path, domain
+// and security; maxAge < 0 deletes it
+func rememberCookie(c *gola.Context, name, value string, maxAge int) *http.Cookie {
+	cookie := &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     "/",
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   c.Request.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	}
+	if c.SessionManager != nil {
+		opts := c.SessionManager.Options()
+		cookie.Path, cookie.Domain = opts.Path, opts.Domain
+		cookie.Secure = cookie.Secure || opts.Secure
+		cookie.SameSite = opts.SameSite
+	}
+	return cookie
+}
@@ -0,0 +1,325 @@
+// pkg/auth/session_guard.go
+package auth
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/aasoft24/golara/wpkg/gola"
+	"github.com/aasoft24/golara/wpkg/hashing"
+	"github.com/aasoft24/golara/wpkg/session"
+)
+
+var (
+	ErrNoRemember      = errors.New("auth: the user provider does not support remember tokens")
+	ErrInvalidPassword = errors.New("auth: the password is incorrect")
+)
+
+// SessionGuard keeps the user ID in the session (session.UserIDKey), so the
+// database session driver can list and end a user's sessions. With remember
+// it also sets a long-lived remember_<guard> cookie that logs the user back
+// in once the session has expired.
+type SessionGuard struct {
+	name     string
+	provider UserProvider
+}
+
+func NewSessionGuard(name string, provider UserProvider) *SessionGuard {
+	return &SessionGuard{name: name, provider: provider}
+}
+
+// Provider returns the guard's user provider
+func (g *SessionGuard) Provider() UserProvider {
+	return g.provider
+}
+
+// hashKey keeps the password fingerprint of the login in the session
+func (g *SessionGuard) hashKey() string {
+	return "_password_hash." + g.name
+}
+
+func (g *SessionGuard) recallerName() string {
+	return "remember_" + g.name
+}
+
+func (g *SessionGuard) viaRememberKey() gola.Key[bool] {
+	return gola.NewKey[bool]("auth.via_remember." + g.name)
+}
+
+// rememberTokenKey holds the remember token issued in this request, which
+// the request's own cookie no longer shows
+func (g *SessionGuard) rememberTokenKey() gola.Key[string] {
+	return gola.NewKey[string]("auth.remember_token." + g.name)
+}
+
+// rememberToken returns this device's remember token, "" without one
+func (g *SessionGuard) rememberToken(c *gola.Context) string {
+	if token, ok := gola.GetValue(c, g.rememberTokenKey()); ok {
+		return token
+	}
+	value, err := c.GetCookie(g.recallerName())
+	if err != nil {
+		return ""
+	}
+	rec, ok := parseRecaller(value)
+	if !ok {
+		return ""
+	}
+	return rec.token
+}
+
+func (g *SessionGuard) User(c *gola.Context) (Authenticatable, error) {
+	if r, ok := cached(c, g.name); ok {
+		return r.user, nil
+	}
+	if c.Session == nil {
+		return nil, nil
+	}
+
+	user, err := g.provider.RetrieveByID(session.UserID(c.Session))
+	if err != nil {
+		return nil, err
+	}
+	if user != nil && !g.fingerprintMatches(c, user) {
+		// the password changed since this login, e.g. "log out other devices"
+		if err := g.clearUserData(c); err != nil {
+			return nil, err
+		}
+		user = nil
+	}
+	if user == nil {
+		if user, err = g.userFromRecaller(c); err != nil {
+			return nil, err
+		}
+	}
+	setUser(c, g.name, user)
+	if user != nil {
+		c.SetFirstParty()
+	}
+	return user, nil
+}
+
+func (g *SessionGuard) fingerprintMatches(c *gola.Context, user Authenticatable) bool {
+	stored, _ := c.Session.Get(g.hashKey()).(string)
+	if stored == "" {
+		// logged in before fingerprints were kept
+		c.Session.Set(g.hashKey(), passwordFingerprint(user))
+		return true
+	}
+	return stored == passwordFingerprint(user)
+}
+
+// userFromRecaller logs in again from a valid remember cookie. The token is
+// kept as is: rotating it on use would let parallel requests of the same
+// browser invalidate each other. It is revoked by Logout and, with every
+// other token of the user, by a password change.
+func (g *SessionGuard) userFromRecaller(c *gola.Context) (Authenticatable, error) {
+	rp, ok := g.provider.(RememberProvider)
+	if !ok {
+		return nil, nil
+	}
+	value, err := c.GetCookie(g.recallerName())
+	if err != nil {
+		return nil, nil
+	}
+	rec, ok := parseRecaller(value)
+	if !ok {
+		return nil, nil
+	}
+	user, err := rp.RetrieveByToken(rec.id, rec.token)
+	if err != nil || user == nil || rec.fingerprint != passwordFingerprint(user) {
+		c.QueueCookie(rememberCookie(c, g.recallerName(), "", -1))
+		return nil, err
+	}
+	if err := g.updateSession(c, user); err != nil {
+		return nil, err
+	}
+	gola.SetValue(c, g.viaRememberKey(), true)
+	return user, nil
+}
+
+// ViaRemember reports whether the user was logged in by the remember cookie
+// in this request, e.g. to ask for the password before sensitive actions
+func (g *SessionGuard) ViaRemember(c *gola.Context) bool {
+	via, _ := gola.GetValue(c, g.viaRememberKey())
+	return via
+}
+
+func (g *SessionGuard) Check(c *gola.Context) bool {
+	user, _ := g.User(c)
+	return user != nil
+}
+
+func (g *SessionGuard) ID(c *gola.Context) uint {
+	if user, _ := g.User(c); user != nil {
+		return user.GetAuthID()
+	}
+	return 0
+}
+
+func (g *SessionGuard) Validate(credentials map[string]string) bool {
+	user, err := g.provider.RetrieveByCredentials(credentials)
+	return err == nil && user != nil && g.provider.ValidateCredentials(user, credentials)
+}
+
+// Attempt logs in the user matching credentials; pass true to also set the
+// remember cookie
+func (g *SessionGuard) Attempt(c *gola.Context, credentials map[string]string, remember ...bool) (bool, error) {
+	user, err := g.provider.RetrieveByCredentials(credentials)
+	if err != nil || user == nil {
+		return false, err
+	}
+	if !g.provider.ValidateCredentials(user, credentials) {
+		return false, nil
+	}
+	if err := g.provider.RehashPasswordIfRequired(user, credentials); err != nil {
+		return false, err
+	}
+	if hashing.NeedsRehash(user.GetAuthPassword()) {
+		// reload, so the fingerprint is taken from the new hash
+		if user, err = g.provider.RetrieveByID(user.GetAuthID()); err != nil || user == nil {
+			return false, err
+		}
+	}
+	return true, g.Login(c, user, remember...)
+}
+
+// Login stores the user in the session. The session ID and CSRF token are
+// regenerated, so an ID planted before login is useless afterwards.
+func (g *SessionGuard) Login(c *gola.Context, user Authenticatable, remember ...bool) error {
+	if err := g.updateSession(c, user); err != nil {
+		return err
+	}
+	if len(remember) > 0 && remember[0] {
+		if err := g.issueRecaller(c, user); err != nil {
+			return err
+		}
+	}
+	setUser(c, g.name, user)
+	c.SetFirstParty()
+	return nil
+}
+
+func (g *SessionGuard) updateSession(c *gola.Context, user Authenticatable) error {
+	if c.Session == nil {
+		return ErrNoSession
+	}
+	if err := c.Session.Regenerate(true); err != nil {
+		return err
+	}
+	c.Session.Set(session.UserIDKey, user.GetAuthID())
+	c.Session.Set(g.hashKey(), passwordFingerprint(user))
+	session.RegenerateToken(c.Session)
+	return nil
+}
+
+// issueRecaller stores a new device token and sets the cookie
+func (g *SessionGuard) issueRecaller(c *gola.Context, user Authenticatable) error {
+	rp, ok := g.provider.(RememberProvider)
+	if !ok {
+		return ErrNoRemember
+	}
+	token := randomToken()
+	if err := rp.AddRememberToken(user, token); err != nil {
+		return err
+	}
+	value := strconv.FormatUint(uint64(user.GetAuthID()), 10) + "|" + token + "|" + passwordFingerprint(user)
+	c.QueueCookie(rememberCookie(c, g.recallerName(), value, int(rememberLifetime().Seconds())))
+	gola.SetValue(c, g.rememberTokenKey(), token)
+	return nil
+}
+
+func (g *SessionGuard) LoginUsingID(c *gola.Context, id uint, remember ...bool) (Authenticatable, error) {
+	user, err := g.provider.RetrieveByID(id)
+	if err != nil || user == nil {
+		return nil, err
+	}
+	return user, g.Login(c, user, remember...)
+}
+
+// Logout drops the session data and ID, issues a new CSRF token and
+// revokes this device's remember token
+func (g *SessionGuard) Logout(c *gola.Context) error {
+	user, _ := g.User(c)
+	if rp, ok := g.provider.(RememberProvider); ok && user != nil {
+		if token := g.rememberToken(c); token != "" {
+			if err := rp.RemoveRememberToken(user, token); err != nil {
+				return err
+			}
+		}
+	}
+	return g.clearUserData(c)
+}
+
+func (g *SessionGuard) clearUserData(c *gola.Context) error {
+	setUser(c, g.name, nil)
+	c.Set("User", nil)
+	if _, err := c.GetCookie(g.recallerName()); err == nil || g.rememberToken(c) != "" {
+		c.QueueCookie(rememberCookie(c, g.recallerName(), "", -1))
+	}
+	gola.SetValue(c, g.rememberTokenKey(), "")
+	if c.Session == nil {
+		return nil
+	}
+	if err := c.Session.Invalidate(); err != nil {
+		return err
+	}
+	session.RegenerateToken(c.Session)
+	return nil
+}
+
+// ChangePassword stores a new password for the logged-in user. Other
+// sessions and every remember cookie stop working; this one stays logged in.
+func (g *SessionGuard) ChangePassword(c *gola.Context, password string) error {
+	user, err := g.User(c)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return ErrNoSession
+	}
+	return g.resetCredentials(c, user, password)
+}
+
+// LogoutOtherDevices ends the user's sessions and remember cookies on every
+// other device. The current password is required; it is re-hashed, which
+// changes the fingerprint the other sessions were bound to.
+func (g *SessionGuard) LogoutOtherDevices(c *gola.Context, password string) error {
+	user, err := g.User(c)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return ErrNoSession
+	}
+	if !hashing.Check(password, user.GetAuthPassword()) {
+		return ErrInvalidPassword
+	}
+	return g.resetCredentials(c, user, password)
+}
+
+func (g *SessionGuard) resetCredentials(c *gola.Context, user Authenticatable, password string) error {
+	rp, ok := g.provider.(RememberProvider)
+	if !ok {
+		return ErrNoRemember
+	}
+	hash, err := hashing.Make(password)
+	if err != nil {
+		return err
+	}
+	if err := rp.UpdatePassword(user, hash); err != nil {
+		return err
+	}
+	if err := rp.RevokeRememberTokens(user); err != nil {
+		return err
+	}
+	if user, err = g.provider.RetrieveByID(user.GetAuthID()); err != nil || user == nil {
+		return err
+	}
+	c.Session.Set(g.hashKey(), passwordFingerprint(user))
+	setUser(c, g.name, user)
+	if _, err := c.GetCookie(g.recallerName()); err == nil {
+		return g.issueRecaller(c, user)
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+// pkg/auth/session_guard_test.go
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aasoft24/golara/wpkg/gola"
+	"github.com/aasoft24/golara/wpkg/session"
+)
+
+type rememberUser struct {
+	ID            uint
+	Email         string
+	Password      string
+	RememberToken string
+}
+
+func (u *rememberUser) GetAuthID() uint          { return u.ID }
+func (u *rememberUser) GetAuthPassword() string  { return u.Password }
+func (u *rememberUser) GetRememberToken() string { return u.RememberToken }
+
+func TestRememberCookieParallelRequests(t *testing.T) {
+	db := testDB(t)
+	if err := db.AutoMigrate(&rememberUser{}); err != nil {
+		t.Fatal(err)
+	}
+	user := &rememberUser{Email: "a@example.com", Password: "hash"}
+	db.Create(user)
+
+	g := NewSessionGuard("web", NewGormProvider[rememberUser](db))
+	sessions := session.NewManager(session.NewMemoryStore(), "go_session")
+	// request starts a fresh session, as a browser whose session expired
+	request := func(cookies ...*http.Cookie) *gola.Context {
+		r := httptest.NewRequest("GET", "/", nil)
+		for _, cookie := range cookies {
+			r.AddCookie(cookie)
+		}
+		c := gola.NewContext(httptest.NewRecorder(), r)
+		sess, err := sessions.Start(c.Writer, r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c.Session, c.SessionManager = sess, sessions
+		return c
+	}
+
+	c := request()
+	if err := g.Login(c, user, true); err != nil {
+		t.Fatal(err)
+	}
+	queued := c.QueuedCookies()
+	if len(queued) != 1 || queued[0].Name != "remember_web" {
+		t.Fatalf("queued cookies = %v, want remember_web", queued)
+	}
+	recaller := &http.Cookie{Name: "remember_web", Value: queued[0].Value}
+
+	// two tabs restoring the login at once both get in and keep the cookie
+	for i := 0; i < 2; i++ {
+		c := request(recaller)
+		if u, err := g.User(c); err != nil || u == nil {
+			t.Fatalf("request %d: User() = %v, %v", i, u, err)
+		}
+		if !g.ViaRemember(c) {
+			t.Fatalf("request %d: ViaRemember() = false", i)
+		}
+		for _, cookie := range c.QueuedCookies() {
+			if cookie.Name == "remember_web" {
+				t.Fatalf("request %d replaced the remember cookie: %v", i, cookie)
+			}
+		}
+	}
+
+	c = request(recaller)
+	if err := g.Logout(c); err != nil {
+		t.Fatal(err)
+	}
+	c = request(recaller)
+	if u, _ := g.User(c); u != nil {
+		t.Fatal("the remember cookie still logs in after logout")
+	}
+}
@@ -0,0 +1,85 @@
+// pkg/auth/token_guard.go
+package auth
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+
+	"github.com/aasoft24/golara/wpkg/gola"
+)
+
+// TokenGuard authenticates stateless API requests with a token column on
+// the users table, sent as a bearer token or an api_token parameter
+type TokenGuard struct {
+	name       string
+	provider   UserProvider
+	InputKey   string // query or form field, default api_token
+	StorageKey string // users column, default api_token
+	Hash       bool   // the column holds the SHA-256 hex of the token
+}
+
+func NewTokenGuard(name string, provider UserProvider) *TokenGuard {
+	return &TokenGuard{name: name, provider: provider, InputKey: "api_token", StorageKey: "api_token"}
+}
+
+// BearerToken returns the token of an "Authorization: Bearer" header
+func BearerToken(c *gola.Context) string {
+	h := c.Request.Header.Get("Authorization")
+	if len(h) > 7 && strings.EqualFold(h[:7], "Bearer ") {
+		return strings.TrimSpace(h[7:])
+	}
+	return ""
+}
+
+func (g *TokenGuard) token(c *gola.Context) string {
+	if token := BearerToken(c); token != "" {
+		return token
+	}
+	if token := c.Request.URL.Query().Get(g.InputKey); token != "" {
+		return token
+	}
+	_ = c.ParseForm()
+	return c.Request.PostFormValue(g.InputKey)
+}
+
+func (g *TokenGuard) byToken(token string) (Authenticatable, error) {
+	if token == "" {
+		return nil, nil
+	}
+	if g.Hash {
+		sum := sha256.Sum256([]byte(token))
+		token = hex.EncodeToString(sum[:])
+	}
+	return g.provider.RetrieveByCredentials(map[string]string{g.StorageKey: token})
+}
+
+func (g *TokenGuard) User(c *gola.Context) (Authenticatable, error) {
+	if r, ok := cached(c, g.name); ok {
+		return r.user, nil
+	}
+	user, err := g.byToken(g.token(c))
+	if err != nil {
+		return nil, err
+	}
+	setUser(c, g.name, user)
+	return user, nil
+}
+
+func (g *TokenGuard) Check(c *gola.Context) bool {
+	user, _ := g.User(c)
+	return user != nil
+}
+
+func (g *TokenGuard) ID(c *gola.Context) uint {
+	if user, _ := g.User(c); user != nil {
+		return user.GetAuthID()
+	}
+	return 0
+}
+
+// Validate checks credentials[InputKey]
+func (g *TokenGuard) Validate(credentials map[string]string) bool {
+	user, err := g.byToken(credentials[g.InputKey])
+	return err == nil && user != nil
+}
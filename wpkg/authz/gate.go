@@ -0,0 +1,183 @@
+// pkg/authz/gate.go
+package authz
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/aasoft24/golara/wpkg/auth"
+)
+
+// AuthorizationError is returned by Authorize when the user may not act
+type AuthorizationError struct {
+	Ability string
+}
+
+func (e *AuthorizationError) Error() string {
+	return fmt.Sprintf("authz: this action is unauthorized (%s)", e.Ability)
+}
+
+// Status is the HTTP status to answer with, 403
+func (e *AuthorizationError) Status() int {
+	return http.StatusForbidden
+}
+
+// Ability decides whether user may do something; args are what the check
+// was called with, e.g. a model
+type Ability func(user auth.Authenticatable, args ...interface{}) bool
+
+// BeforeFunc runs before every check; decided=false lets the check go on.
+// Typically used to allow everything for super admins.
+type BeforeFunc func(user auth.Authenticatable, ability string, args ...interface{}) (allowed, decided bool)
+
+// Gate holds abilities and model policies
+type Gate struct {
+	mu        sync.RWMutex
+	abilities map[string]Ability
+	policies  map[reflect.Type]interface{}
+	names     map[string]reflect.Type // lower-case model name, for "can:create,post"
+	befores   []BeforeFunc
+}
+
+func New() *Gate {
+	return &Gate{
+		abilities: make(map[string]Ability),
+		policies:  make(map[reflect.Type]interface{}),
+		names:     make(map[string]reflect.Type),
+	}
+}
+
+// Default is the gate used by the package functions, the middleware and
+// the can template function
+var Default = New()
+
+// Define registers an ability, e.g. Define("edit-settings", fn)
+func (g *Gate) Define(ability string, fn Ability) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.abilities[ability] = fn
+}
+
+// Policy registers policy for the type of model, e.g.
+// Policy(&models.Post{}, &PostPolicy{}). Checks with a model of that type
+// call the policy method named after the ability ("update" calls Update).
+func (g *Gate) Policy(model interface{}, policy interface{}) {
+	t := modelType(model)
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.policies[t] = policy
+	g.names[strings.ToLower(t.Name())] = t
+}
+
+// Before registers a hook that runs before every check
+func (g *Gate) Before(fn BeforeFunc) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.befores = append(g.befores, fn)
+}
+
+func modelType(model interface{}) reflect.Type {
+	t := reflect.TypeOf(model)
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
+
+// Allows reports whether user may do ability. Guests (nil user) are denied.
+func (g *Gate) Allows(user auth.Authenticatable, ability string, args ...interface{}) bool {
+	if isNil(user) {
+		return false
+	}
+	g.mu.RLock()
+	befores := g.befores
+	g.mu.RUnlock()
+	for _, before := range befores {
+		if allowed, decided := before(user, ability, args...); decided {
+			return allowed
+		}
+	}
+	if policy, args, ok := g.policyFor(args); ok {
+		if allowed, decided := callPolicy(policy, user, ability, args); decided {
+			return allowed
+		}
+	}
+	g.mu.RLock()
+	fn, ok := g.abilities[ability]
+	g.mu.RUnlock()
+	return ok && fn(user, args...)
+}
+
+// isNil also catches a nil *User stored in the interface
+func isNil(user auth.Authenticatable) bool {
+	if user == nil {
+		return true
+	}
+	v := reflect.ValueOf(user)
+	return v.Kind() == reflect.Pointer && v.IsNil()
+}
+
+// Denies is the opposite of Allows
+func (g *Gate) Denies(user auth.Authenticatable, ability string, args ...interface{}) bool {
+	return !g.Allows(user, ability, args...)
+}
+
+// Authorize returns an *AuthorizationError (403) when user may not do ability
+func (g *Gate) Authorize(user auth.Authenticatable, ability string, args ...interface{}) error {
+	if g.Allows(user, ability, args...) {
+		return nil
+	}
+	return &AuthorizationError{Ability: ability}
+}
+
+// policyFor finds the policy of the first argument: a model, or a model
+// name for checks without an instance (create). A name is dropped from args.
+func (g *Gate) policyFor(args []interface{}) (interface{}, []interface{}, bool) {
+	if len(args) == 0 || args[0] == nil {
+		return nil, args, false
+	}
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	if name, ok := args[0].(string); ok {
+		t, ok := g.names[strings.ToLower(name)]
+		if !ok {
+			return nil, args, false
+		}
+		return g.policies[t], args[1:], true
+	}
+	policy, ok := g.policies[modelType(args[0])]
+	return policy, args, ok
+}
+
+// Define registers an ability on the Default gate
+func Define(ability string, fn Ability) {
+	Default.Define(ability, fn)
+}
+
+// Policy registers a model policy on the Default gate
+func Policy(model interface{}, policy interface{}) {
+	Default.Policy(model, policy)
+}
+
+// Before registers a hook on the Default gate
+func Before(fn BeforeFunc) {
+	Default.Before(fn)
+}
+
+// Allows checks ability with the Default gate
+func Allows(user auth.Authenticatable, ability string, args ...interface{}) bool {
+	return Default.Allows(user, ability, args...)
+}
+
+// Denies checks ability with the Default gate
+func Denies(user auth.Authenticatable, ability string, args ...interface{}) bool {
+	return Default.Denies(user, ability, args...)
+}
+
+// Authorize checks ability with the Default gate, returning a 403 error
+func Authorize(user auth.Authenticatable, ability string, args ...interface{}) error {
+	return Default.Authorize(user, ability, args...)
+}
@@ -0,0 +1,100 @@
+// pkg/authz/middleware.go
+package authz
+
+import (
+	"html/template"
+	"net/http"
+
+	"github.com/aasoft24/golara/wpkg/auth"
+	"github.com/aasoft24/golara/wpkg/gola"
+	"github.com/aasoft24/golara/wpkg/logger"
+)
+
+// Can lets the request through when the user may do ability. Each param
+// names a route parameter whose bound model (ctx.Model) is passed to the
+// check; a name that is not a route parameter is passed as is, selecting
+// the policy for checks like "can:create,post". Register it as the "can"
+// alias:
+//
+//	router.AliasMiddleware("can", func(args ...string) ... { return authz.Can(args[0], args[1:]...) })
+//
+// Route models are bound after group middleware, so use Can as route
+// middleware when it needs a model; in a group's middleware a route
+// parameter has no model yet and the request fails with 500.
+func Can(ability string, params ...string) func(next func(ctx *gola.Context)) func(ctx *gola.Context) {
+	return func(next func(ctx *gola.Context)) func(ctx *gola.Context) {
+		return func(ctx *gola.Context) {
+			args := make([]interface{}, len(params))
+			for i, p := range params {
+				if model := ctx.Model(p); model != nil {
+					args[i] = model
+				} else if _, ok := ctx.Params.Get(p); ok {
+					logger.Error("authz: can:" + ability + " needs the model of :" + p + ", which is not bound yet; use it as route middleware")
+					ctx.Error(http.StatusInternalServerError, "Internal Server Error")
+					return
+				} else {
+					args[i] = p
+				}
+			}
+			if !AuthorizeRequest(ctx, ability, args...) {
+				return
+			}
+			next(ctx)
+		}
+	}
+}
+
+// AuthorizeRequest checks ability for the request user with the Default
+// gate and answers 403 when it is denied; handlers return on false:
+//
+//	if !authz.AuthorizeRequest(c, "update", post) { return }
+func AuthorizeRequest(ctx *gola.Context, ability string, args ...interface{}) bool {
+	if err := Authorize(auth.User(ctx), ability, args...); err != nil {
+		Forbidden(ctx, err)
+		return false
+	}
+	return true
+}
+
+// Forbidden answers an authorization error, JSON for API clients
+func Forbidden(ctx *gola.Context, err error) {
+	if ctx.WantsJSON() {
+		ctx.JSON(http.StatusForbidden, map[string]interface{}{"error": "This action is unauthorized."})
+		return
+	}
+	ctx.Error(http.StatusForbidden, "This action is unauthorized.")
+}
+
+// Funcs returns can and cannot for templates, checked for user:
+//
+//	{{ if can "update" .Post }}<a href="...">Edit</a>{{ end }}
+func Funcs(user func() auth.Authenticatable) template.FuncMap {
+	current := func() auth.Authenticatable {
+		if user == nil {
+			return nil
+		}
+		return user()
+	}
+	return template.FuncMap{
+		"can": func(ability string, args ...interface{}) bool {
+			return Allows(current(), ability, args...)
+		},
+		"cannot": func(ability string, args ...interface{}) bool {
+			return Denies(current(), ability, args...)
+		},
+	}
+}
+
+// Middleware binds can and cannot to the request user; the user is only
+// loaded when a template calls them. Views need the placeholders to parse,
+// register them before creating the template engine:
+//
+//	view.AddFuncs(authz.Funcs(nil))
+func Middleware(next func(ctx *gola.Context)) func(ctx *gola.Context) {
+	return func(ctx *gola.Context) {
+		ctx.AddTemplateFuncs(Funcs(func() auth.Authenticatable {
+			return auth.User(ctx)
+		}))
+		next(ctx)
+	}
+}
@@ -0,0 +1,75 @@
+// pkg/authz/policy.go
+package authz
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/aasoft24/golara/wpkg/auth"
+)
+
+// A policy is a struct with a method per ability, taking the user (its
+// concrete type, e.g. *models.User, or auth.Authenticatable) and the
+// check's arguments, and returning bool:
+//
+//	func (PostPolicy) View(u *models.User, p *models.Post) bool
+//	func (PostPolicy) Update(u *models.User, p *models.Post) bool
+//	func (PostPolicy) Create(u *models.User) bool
+//
+// An optional Before(user, ability string) (allowed, decided bool) runs
+// first. A method that doesn't exist or doesn't fit the arguments denies.
+
+// callPolicy runs the policy's Before, then the method of ability
+func callPolicy(policy interface{}, user auth.Authenticatable, ability string, args []interface{}) (allowed, decided bool) {
+	v := reflect.ValueOf(policy)
+	if before := v.MethodByName("Before"); before.IsValid() {
+		if out, ok := call(before, user, []interface{}{ability}); ok && len(out) == 2 &&
+			out[0].Kind() == reflect.Bool && out[1].Kind() == reflect.Bool && out[1].Bool() {
+			return out[0].Bool(), true
+		}
+	}
+	method := v.MethodByName(methodName(ability))
+	if !method.IsValid() {
+		return false, false
+	}
+	out, ok := call(method, user, args)
+	if !ok || len(out) != 1 || out[0].Kind() != reflect.Bool {
+		return false, true
+	}
+	return out[0].Bool(), true
+}
+
+// methodName maps "update" to Update and "view-any" or "view_any" to ViewAny
+func methodName(ability string) string {
+	parts := strings.FieldsFunc(ability, func(r rune) bool { return r == '-' || r == '_' })
+	for i, p := range parts {
+		parts[i] = strings.ToUpper(p[:1]) + p[1:]
+	}
+	return strings.Join(parts, "")
+}
+
+// call invokes method with user and args when their types fit
+func call(method reflect.Value, user auth.Authenticatable, args []interface{}) ([]reflect.Value, bool) {
+	t := method.Type()
+	if t.IsVariadic() || t.NumIn() != len(args)+1 {
+		return nil, false
+	}
+	in := make([]reflect.Value, 0, t.NumIn())
+	for i, arg := range append([]interface{}{user}, args...) {
+		want := t.In(i)
+		if arg == nil {
+			switch want.Kind() {
+			case reflect.Pointer, reflect.Interface, reflect.Map, reflect.Slice:
+				in = append(in, reflect.Zero(want))
+				continue
+			}
+			return nil, false
+		}
+		value := reflect.ValueOf(arg)
+		if !value.Type().AssignableTo(want) {
+			return nil, false
+		}
+		in = append(in, value)
+	}
+	return method.Call(in), true
+}
@@ -10,9 +10,14 @@ import (
 )
 
 type AppConfig struct {
-	Name     string `yaml:"name"`
-	Env      string `yaml:"env"`
-	Timezone string `yaml:"timezone"`
+	Name           string   `yaml:"name"`
+	Env            string   `yaml:"env"`
+	Timezone       string   `yaml:"timezone"`
+	Key            string   `yaml:"key"`           // encryption key, APP_KEY in .env (key:generate)
+	PreviousKeys   []string `yaml:"previous_keys"` // old keys still accepted for decryption
+	Locale         string   `yaml:"locale"`
+	FallbackLocale string   `yaml:"fallback_locale"`
+	Locales        []string `yaml:"locales"`
 }
 
 type Config struct {
@@ -33,6 +38,86 @@ type Config struct {
 		DB       int    `yaml:"db"`
 		Enabled  bool   `yaml:"enabled"`
 	} `yaml:"redis"`
+
+	Upload  UploadConfig  `yaml:"upload"`
+	Session SessionConfig `yaml:"session"`
+	CSRF    CSRFConfig    `yaml:"csrf"`
+	Hashing HashingConfig `yaml:"hashing"`
+	Auth    AuthConfig    `yaml:"auth"`
+	JWT     JWTConfig     `yaml:"jwt"`
+}
+
+// AuthConfig sets the default guard and where the auth middleware redirects
+type AuthConfig struct {
+	Guard     string `yaml:"guard"`      // default guard, web
+	LoginPath string `yaml:"login_path"` // guests are sent here, default /login
+	Home      string `yaml:"home"`       // logged-in users are sent here by guest routes, default /
+
+	RememberDays int `yaml:"remember_days"` // remember-me cookie lifetime, default 30
+
+	TokenExpiration int `yaml:"token_expiration"` // personal access token lifetime in minutes, 0 never expires
+}
+
+// JWTConfig configures the jwt guard. HS256 signs with Secret; RS256 and
+// EdDSA with PrivateKey, and verify with PublicKey (derived when empty).
+// Keys are PEM text or the path of a PEM file.
+type JWTConfig struct {
+	Algorithm  string `yaml:"algorithm"`   // HS256 (default), RS256, EdDSA
+	Secret     string `yaml:"secret"`      // HS256 key, at least 32 bytes
+	PrivateKey string `yaml:"private_key"` // RS256/EdDSA signing key
+	PublicKey  string `yaml:"public_key"`  // RS256/EdDSA verification key
+	TTL        int    `yaml:"ttl"`         // access token minutes, default 15
+	RefreshTTL int    `yaml:"refresh_ttl"` // refresh token minutes, default 20160 (14 days)
+	Issuer     string `yaml:"issuer"`      // iss claim, checked when set
+	Audience   string `yaml:"audience"`    // aud claim, checked when set
+}
+
+// HashingConfig selects the password hasher; zero values use the defaults
+type HashingConfig struct {
+	Driver       string `yaml:"driver"`        // bcrypt (default), argon2id
+	BcryptRounds int    `yaml:"bcrypt_rounds"` // default 12
+	ArgonMemory  uint32 `yaml:"argon_memory"`  // KiB, default 65536
+	ArgonTime    uint32 `yaml:"argon_time"`    // iterations, default 3
+	ArgonThreads uint8  `yaml:"argon_threads"` // default 4
+}
+
+// CSRFConfig lists URIs that skip CSRF verification; a trailing * matches
+// the rest of the path (/api/*)
+type CSRFConfig struct {
+	Except []string `yaml:"except"`
+}
+
+// UploadConfig limits multipart requests and stored files (sizes in bytes)
+type UploadConfig struct {
+	Root           string `yaml:"root"`             // base folder for Store, default storage/app
+	MaxMemory      int64  `yaml:"max_memory"`       // form values kept in memory; files always stream to temp files
+	MaxRequestSize int64  `yaml:"max_request_size"` // whole request body, capped before any parsing, default 64 MB, -1 unlimited
+	MaxFileSize    int64  `yaml:"max_file_size"`    // default per-file limit for Store
+	MaxImageSize   int64  `yaml:"max_image_size"`   // bytes imaging.Decode reads, default 20 MB, -1 unlimited
+	MaxImagePixels int64  `yaml:"max_image_pixels"` // width*height imaging.Decode accepts, default 40 MP, -1 unlimited
+}
+
+// SessionConfig selects the session driver and configures its cookie
+type SessionConfig struct {
+	Driver      string `yaml:"driver"`       // memory (default), file, database, redis, cookie
+	Cookie      string `yaml:"cookie"`       // cookie name, default go_session
+	Files       string `yaml:"files"`        // file driver folder
+	Table       string `yaml:"table"`        // database driver table
+	RedisPrefix string `yaml:"redis_prefix"` // redis driver key prefix
+
+	Lifetime        int    `yaml:"lifetime"`         // idle timeout in minutes, extended on activity
+	AbsoluteTimeout int    `yaml:"absolute_timeout"` // minutes since login/start, 0 = none
+	ExpireOnClose   bool   `yaml:"expire_on_close"`
+	Domain          string `yaml:"domain"`
+	Path            string `yaml:"path"`
+	Secure          bool   `yaml:"secure"`    // always on for HTTPS requests
+	SameSite        string `yaml:"same_site"` // lax, strict, none
+	Partitioned     bool   `yaml:"partitioned"`
+
+	Lock        bool `yaml:"lock"`          // serialize concurrent requests of one session
+	LockWait    int  `yaml:"lock_wait"`     // seconds to wait for the lock, default 10
+	LockTTL     int  `yaml:"lock_ttl"`      // seconds before an unreleased lock expires, default 30
+	MergeOnSave bool `yaml:"merge_on_save"` // merge flash/old input with concurrent writes
 }
 
 var GConfig *Config
@@ -62,6 +147,11 @@ func LoadConfig(path string) {
 	}
 }
 
+// Init loads config.yaml, then .env and the environment on top of it
 func Init() {
 	LoadConfig("config.yaml")
+	if err := LoadEnv(".env"); err != nil {
+		log.Fatalf(".env read error: %v", err)
+	}
+	ApplyEnv()
 }
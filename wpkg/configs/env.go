@@ -0,0 +1,146 @@
+// wpkg/configs/env.go
+package configs
+
+import (
+	"bufio"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LoadEnv reads KEY=VALUE lines from path into the process environment.
+// Variables that are already set win over the file, a missing file is not
+// an error.
+func LoadEnv(path string) error {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		if _, set := os.LookupEnv(key); !set {
+			os.Setenv(key, value)
+		}
+	}
+	return scanner.Err()
+}
+
+// ApplyEnv overrides GConfig with the environment, e.g. APP_KEY
+func ApplyEnv() {
+	// App
+	if val := os.Getenv("APP_NAME"); val != "" {
+		GConfig.App.Name = val
+	}
+	if val := os.Getenv("APP_ENV"); val != "" {
+		GConfig.App.Env = val
+	}
+	if val := os.Getenv("APP_TIMEZONE"); val != "" {
+		GConfig.App.Timezone = val
+	}
+	if val := os.Getenv("APP_LOCALE"); val != "" {
+		GConfig.App.Locale = val
+	}
+	if val := os.Getenv("APP_FALLBACK_LOCALE"); val != "" {
+		GConfig.App.FallbackLocale = val
+	}
+	if val := os.Getenv("APP_KEY"); val != "" {
+		GConfig.App.Key = val
+	}
+	if val := os.Getenv("APP_PREVIOUS_KEYS"); val != "" {
+		GConfig.App.PreviousKeys = strings.Split(val, ",")
+	}
+
+	// Hashing
+	if val := os.Getenv("HASH_DRIVER"); val != "" {
+		GConfig.Hashing.Driver = val
+	}
+	if val := os.Getenv("BCRYPT_ROUNDS"); val != "" {
+		GConfig.Hashing.BcryptRounds = atoiSafe(val, GConfig.Hashing.BcryptRounds)
+	}
+
+	// JWT
+	if val := os.Getenv("JWT_ALGORITHM"); val != "" {
+		GConfig.JWT.Algorithm = val
+	}
+	if val := os.Getenv("JWT_SECRET"); val != "" {
+		GConfig.JWT.Secret = val
+	}
+	if val := os.Getenv("JWT_PRIVATE_KEY"); val != "" {
+		GConfig.JWT.PrivateKey = val
+	}
+	if val := os.Getenv("JWT_PUBLIC_KEY"); val != "" {
+		GConfig.JWT.PublicKey = val
+	}
+
+	// Session
+	if val := os.Getenv("SESSION_DRIVER"); val != "" {
+		GConfig.Session.Driver = val
+	}
+	if val := os.Getenv("SESSION_LIFETIME"); val != "" {
+		GConfig.Session.Lifetime = atoiSafe(val, GConfig.Session.Lifetime)
+	}
+	if val := os.Getenv("SESSION_DOMAIN"); val != "" {
+		GConfig.Session.Domain = val
+	}
+	if val := os.Getenv("SESSION_SECURE_COOKIE"); val != "" {
+		GConfig.Session.Secure = (val == "true" || val == "1")
+	}
+
+	// Server
+	if val := os.Getenv("SERVER_HOST"); val != "" {
+		GConfig.Server.Host = val
+	}
+	if val := os.Getenv("SERVER_PORT"); val != "" {
+		GConfig.Server.Port = atoiSafe(val, GConfig.Server.Port)
+	}
+
+	// Redis
+	if val := os.Getenv("REDIS_HOST"); val != "" {
+		GConfig.Redis.Host = val
+	}
+	if val := os.Getenv("REDIS_PORT"); val != "" {
+		GConfig.Redis.Port = atoiSafe(val, GConfig.Redis.Port)
+	}
+	if val := os.Getenv("REDIS_PASSWORD"); val != "" {
+		GConfig.Redis.Password = val
+	}
+	if val := os.Getenv("REDIS_DB"); val != "" {
+		GConfig.Redis.DB = atoiSafe(val, GConfig.Redis.DB)
+	}
+	if val := os.Getenv("REDIS_ENABLED"); val != "" {
+		GConfig.Redis.Enabled = (val == "true" || val == "1")
+	}
+
+	// timezone apply
+	if GConfig.App.Timezone != "" {
+		if loc, err := time.LoadLocation(GConfig.App.Timezone); err == nil {
+			time.Local = loc
+		}
+	}
+}
+
+func atoiSafe(s string, def int) int {
+	if v, err := strconv.Atoi(s); err == nil {
+		return v
+	}
+	return def
+}
@@ -0,0 +1,169 @@
+// pkg/encryption/encrypter.go
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+// KeySize is the AES-256 key length in bytes
+const KeySize = 32
+
+var (
+	ErrMissingKey = errors.New("encryption: no application key, set app.key or APP_KEY (run key:generate)")
+	ErrInvalidKey = errors.New("encryption: the key must be 32 bytes (base64:... or 32 characters)")
+	ErrDecrypt    = errors.New("encryption: the payload is invalid or was encrypted with an unknown key")
+)
+
+// Encrypter encrypts with the current key and decrypts with the current or
+// any previous key, so keys can be rotated without logging everyone out
+type Encrypter struct {
+	aeads    []cipher.AEAD // current key first
+	signKeys [][]byte      // HMAC keys derived from the same keys
+}
+
+// New returns an Encrypter for a 32 byte key and optional previous keys
+func New(key []byte, previous ...[]byte) (*Encrypter, error) {
+	e := &Encrypter{}
+	for _, k := range append([][]byte{key}, previous...) {
+		if len(k) != KeySize {
+			return nil, ErrInvalidKey
+		}
+		block, err := aes.NewCipher(k)
+		if err != nil {
+			return nil, err
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, err
+		}
+		e.aeads = append(e.aeads, aead)
+		e.signKeys = append(e.signKeys, deriveKey(k, "sign"))
+	}
+	return e, nil
+}
+
+// ParseKey decodes an APP_KEY: "base64:<44 chars>" or 32 raw characters
+func ParseKey(s string) ([]byte, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, ErrMissingKey
+	}
+	if encoded, ok := strings.CutPrefix(s, "base64:"); ok {
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil || len(key) != KeySize {
+			return nil, ErrInvalidKey
+		}
+		return key, nil
+	}
+	if len(s) != KeySize {
+		return nil, ErrInvalidKey
+	}
+	return []byte(s), nil
+}
+
+// GenerateKey returns a random key in the "base64:..." APP_KEY format
+func GenerateKey() (string, error) {
+	key := make([]byte, KeySize)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+	return "base64:" + base64.StdEncoding.EncodeToString(key), nil
+}
+
+// Seal encrypts plain with the current key. aad is authenticated but not
+// encrypted, e.g. a cookie name so a value can't be moved to another cookie.
+// The result is URL and cookie safe.
+func (e *Encrypter) Seal(plain, aad []byte) (string, error) {
+	aead := e.aeads[0]
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(aead.Seal(nonce, nonce, plain, aad)), nil
+}
+
+// Open decrypts a value from Seal, trying the previous keys too
+func (e *Encrypter) Open(value string, aad []byte) ([]byte, error) {
+	sealed, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	for _, aead := range e.aeads {
+		if len(sealed) < aead.NonceSize() {
+			continue
+		}
+		nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+		if plain, err := aead.Open(nil, nonce, ciphertext, aad); err == nil {
+			return plain, nil
+		}
+	}
+	return nil, ErrDecrypt
+}
+
+// EncryptString encrypts a string
+func (e *Encrypter) EncryptString(s string) (string, error) {
+	return e.Seal([]byte(s), nil)
+}
+
+// DecryptString decrypts a value from EncryptString
+func (e *Encrypter) DecryptString(value string) (string, error) {
+	plain, err := e.Open(value, nil)
+	return string(plain), err
+}
+
+// Encrypt encrypts any JSON-serializable value, e.g. a struct
+func (e *Encrypter) Encrypt(v interface{}) (string, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return e.Seal(b, nil)
+}
+
+// Decrypt decrypts a value from Encrypt into v (a pointer)
+func (e *Encrypter) Decrypt(value string, v interface{}) error {
+	plain, err := e.Open(value, nil)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(plain, v)
+}
+
+// Sign returns an HMAC-SHA256 of data with the current key, URL safe
+func (e *Encrypter) Sign(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(mac(e.signKeys[0], data))
+}
+
+// Verify checks a signature from Sign against the current and previous keys
+func (e *Encrypter) Verify(data []byte, signature string) bool {
+	sig, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	for _, k := range e.signKeys {
+		if hmac.Equal(sig, mac(k, data)) {
+			return true
+		}
+	}
+	return false
+}
+
+// deriveKey gives each purpose its own key, so the AES key never doubles as
+// an HMAC key
+func deriveKey(key []byte, purpose string) []byte {
+	return mac(key, []byte("golara."+purpose))
+}
+
+func mac(key, data []byte) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write(data)
+	return h.Sum(nil)
+}
@@ -0,0 +1,159 @@
+// pkg/encryption/encrypter_test.go
+package encryption
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/aasoft24/golara/wpkg/configs"
+)
+
+var (
+	oldKey = bytes.Repeat([]byte("o"), KeySize)
+	newKey = bytes.Repeat([]byte("n"), KeySize)
+)
+
+func encrypter(t *testing.T, key []byte, previous ...[]byte) *Encrypter {
+	t.Helper()
+	e, err := New(key, previous...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return e
+}
+
+func TestKeyRotation(t *testing.T) {
+	before := encrypter(t, oldKey)
+	rotated := encrypter(t, newKey, oldKey)
+	dropped := encrypter(t, newKey)
+
+	tests := []struct {
+		name    string
+		sealer  *Encrypter
+		opener  *Encrypter
+		aad     string // aad used to open; sealed with "session"
+		tamper  bool
+		wantErr bool
+	}{
+		{"same key", before, before, "session", false, false},
+		{"old value after rotation", before, rotated, "session", false, false},
+		{"new value after rotation", rotated, rotated, "session", false, false},
+		{"old value once the key is dropped", before, dropped, "session", false, true},
+		{"new value on a server not yet rotated", rotated, before, "session", false, true},
+		{"moved to another cookie", rotated, rotated, "remember", false, true},
+		{"tampered", rotated, rotated, "session", true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sealed, err := tt.sealer.Seal([]byte("secret"), []byte("session"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.tamper {
+				b := []byte(sealed)
+				b[len(b)/2] ^= 'A' ^ 'B'
+				sealed = string(b)
+			}
+			plain, err := tt.opener.Open(sealed, []byte(tt.aad))
+			if tt.wantErr {
+				if !errors.Is(err, ErrDecrypt) {
+					t.Fatalf("Open() = %q, %v, want ErrDecrypt", plain, err)
+				}
+				return
+			}
+			if err != nil || string(plain) != "secret" {
+				t.Fatalf("Open() = %q, %v", plain, err)
+			}
+		})
+	}
+}
+
+func TestSignAcrossRotation(t *testing.T) {
+	before := encrypter(t, oldKey)
+	rotated := encrypter(t, newKey, oldKey)
+	dropped := encrypter(t, newKey)
+	data := []byte("user=1")
+
+	tests := []struct {
+		name   string
+		signer *Encrypter
+		verify *Encrypter
+		data   []byte
+		want   bool
+	}{
+		{"old signature after rotation", before, rotated, data, true},
+		{"new signature", rotated, rotated, data, true},
+		{"old signature once dropped", before, dropped, data, false},
+		{"other data", rotated, rotated, []byte("user=2"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.verify.Verify(tt.data, tt.signer.Sign(data)); got != tt.want {
+				t.Fatalf("Verify() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFromConfigRotation(t *testing.T) {
+	oldApp, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	newApp, _ := GenerateKey()
+	cfg := &configs.Config{}
+	cfg.App.Key = oldApp
+	before, err := FromConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var value struct{ ID int }
+	value.ID = 7
+	sealed, err := before.Encrypt(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg.App.Key, cfg.App.PreviousKeys = newApp, []string{oldApp}
+	rotated, err := FromConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	value.ID = 0
+	if err := rotated.Decrypt(sealed, &value); err != nil || value.ID != 7 {
+		t.Fatalf("Decrypt() = %+v, %v", value, err)
+	}
+
+	cfg.App.PreviousKeys = []string{"too short"}
+	if _, err := FromConfig(cfg); !errors.Is(err, ErrInvalidKey) {
+		t.Fatalf("FromConfig() error = %v, want ErrInvalidKey for a bad previous key", err)
+	}
+}
+
+func TestParseKey(t *testing.T) {
+	generated, _ := GenerateKey()
+	tests := []struct {
+		name    string
+		key     string
+		wantErr error
+	}{
+		{"generated", generated, nil},
+		{"raw 32 characters", string(newKey), nil},
+		{"empty", " ", ErrMissingKey},
+		{"short", "abc", ErrInvalidKey},
+		{"bad base64", "base64:***", ErrInvalidKey},
+		{"base64 of 16 bytes", "base64:AAAAAAAAAAAAAAAAAAAAAA==", ErrInvalidKey},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := ParseKey(tt.key)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ParseKey() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && len(key) != KeySize {
+				t.Fatalf("key has %d bytes", len(key))
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+// pkg/encryption/encryption.go
+package encryption
+
+import (
+	"sync"
+
+	"github.com/aasoft24/golara/wpkg/configs"
+)
+
+var (
+	mu       sync.RWMutex
+	instance *Encrypter
+)
+
+// FromConfig returns an Encrypter for app.key and app.previous_keys
+func FromConfig(cfg *configs.Config) (*Encrypter, error) {
+	if cfg == nil {
+		return nil, ErrMissingKey
+	}
+	key, err := ParseKey(cfg.App.Key)
+	if err != nil {
+		return nil, err
+	}
+	var previous [][]byte
+	for _, k := range cfg.App.PreviousKeys {
+		p, err := ParseKey(k)
+		if err != nil {
+			return nil, err
+		}
+		previous = append(previous, p)
+	}
+	return New(key, previous...)
+}
+
+// Default returns the app Encrypter, built from the loaded config on first use
+func Default() (*Encrypter, error) {
+	mu.RLock()
+	e := instance
+	mu.RUnlock()
+	if e != nil {
+		return e, nil
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if instance != nil {
+		return instance, nil
+	}
+	e, err := FromConfig(configs.GConfig)
+	if err != nil {
+		return nil, err
+	}
+	instance = e
+	return e, nil
+}
+
+// SetDefault replaces the app Encrypter, e.g. in tests
+func SetDefault(e *Encrypter) {
+	mu.Lock()
+	defer mu.Unlock()
+	instance = e
+}
+
+// EncryptString encrypts with the app key
+func EncryptString(s string) (string, error) {
+	e, err := Default()
+	if err != nil {
+		return "", err
+	}
+	return e.EncryptString(s)
+}
+
+// DecryptString decrypts with the app key or a previous one
+func DecryptString(value string) (string, error) {
+	e, err := Default()
+	if err != nil {
+		return "", err
+	}
+	return e.DecryptString(value)
+}
+
+// Encrypt encrypts a JSON-serializable value with the app key
+func Encrypt(v interface{}) (string, error) {
+	e, err := Default()
+	if err != nil {
+		return "", err
+	}
+	return e.Encrypt(v)
+}
+
+// Decrypt decrypts into v with the app key or a previous one
+func Decrypt(value string, v interface{}) error {
+	e, err := Default()
+	if err != nil {
+		return err
+	}
+	return e.Decrypt(value, v)
+}
@@ -1,18 +1,15 @@
 package facades
 
 import (
+	"github.com/aasoft24/golara/wpkg/auth"
 	"github.com/aasoft24/golara/wpkg/gola"
-	"github.com/aasoft24/golara/wpkg/helpers"
-
-	"github.com/gorilla/sessions"
 )
 
+// AuthFacade is a shortcut to the default guard of the auth package
 type AuthFacade struct{}
 
 var Auth = &AuthFacade{}
 
-var store = sessions.NewCookieStore([]byte("very-secret-key"))
-
 type SafeUser struct {
 	ID     uint
 	Name   string
@@ -20,78 +17,56 @@ type SafeUser struct {
 	Mobile string
 }
 
-// User returns SafeUser and ok flag
-func (a *AuthFacade) User(c *gola.Context) (*helpers.SafeUser, bool) {
-	user, ok := c.Get("User").(helpers.SafeUser)
-	if !ok {
-		return nil, false
-	}
-	return &user, true
+// User returns the authenticated user and ok flag
+func (a *AuthFacade) User(c *gola.Context) (auth.Authenticatable, bool) {
+	user := auth.User(c)
+	return user, user != nil
 }
 
 // Id returns user ID
 func (a *AuthFacade) Id(c *gola.Context) uint {
-	if u, ok := a.User(c); ok {
-		return u.ID
-	}
-	return 0
+	return auth.ID(c)
 }
 
 // Check if authenticated
 func (a *AuthFacade) Check(c *gola.Context) bool {
-	_, ok := a.User(c)
-	return ok
+	return auth.Check(c)
 }
 
 // Guest
 func (a *AuthFacade) Guest(c *gola.Context) bool {
-	return !a.Check(c)
+	return auth.Guest(c)
 }
 
-// Auth.attempt(email, password)
-// func (a *AuthFacade) Attempt(c *gola.Context, identifier, password string) bool {
-// 	var user models.User
-// 	db := database.DB
-
-// 	// Try finding user by email, mobile, or name
-// 	if err := db.Where("email = ? OR mobile = ?", identifier, identifier).First(&user).Error; err != nil {
-// 		return false
-// 	}
-
-// 	// Password check
-// 	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
-// 		return false
-// 	}
-
-// 	// Set session safely
-// 	if c != nil && c.Request != nil && c.Response != nil {
-// 		session, _ := store.Get(c.Request, "user-session")
-// 		session.Values["user"] = user.ID
-// 		session.Save(c.Request, c.Response)
-
-// 		// Set context
-// 		c.Set("User", SafeUser{
-// 			ID:     uint(user.ID),
-// 			Name:   user.Name,
-// 			Email:  user.Email,
-// 			Mobile: user.Mobile,
-// 		})
-// 	}
+// Attempt logs in the user matching credentials, e.g. email and password.
+// Outdated password hashes are upgraded on the way; remember sets the
+// remember-me cookie.
+func (a *AuthFacade) Attempt(c *gola.Context, credentials map[string]string, remember ...bool) (bool, error) {
+	return auth.Attempt(c, credentials, remember...)
+}
 
-// 	return true
-// }
+// Validate checks credentials without logging in
+func (a *AuthFacade) Validate(credentials map[string]string) bool {
+	return auth.Validate(credentials)
+}
 
-// Auth.logout()
+// Login stores the user in the session. The session ID and CSRF token are
+// regenerated, so an ID planted before login is useless afterwards.
+func (a *AuthFacade) Login(c *gola.Context, user auth.Authenticatable, remember ...bool) error {
+	return auth.Login(c, user, remember...)
+}
 
-// Logout
-func (a *AuthFacade) Logout(c *gola.Context) {
-	if c == nil {
-		return
-	}
+// LoginUsingID logs in the user with id
+func (a *AuthFacade) LoginUsingID(c *gola.Context, id uint, remember ...bool) (auth.Authenticatable, error) {
+	return auth.LoginUsingID(c, id, remember...)
+}
 
-	session, _ := store.Get(c.Request, "user-session")
-	session.Options.MaxAge = -1
-	session.Save(c.Request, c.Writer)
+// Logout drops the session data and ID and issues a new CSRF token
+func (a *AuthFacade) Logout(c *gola.Context) error {
+	return auth.Logout(c)
+}
 
-	c.Set("User", nil)
+// LogoutOtherDevices ends the user's sessions on every other device
+func (a *AuthFacade) LogoutOtherDevices(c *gola.Context, password string) error {
+	return auth.LogoutOtherDevices(c, password)
 }
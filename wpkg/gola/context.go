@@ -4,33 +4,33 @@ package gola
 import (
 	"encoding/json"
 	"fmt"
+	"html/template"
 	"io"
 	"io/ioutil"
 	"mime/multipart"
 	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/aasoft24/golara/wpkg/helpers"
 	"github.com/aasoft24/golara/wpkg/logger"
 	mySession "github.com/aasoft24/golara/wpkg/session"
 	"github.com/aasoft24/golara/wpkg/view"
 
 	"github.com/aasoft24/golara/wpkg/configs"
-
-	"github.com/gorilla/sessions"
 )
 
 type Context struct {
-	Writer         http.ResponseWriter
+	Writer         ResponseWriter // records status/size, see response_writer.go
 	Response       http.ResponseWriter
 	Request        *http.Request
-	Params         map[string]string
+	Params         Params
 	TemplateEngine *view.TemplateEngine
 	Session        mySession.Session
 	SessionManager *mySession.Manager
-	Store          *sessions.CookieStore
 	Config         *configs.Config // কনফিগ যোগ করুন
 
 	mu        sync.Mutex
@@ -39,9 +39,41 @@ type Context struct {
 	Errors    map[string]string
 
 	Values map[string]interface{} // <-- ekhane add korte hobe
+
+	templateFuncs template.FuncMap // request-bound template funcs (i18n, csrf, ...)
+	startTime     time.Time
+	scoped        map[interface{}]interface{} // typed values, see SetValue
+	urls          URLGenerator                // named routes, set by the router
+	pending       *RedirectResponse           // fluent redirect sent after the handler
+	cookies       []*http.Cookie              // queued, sent just before the headers
+	uploads       map[string][]*UploadedFile  // multipart files in temp files, see upload.go
+	multipartRead bool
+	multipartErr  error
+}
+
+// NewContext builds the request context, wrapping w in a ResponseWriter
+func NewContext(w http.ResponseWriter, r *http.Request) *Context {
+	c := &Context{
+		Writer:    NewResponseWriter(w),
+		Request:   r,
+		startTime: time.Now(),
+	}
+	c.limitBody(w)
+	return c
+}
+
+// StartTime returns when the router started handling the request
+func (c *Context) StartTime() time.Time {
+	return c.startTime
 }
 
-var store = sessions.NewCookieStore([]byte("very-secret-key"))
+// Elapsed returns the time spent on the request so far
+func (c *Context) Elapsed() time.Duration {
+	if c.startTime.IsZero() {
+		return 0
+	}
+	return time.Since(c.startTime)
+}
 
 func (c *Context) Header(key, value string) {
 	c.Writer.Header().Set(key, value)
@@ -84,7 +116,7 @@ func (c *Context) HTML(status int, html string) {
 
 // Param gets route parameter
 func (c *Context) Param(key string) string {
-	return c.Params[key]
+	return c.Params.ByName(key)
 }
 
 // Error sends error response
@@ -112,7 +144,7 @@ func (c *Context) Query(key string, defaultValue ...string) string {
 }
 
 func (c *Context) FormValue(key string, defaultValue ...string) string {
-	if err := c.Request.ParseForm(); err == nil {
+	if err := c.ParseForm(); err == nil {
 		if val := c.Request.FormValue(key); val != "" {
 			return val
 		}
@@ -125,7 +157,7 @@ func (c *Context) FormValue(key string, defaultValue ...string) string {
 
 func (c *Context) PostFormArray(key string, defaultValue ...[]string) []string {
 	// ensure form is parsed
-	if err := c.Request.ParseForm(); err == nil {
+	if err := c.ParseForm(); err == nil {
 		if vals, ok := c.Request.PostForm[key]; ok && len(vals) > 0 {
 			return vals
 		}
@@ -152,7 +184,7 @@ func (c *Context) AllQuery() map[string]string {
 
 // FormValue gets POST form value by key, with optional default
 func (c *Context) PostForm(key string, defaultValue ...string) string {
-	if err := c.Request.ParseForm(); err != nil {
+	if err := c.ParseForm(); err != nil {
 		return ""
 	}
 	if val := c.Request.PostFormValue(key); val != "" {
@@ -167,7 +199,7 @@ func (c *Context) PostForm(key string, defaultValue ...string) string {
 // AllPostForm returns all POST form values as map[string]string
 func (c *Context) AllPostForm() map[string]string {
 	result := make(map[string]string)
-	if err := c.Request.ParseForm(); err != nil {
+	if err := c.ParseForm(); err != nil {
 		return result
 	}
 	for key, vals := range c.Request.PostForm {
@@ -183,7 +215,7 @@ func (c *Context) AllPostForm() map[string]string {
 // pkg/gola/context.go
 func (c *Context) Input(key string, defaultValue ...string) string {
 	// প্রথমে POST form
-	if err := c.Request.ParseForm(); err == nil {
+	if err := c.ParseForm(); err == nil {
 		if val := c.Request.PostFormValue(key); val != "" {
 			return val
 		}
@@ -196,7 +228,7 @@ func (c *Context) Input(key string, defaultValue ...string) string {
 	}
 
 	// তারপর route param
-	if val, ok := c.Params[key]; ok {
+	if val, ok := c.Params.Get(key); ok {
 		return val
 	}
 
@@ -207,12 +239,27 @@ func (c *Context) Input(key string, defaultValue ...string) string {
 	return ""
 }
 
+// HasInput reports whether key was sent in the body form, the query or the
+// route, even with an empty value
+func (c *Context) HasInput(key string) bool {
+	if err := c.ParseForm(); err == nil {
+		if _, ok := c.Request.PostForm[key]; ok {
+			return true
+		}
+	}
+	if _, ok := c.Request.URL.Query()[key]; ok {
+		return true
+	}
+	_, ok := c.Params.Get(key)
+	return ok
+}
+
 // সব input একসাথে map হিসেবে
 func (c *Context) AllInput() map[string]string {
 	result := make(map[string]string)
 
 	// POST form
-	_ = c.Request.ParseForm()
+	_ = c.ParseForm()
 	for key, vals := range c.Request.PostForm {
 		if len(vals) > 0 {
 			result[key] = vals[0]
@@ -229,40 +276,31 @@ func (c *Context) AllInput() map[string]string {
 	}
 
 	// route params
-	for key, val := range c.Params {
-		result[key] = val
+	for _, p := range c.Params {
+		result[p.Key] = p.Value
 	}
 
 	return result
 }
 
-// FormFile handles file upload and returns the file header and a file object
+// FormFile returns the first uploaded file for key. The header only
+// describes the part (Filename, Header, Size); read the content from the
+// returned file, or use c.File(key) to validate and store it.
 func (c *Context) FormFile(key string) (multipart.File, *multipart.FileHeader, error) {
-	// Parse multipart form if not already parsed
-	if c.Request.MultipartForm == nil {
-		if err := c.Request.ParseMultipartForm(32 << 20); err != nil { // 32MB max
-			return nil, nil, err
-		}
+	f := c.File(key)
+	file, err := f.Open()
+	if err != nil {
+		return nil, nil, err
 	}
-
-	return c.Request.FormFile(key)
+	return file, &multipart.FileHeader{Filename: f.Filename, Header: f.Header, Size: f.Size()}, nil
 }
 
-// FormFiles handles multiple file uploads and returns all files
-func (c *Context) FormFiles(key string) ([]*multipart.FileHeader, error) {
-	// Parse multipart form if not already parsed
-	if c.Request.MultipartForm == nil {
-		if err := c.Request.ParseMultipartForm(32 << 20); err != nil { // 32MB max
-			return nil, err
-		}
-	}
-
-	if c.Request.MultipartForm == nil || c.Request.MultipartForm.File == nil {
-		return nil, http.ErrMissingFile
+// FormFiles returns all files uploaded for key
+func (c *Context) FormFiles(key string) ([]*UploadedFile, error) {
+	if err := c.parseMultipart(); err != nil {
+		return nil, err
 	}
-
-	files := c.Request.MultipartForm.File[key]
-	return files, nil
+	return c.uploads[key], nil
 }
 
 func (c *Context) Set(key string, value interface{}) {
@@ -281,8 +319,9 @@ func (c *Context) Get(key string) interface{} {
 	return c.Values[key]
 }
 
-// SaveUploadedFile saves an uploaded file to a specific destination
-func (c *Context) SaveUploadedFile(file *multipart.FileHeader, dst string) error {
+// SaveUploadedFile saves an uploaded file to a specific destination.
+// dst is used as given; prefer c.File(key).Store(dir) for user controlled names.
+func (c *Context) SaveUploadedFile(file *UploadedFile, dst string) error {
 	src, err := file.Open()
 	if err != nil {
 		return err
@@ -301,40 +340,15 @@ func (c *Context) SaveUploadedFile(file *multipart.FileHeader, dst string) error
 
 // HasFile checks if a file was uploaded with the given key
 func (c *Context) HasFile(key string) bool {
-	// Parse multipart form if not already parsed
-	if c.Request.MultipartForm == nil {
-		if err := c.Request.ParseMultipartForm(32 << 20); err != nil { // 32MB max
-			return false
-		}
-	}
-
-	if c.Request.MultipartForm == nil || c.Request.MultipartForm.File == nil {
-		return false
-	}
-
-	files := c.Request.MultipartForm.File[key]
-	return len(files) > 0
+	return len(c.Files(key)) > 0
 }
 
 // IsValidImage checks if uploaded file is a valid image
-func (c *Context) IsValidImage(file *multipart.FileHeader) (bool, string) {
-	src, err := file.Open()
+func (c *Context) IsValidImage(file *UploadedFile) (bool, string) {
+	filetype, err := file.MIME()
 	if err != nil {
-		return false, "Cannot open file"
-	}
-	defer src.Close()
-
-	buff := make([]byte, 512)
-	if _, err = src.Read(buff); err != nil {
 		return false, "Cannot read file"
 	}
-
-	// Reset file pointer
-	if _, err = src.Seek(0, 0); err != nil {
-		return false, "Cannot reset file pointer"
-	}
-
-	filetype := http.DetectContentType(buff)
 	allowedTypes := []string{"image/jpeg", "image/png", "image/gif"}
 
 	for _, t := range allowedTypes {
@@ -347,14 +361,21 @@ func (c *Context) IsValidImage(file *multipart.FileHeader) (bool, string) {
 }
 
 // GetFileExtension returns the extension of an uploaded file
-func (c *Context) GetFileExtension(file *multipart.FileHeader) string {
+func (c *Context) GetFileExtension(file *UploadedFile) string {
 	return filepath.Ext(file.Filename)
 }
 
-// GenerateUniqueFilename generates a unique filename with original extension
-func (c *Context) GenerateUniqueFilename(file *multipart.FileHeader) string {
-	ext := c.GetFileExtension(file)
-	return fmt.Sprintf("%d%s", time.Now().UnixNano(), ext)
+// GenerateUniqueFilename generates a random filename with the extension of the
+// sniffed content type (the client extension is never trusted). HTML, XML and
+// SVG get no extension, so they are not served back as active content.
+func (c *Context) GenerateUniqueFilename(file *UploadedFile) string {
+	name := randomHex(16)
+	if mimeType, err := file.MIME(); err == nil && !helpers.IsActiveContent(mimeType) {
+		if ext := helpers.ExtensionForMime(mimeType); ext != "" {
+			name += "." + ext
+		}
+	}
+	return name
 }
 
 // Redirect helper
@@ -374,34 +395,46 @@ func (c *Context) RedirectBack() {
 	c.Redirect(referer)
 }
 
-func (ctx *Context) SetFlash(msgType, message string) {
-	// Update context fields immediately
-	ctx.Flash = message
-	ctx.FlashType = msgType
-
-	// Save to session
-	ctx.Session.Set("_flash", map[string]string{
-		"Flash":     message,
-		"FlashType": msgType,
-	})
-
-	_ = ctx.Session.Save()
+// AddTemplateFuncs binds template functions to this request's renders,
+// overriding funcs of the same name registered with view.AddFuncs
+func (c *Context) AddTemplateFuncs(funcs template.FuncMap) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.templateFuncs == nil {
+		c.templateFuncs = template.FuncMap{}
+	}
+	for name, fn := range funcs {
+		c.templateFuncs[name] = fn
+	}
 }
 
-// GetFlash retrieves flash message from session and clears it
-func (ctx *Context) GetFlash() map[string]string {
-	val := ctx.Session.Get("_flash")
-	if val == nil {
-		return nil
+// templates returns the template engine bound to the request funcs
+func (c *Context) templates() *view.TemplateEngine {
+	funcs := c.templateFuncs
+	if c.Session != nil {
+		funcs = make(template.FuncMap, len(c.templateFuncs)+5)
+		for name, fn := range c.flashFuncs() {
+			funcs[name] = fn
+		}
+		for name, fn := range c.csrfFuncs() {
+			funcs[name] = fn
+		}
+		for name, fn := range c.templateFuncs {
+			funcs[name] = fn
+		}
 	}
-
-	flash, ok := val.(map[string]string)
-	if !ok {
-		return nil
+	if len(funcs) == 0 {
+		return c.TemplateEngine
 	}
+	return c.TemplateEngine.WithFuncs(funcs)
+}
 
-	ctx.Session.Delete("_flash") // safely delete
-	return flash
+// Locale returns the request locale resolved by the i18n middleware
+func (c *Context) Locale() string {
+	if locale, ok := c.Get("locale").(string); ok {
+		return locale
+	}
+	return ""
 }
 
 // Render renders a template with optional layout
@@ -419,7 +452,7 @@ func (c *Context) Render(status int, view string, data interface{}, layout ...st
 		useLayout = layout[0]
 	}
 
-	err := c.TemplateEngine.RenderWithLayout(c.Writer, view, useLayout, data)
+	err := c.templates().RenderWithLayout(c.Writer, view, useLayout, data)
 	if err != nil {
 		http.Error(c.Writer, err.Error(), http.StatusInternalServerError)
 	}
@@ -437,7 +470,7 @@ func (ctx *Context) SetOld(key, value string) {
 	}
 
 	old[key] = value
-	ctx.Session.Set("_old", old)
+	mySession.Flash(ctx.Session, "_old", old)
 	_ = ctx.Session.Save()
 	//fmt.Println("Old session after set:", ctx.Session.Get("_old"))
 }
@@ -453,9 +486,9 @@ func (c *Context) GetOld(key string) string {
 	return ""
 }
 
-// SetErrors stores validation errors in session
+// SetErrors flashes validation errors for the next request
 func (ctx *Context) SetErrors(errors map[string]string) {
-	ctx.Session.Set("_errors", errors)
+	mySession.Flash(ctx.Session, "_errors", errors)
 	_ = ctx.Session.Save()
 }
 
@@ -468,8 +501,6 @@ func (ctx *Context) GetErrors() map[string]string {
 	if !ok {
 		return nil
 	}
-	ctx.Session.Delete("_errors")
-	_ = ctx.Session.Save()
 	return errors
 }
 
@@ -538,11 +569,12 @@ func (c *Context) View(name string, data any, layout ...string) error {
 
 	// Build payload
 	payload := map[string]any{
-		"Context":   c,
-		"Flash":     flashMsg,
-		"FlashType": flashType,
-		"Errors":    errors,
-		"Old":       old,
+		"Context":       c,
+		"Flash":         flashMsg,
+		"FlashType":     flashType,
+		"FlashMessages": c.FlashMessages(),
+		"Errors":        errors,
+		"Old":           old,
 		//"User":      c.Get("User"), // <-- add this
 	}
 
@@ -565,11 +597,6 @@ func (c *Context) View(name string, data any, layout ...string) error {
 
 	data = payload
 
-	// Clear session helpers
-	c.Session.Delete("_errors")
-	c.Session.Delete("_old")
-	c.Session.Save()
-
 	// Layout handling
 	useLayout := ""
 	if len(layout) > 0 {
@@ -579,14 +606,14 @@ func (c *Context) View(name string, data any, layout ...string) error {
 	//fmt.Println("final payload ->", data)
 
 	if useLayout == "remove" {
-		return c.TemplateEngine.RenderWithoutLayout(c.Writer, name, data)
+		return c.templates().RenderWithoutLayout(c.Writer, name, data)
 	}
 
 	if useLayout != "" {
-		return c.TemplateEngine.RenderWithLayout(c.Writer, name, useLayout, data)
+		return c.templates().RenderWithLayout(c.Writer, name, useLayout, data)
 	}
 
-	return c.TemplateEngine.RenderWithoutLayout(c.Writer, name, data)
+	return c.templates().RenderWithoutLayout(c.Writer, name, data)
 }
 
 func (c *Context) RenderPartial(status int, view string, data any) {
@@ -601,19 +628,23 @@ func (c *Context) RenderPartial(status int, view string, data any) {
 	fmt.Println("view ", view)
 	fmt.Println("data ", data)
 
-	err := c.TemplateEngine.Render(c.Writer, view, data)
+	err := c.templates().Render(c.Writer, view, data)
 	if err != nil {
 		fmt.Fprintln(c.Writer, "Template render error:", err)
 		return
 	}
 }
 
-// Auth returns the authenticated user if available
-func (c *Context) Auth() map[string]interface{} {
-	if user, ok := c.Get("User").(map[string]interface{}); ok {
-		return user
-	}
-	return nil
+// Auth returns the authenticated user set by the auth package (an
+// auth.Authenticatable), nil for guests
+func (c *Context) Auth() interface{} {
+	return c.Get("User")
+}
+
+// WantsJSON reports whether the client asked for JSON (API and AJAX calls)
+func (c *Context) WantsJSON() bool {
+	return strings.Contains(c.Request.Header.Get("Accept"), "json") ||
+		c.Request.Header.Get("X-Requested-With") == "XMLHttpRequest"
 }
 
 // Check returns true if user is authenticated
@@ -623,7 +654,12 @@ func (c *Context) Check() bool {
 
 // Id returns the authenticated user's ID
 func (c *Context) Id() uint {
-	if user := c.Auth(); user != nil {
+	switch user := c.Auth().(type) {
+	case interface{ GetAuthID() uint }:
+		return user.GetAuthID()
+	case helpers.SafeUser:
+		return user.ID
+	case map[string]interface{}:
 		// ID can be stored as int, int64, or uint
 		switch v := user["ID"].(type) {
 		case int:
@@ -640,7 +676,7 @@ func (c *Context) Id() uint {
 }
 
 // Laravel-style helper methods
-func (c *Context) User() map[string]interface{} {
+func (c *Context) User() interface{} {
 	return c.Auth()
 }
 
@@ -0,0 +1,79 @@
+// pkg/gola/cookie.go
+package gola
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/aasoft24/golara/wpkg/encryption"
+)
+
+// ErrCookieSignature is returned by GetSignedCookie for a tampered or unsigned value
+var ErrCookieSignature = errors.New("gola: cookie signature is invalid")
+
+// QueueCookie sends cookie with the response, just before the headers are
+// written. Middleware can queue cookies without knowing when the handler
+// writes; a later cookie with the same name and path replaces an earlier one.
+func (c *Context) QueueCookie(cookie *http.Cookie) {
+	c.Unqueue(cookie.Name, cookie.Path)
+	if len(c.cookies) == 0 {
+		c.Writer.Before(c.sendQueuedCookies)
+	}
+	c.cookies = append(c.cookies, cookie)
+}
+
+// Unqueue drops a queued cookie; an empty path matches any path
+func (c *Context) Unqueue(name, path string) {
+	kept := c.cookies[:0]
+	for _, q := range c.cookies {
+		if q.Name != name || (path != "" && q.Path != path) {
+			kept = append(kept, q)
+		}
+	}
+	c.cookies = kept
+}
+
+// QueuedCookies returns the cookies waiting to be sent
+func (c *Context) QueuedCookies() []*http.Cookie {
+	return c.cookies
+}
+
+func (c *Context) sendQueuedCookies() {
+	for _, cookie := range c.cookies {
+		http.SetCookie(c.Writer, cookie)
+	}
+	c.cookies = c.cookies[:0]
+}
+
+// SetSignedCookie sets a cookie whose value can be read but not changed by
+// the client; the HMAC covers the name, so values can't be swapped
+func (c *Context) SetSignedCookie(name, value string, maxAge int, path, domain string, secure, httpOnly bool) error {
+	enc, err := encryption.Default()
+	if err != nil {
+		return err
+	}
+	c.SetCookie(name, value+"."+enc.Sign([]byte(name+"="+value)), maxAge, path, domain, secure, httpOnly)
+	return nil
+}
+
+// GetSignedCookie returns the value of a cookie set by SetSignedCookie
+func (c *Context) GetSignedCookie(name string) (string, error) {
+	raw, err := c.GetCookie(name)
+	if err != nil {
+		return "", err
+	}
+	enc, err := encryption.Default()
+	if err != nil {
+		return "", err
+	}
+	i := strings.LastIndexByte(raw, '.')
+	if i < 0 {
+		return "", ErrCookieSignature
+	}
+	value, signature := raw[:i], raw[i+1:]
+	if !enc.Verify([]byte(name+"="+value), signature) {
+		return "", ErrCookieSignature
+	}
+	return value, nil
+}
@@ -0,0 +1,27 @@
+// pkg/gola/csrf.go
+package gola
+
+import (
+	"html/template"
+
+	mySession "github.com/aasoft24/golara/wpkg/session"
+	"github.com/aasoft24/golara/wpkg/view"
+)
+
+// CSRFToken returns the session's CSRF token, "" without a session
+func (c *Context) CSRFToken() string {
+	return view.CSRFToken(c.Session)
+}
+
+// RegenerateCSRFToken issues a new token, e.g. after a privilege change
+func (c *Context) RegenerateCSRFToken() string {
+	if c.Session == nil {
+		return ""
+	}
+	return mySession.RegenerateToken(c.Session)
+}
+
+// csrfFuncs binds csrf, csrf_field and csrf_meta to the request session
+func (c *Context) csrfFuncs() template.FuncMap {
+	return view.CSRFunctions(c.Session)
+}
@@ -0,0 +1,102 @@
+// pkg/gola/flash.go
+package gola
+
+import (
+	"html/template"
+	"strings"
+
+	mySession "github.com/aasoft24/golara/wpkg/session"
+	"github.com/aasoft24/golara/wpkg/view"
+)
+
+func init() {
+	// placeholders so templates parse; bound to the request in templates()
+	view.AddFuncs(template.FuncMap{
+		"flash_messages": func(types ...string) []mySession.FlashMessage { return nil },
+		"flashes":        func(types ...string) template.HTML { return "" },
+	})
+}
+
+// SetFlash adds a typed message (success, info, warning, error) for the next
+// request. Several messages can be flashed, none overwrites another. Without
+// a session only the current request sees it, through ctx.Flash.
+func (ctx *Context) SetFlash(msgType, message string) {
+	// Update context fields immediately
+	ctx.Flash = message
+	ctx.FlashType = msgType
+
+	if ctx.Session == nil {
+		return
+	}
+	mySession.AddFlash(ctx.Session, msgType, message)
+	_ = ctx.Session.Save()
+}
+
+// GetFlash returns the last flash message as {"Flash", "FlashType"}.
+// Flash data is removed by the session itself after one request.
+func (ctx *Context) GetFlash() map[string]string {
+	messages := ctx.FlashMessages()
+	if len(messages) == 0 {
+		return nil
+	}
+	last := messages[len(messages)-1]
+	return map[string]string{
+		"Flash":     last.Message,
+		"FlashType": last.Type,
+	}
+}
+
+// FlashMessages returns the flashed messages, optionally only of the given types
+func (ctx *Context) FlashMessages(types ...string) []mySession.FlashMessage {
+	if ctx.Session == nil {
+		return nil
+	}
+	return mySession.FlashMessages(ctx.Session, types...)
+}
+
+// FlashData stores any value for the next request, read it with Session.Get
+func (ctx *Context) FlashData(key string, value interface{}) {
+	if ctx.Session == nil {
+		return
+	}
+	mySession.Flash(ctx.Session, key, value)
+}
+
+// Reflash keeps all flash data for one more request
+func (ctx *Context) Reflash() {
+	if ctx.Session == nil {
+		return
+	}
+	mySession.Reflash(ctx.Session)
+}
+
+// Keep keeps the given flash keys for one more request
+func (ctx *Context) Keep(keys ...string) {
+	if ctx.Session == nil {
+		return
+	}
+	mySession.Keep(ctx.Session, keys...)
+}
+
+// flashFuncs are the request-bound flash template functions:
+//
+//	{{ flashes }}                      all messages as alert divs
+//	{{ range flash_messages "error" }} ...
+func (ctx *Context) flashFuncs() template.FuncMap {
+	return template.FuncMap{
+		"flash_messages": func(types ...string) []mySession.FlashMessage {
+			return ctx.FlashMessages(types...)
+		},
+		"flashes": func(types ...string) template.HTML {
+			var b strings.Builder
+			for _, m := range ctx.FlashMessages(types...) {
+				b.WriteString(`<div class="alert alert-`)
+				b.WriteString(template.HTMLEscapeString(m.Type))
+				b.WriteString(`" role="alert">`)
+				b.WriteString(template.HTMLEscapeString(m.Message))
+				b.WriteString("</div>\n")
+			}
+			return template.HTML(b.String())
+		},
+	}
+}
@@ -0,0 +1,18 @@
+// pkg/gola/model.go
+package gola
+
+func modelKey(name string) Key[interface{}] {
+	return NewKey[interface{}]("model." + name)
+}
+
+// SetModel stores the model bound to the route parameter name
+func (c *Context) SetModel(name string, model interface{}) {
+	SetValue(c, modelKey(name), model)
+}
+
+// Model returns the model bound to the route parameter name (see
+// routing.Router.Bind), nil when the parameter isn't bound
+func (c *Context) Model(name string) interface{} {
+	model, _ := GetValue(c, modelKey(name))
+	return model
+}
@@ -0,0 +1,142 @@
+// pkg/gola/pool.go
+package gola
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Param is a single route parameter
+type Param struct {
+	Key   string
+	Value string
+}
+
+// Params holds route parameters in match order. Routes rarely have more than
+// a few, so a slice is cheaper than a map and can be reused between requests.
+type Params []Param
+
+// Get returns the value of the first parameter named key
+func (ps Params) Get(key string) (string, bool) {
+	for _, p := range ps {
+		if p.Key == key {
+			return p.Value, true
+		}
+	}
+	return "", false
+}
+
+// ByName returns the value of key or ""
+func (ps Params) ByName(key string) string {
+	v, _ := ps.Get(key)
+	return v
+}
+
+var contextPool = sync.Pool{
+	New: func() interface{} {
+		return &Context{
+			Writer: &responseWriter{status: http.StatusOK, size: noWritten},
+			Params: make(Params, 0, 4),
+		}
+	},
+}
+
+// AcquireContext returns a reset Context from the pool.
+// Pair it with ReleaseContext once the handler chain returns.
+func AcquireContext(w http.ResponseWriter, r *http.Request) *Context {
+	c := contextPool.Get().(*Context)
+	c.reset(w, r)
+	return c
+}
+
+// ReleaseContext puts c back into the pool. c must not be used afterwards;
+// handlers that start goroutines should pass them c.Copy().
+func ReleaseContext(c *Context) {
+	c.removeUploads()
+	c.Request = nil
+	c.Session = nil
+	if rw, ok := c.Writer.(*responseWriter); ok {
+		rw.ResponseWriter = nil
+	}
+	contextPool.Put(c)
+}
+
+// reset clears every per-request field before reuse
+func (c *Context) reset(w http.ResponseWriter, r *http.Request) {
+	if rw, ok := c.Writer.(*responseWriter); ok {
+		rw.reset(w)
+	} else {
+		c.Writer = NewResponseWriter(w)
+	}
+	c.Response = nil
+	c.Request = r
+	c.Params = c.Params[:0]
+	c.TemplateEngine = nil
+	c.Session = nil
+	c.SessionManager = nil
+	c.Config = nil
+	c.Flash = ""
+	c.FlashType = ""
+	c.Errors = nil
+	clear(c.Values)
+	clear(c.scoped)
+	c.templateFuncs = nil
+	c.urls = nil
+	c.pending = nil
+	c.cookies = c.cookies[:0]
+	c.removeUploads()
+	c.startTime = time.Now()
+	c.limitBody(w)
+}
+
+// Copy returns a detached copy that is safe to use after the request ends,
+// e.g. in a goroutine. Writes to its Writer are discarded. Done/Err still
+// follow the original request; wrap it with context.WithoutCancel if the
+// work must outlive the client.
+func (c *Context) Copy() *Context {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cp := &Context{
+		Writer:         NewResponseWriter(discardWriter{header: http.Header{}}),
+		Request:        c.Request,
+		Params:         append(Params(nil), c.Params...),
+		TemplateEngine: c.TemplateEngine,
+		Session:        c.Session,
+		SessionManager: c.SessionManager,
+		Config:         c.Config,
+		Flash:          c.Flash,
+		FlashType:      c.FlashType,
+		startTime:      c.startTime,
+		urls:           c.urls,
+	}
+	if c.Errors != nil {
+		cp.Errors = make(map[string]string, len(c.Errors))
+		for k, v := range c.Errors {
+			cp.Errors[k] = v
+		}
+	}
+	if c.Values != nil {
+		cp.Values = make(map[string]interface{}, len(c.Values))
+		for k, v := range c.Values {
+			cp.Values[k] = v
+		}
+	}
+	if c.scoped != nil {
+		cp.scoped = make(map[interface{}]interface{}, len(c.scoped))
+		for k, v := range c.scoped {
+			cp.scoped[k] = v
+		}
+	}
+	return cp
+}
+
+// discardWriter backs copied contexts that outlive the response
+type discardWriter struct {
+	header http.Header
+}
+
+func (d discardWriter) Header() http.Header         { return d.header }
+func (d discardWriter) Write(b []byte) (int, error) { return len(b), nil }
+func (d discardWriter) WriteHeader(int)             {}
@@ -0,0 +1,20 @@
+// pkg/gola/pool_test.go
+package gola
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func BenchmarkAcquireContext(b *testing.B) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	w := httptest.NewRecorder()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ctx := AcquireContext(w, req)
+		ctx.Params = append(ctx.Params, Param{Key: "id", Value: "1"})
+		ReleaseContext(ctx)
+	}
+}
@@ -0,0 +1,219 @@
+// pkg/gola/redirect.go
+package gola
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/aasoft24/golara/wpkg/logger"
+	mySession "github.com/aasoft24/golara/wpkg/session"
+)
+
+// intendedKey holds the URL a guest tried to open before logging in
+const intendedKey = "url.intended"
+
+// URLGenerator builds URLs for named routes; the router sets itself on every request
+type URLGenerator interface {
+	URL(name string, params map[string]string) (string, error)
+}
+
+// RedirectResponse is a redirect that is sent when the handler returns, so
+// flash data, errors and old input can be chained and saved in one go:
+//
+//	ctx.Back().With("success", "Saved").WithErrors(errs).WithInput()
+type RedirectResponse struct {
+	ctx    *Context
+	url    string
+	status int
+	err    error
+
+	flash  []mySession.FlashMessage
+	errors map[string]string
+	input  map[string]string
+	dirty  bool // session changed outside the chained data
+}
+
+// Back redirects to the previous page; a foreign Referer falls back to "/"
+func (c *Context) Back() *RedirectResponse {
+	target := "/"
+	if ref := c.Request.Referer(); ref != "" {
+		if u, err := url.Parse(ref); err == nil && (u.Host == "" || u.Host == c.Request.Host) {
+			target = u.RequestURI()
+		}
+	}
+	return c.RedirectTo(target)
+}
+
+// RedirectTo redirects to a local path
+func (c *Context) RedirectTo(path string) *RedirectResponse {
+	r := &RedirectResponse{ctx: c, url: path, status: http.StatusFound}
+	c.pending = r
+	return r
+}
+
+// RedirectRoute redirects to a named route, extra params become the query string
+func (c *Context) RedirectRoute(name string, params map[string]string) *RedirectResponse {
+	u, err := c.RouteURL(name, params)
+	r := c.RedirectTo(u)
+	r.err = err
+	return r
+}
+
+// RedirectIntended redirects to the URL stored before a login redirect, or to fallback
+func (c *Context) RedirectIntended(fallback string) *RedirectResponse {
+	target := fallback
+	dirty := false
+	if c.Session != nil {
+		if u, ok := c.Session.Get(intendedKey).(string); ok && u != "" {
+			target = u
+			c.Session.Delete(intendedKey)
+			dirty = true
+		}
+	}
+	r := c.RedirectTo(target)
+	r.dirty = dirty
+	return r
+}
+
+// RedirectAway redirects to an external URL
+func (c *Context) RedirectAway(url string) *RedirectResponse {
+	return c.RedirectTo(url)
+}
+
+// SetIntended remembers url (the current URL by default) for RedirectIntended
+func (c *Context) SetIntended(url ...string) {
+	if c.Session == nil {
+		return
+	}
+	target := c.Request.URL.RequestURI()
+	if len(url) > 0 {
+		target = url[0]
+	}
+	c.Session.Set(intendedKey, target)
+}
+
+// RouteURL builds the URL of a named route
+func (c *Context) RouteURL(name string, params map[string]string) (string, error) {
+	if c.urls == nil {
+		return "", fmt.Errorf("route [%s] not defined", name)
+	}
+	return c.urls.URL(name, params)
+}
+
+// SetURLGenerator is called by the router so handlers can resolve named routes
+func (c *Context) SetURLGenerator(g URLGenerator) {
+	c.urls = g
+}
+
+// WithStatus changes the redirect status, e.g. http.StatusSeeOther
+func (r *RedirectResponse) WithStatus(code int) *RedirectResponse {
+	r.status = code
+	return r
+}
+
+// With flashes a message of the given type, e.g. With("success", "Saved")
+func (r *RedirectResponse) With(typ string, message interface{}) *RedirectResponse {
+	r.flash = append(r.flash, mySession.FlashMessage{Type: typ, Message: fmt.Sprint(message)})
+	return r
+}
+
+// WithErrors flashes validation errors: map[string]string, map[string][]string or error
+func (r *RedirectResponse) WithErrors(errs interface{}) *RedirectResponse {
+	if r.errors == nil {
+		r.errors = make(map[string]string)
+	}
+	switch e := errs.(type) {
+	case map[string]string:
+		for k, v := range e {
+			r.errors[k] = v
+		}
+	case map[string][]string:
+		for k, v := range e {
+			if len(v) > 0 {
+				r.errors[k] = v[0]
+			}
+		}
+	case error:
+		r.errors["error"] = e.Error()
+	case string:
+		r.errors["error"] = e
+	}
+	return r
+}
+
+// WithInput flashes the request input (or the given values) as old input.
+// Password fields are never stored.
+func (r *RedirectResponse) WithInput(input ...map[string]string) *RedirectResponse {
+	if r.input == nil {
+		r.input = make(map[string]string)
+	}
+	values := r.ctx.requestInput()
+	if len(input) > 0 {
+		values = input[0]
+	}
+	for k, v := range values {
+		if isSensitiveInput(k) {
+			continue
+		}
+		r.input[k] = v
+	}
+	return r
+}
+
+// send stores the chained data with a single session save and writes the redirect
+func (r *RedirectResponse) send() {
+	c := r.ctx
+	if r.err != nil {
+		logger.Error("redirect: " + r.err.Error())
+		c.Error(http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+	if c.Session != nil && (r.dirty || len(r.flash) > 0 || len(r.errors) > 0 || len(r.input) > 0) {
+		for _, m := range r.flash {
+			c.Flash, c.FlashType = m.Message, m.Type
+			mySession.AddFlash(c.Session, m.Type, m.Message)
+		}
+		if len(r.errors) > 0 {
+			mySession.Flash(c.Session, "_errors", r.errors)
+		}
+		if len(r.input) > 0 {
+			mySession.Flash(c.Session, "_old", r.input)
+		}
+		_ = c.Session.Save()
+	}
+	http.Redirect(c.Writer, c.Request, r.url, r.status)
+}
+
+// SendPending writes a redirect built with Back/RedirectTo/... once the
+// handler returned. The router calls it; it is a no-op otherwise.
+func (c *Context) SendPending() {
+	r := c.pending
+	if r == nil {
+		return
+	}
+	c.pending = nil
+	if c.Writer.Written() {
+		return
+	}
+	r.send()
+}
+
+// requestInput returns form and query input, without route params
+func (c *Context) requestInput() map[string]string {
+	result := make(map[string]string)
+	_ = c.ParseForm()
+	for key, vals := range c.Request.Form {
+		if len(vals) > 0 {
+			result[key] = vals[0]
+		}
+	}
+	return result
+}
+
+// isSensitiveInput keeps passwords and tokens out of old input
+func isSensitiveInput(key string) bool {
+	k := strings.ToLower(key)
+	return strings.Contains(k, "password") || k == "_token" || k == "_method"
+}
@@ -0,0 +1,93 @@
+// pkg/gola/stdcontext.go
+package gola
+
+import (
+	"context"
+	"time"
+)
+
+// Context implements context.Context by delegating to Request.Context(), so it
+// can be passed straight to database, HTTP client and cache calls:
+//
+//	models.UserModel().WithContext(ctx).Get()
+var _ context.Context = (*Context)(nil)
+
+// Deadline returns the request deadline, if any (see middleware.Timeout)
+func (c *Context) Deadline() (time.Time, bool) {
+	if c.Request == nil {
+		return time.Time{}, false
+	}
+	return c.Request.Context().Deadline()
+}
+
+// Done is closed when the client disconnects or the request times out
+func (c *Context) Done() <-chan struct{} {
+	if c.Request == nil {
+		return nil
+	}
+	return c.Request.Context().Done()
+}
+
+// Err explains why Done was closed
+func (c *Context) Err() error {
+	if c.Request == nil {
+		return nil
+	}
+	return c.Request.Context().Err()
+}
+
+// Value returns typed request values, string keys from Set, then the request context
+func (c *Context) Value(key interface{}) interface{} {
+	c.mu.Lock()
+	if v, ok := c.scoped[key]; ok {
+		c.mu.Unlock()
+		return v
+	}
+	c.mu.Unlock()
+	if k, ok := key.(string); ok {
+		if v := c.Get(k); v != nil {
+			return v
+		}
+	}
+	if c.Request == nil {
+		return nil
+	}
+	return c.Request.Context().Value(key)
+}
+
+// WithContext replaces the request context, e.g. to add a deadline
+func (c *Context) WithContext(ctx context.Context) {
+	c.Request = c.Request.WithContext(ctx)
+}
+
+// Key is a typed key for request-scoped values
+type Key[T any] struct {
+	name string
+}
+
+// NewKey creates a typed key; name is only used for debugging
+func NewKey[T any](name string) Key[T] {
+	return Key[T]{name: name}
+}
+
+func (k Key[T]) String() string {
+	return "gola.Key(" + k.name + ")"
+}
+
+// SetValue stores a typed request-scoped value
+func SetValue[T any](c *Context, key Key[T], value T) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.scoped == nil {
+		c.scoped = make(map[interface{}]interface{})
+	}
+	c.scoped[key] = value
+}
+
+// GetValue returns a typed request-scoped value
+func GetValue[T any](c *Context, key Key[T]) (T, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	v, ok := c.scoped[key].(T)
+	return v, ok
+}
@@ -0,0 +1,111 @@
+// pkg/gola/token.go
+package gola
+
+import "strings"
+
+// AccessToken is the API token a request was authenticated with, e.g. an
+// auth.PersonalAccessToken
+type AccessToken interface {
+	Can(ability string) bool
+}
+
+var accessTokenKey = NewKey[AccessToken]("access_token")
+
+// SetAccessToken is called by token guards once the token is verified
+func (c *Context) SetAccessToken(token AccessToken) {
+	SetValue(c, accessTokenKey, token)
+}
+
+// AccessToken returns the request's API token, nil if there is none
+func (c *Context) AccessToken() AccessToken {
+	token, _ := GetValue(c, accessTokenKey)
+	return token
+}
+
+var firstPartyKey = NewKey[bool]("first_party")
+
+// SetFirstParty is called by the session guard: the user logged in through
+// the session, on the app's own pages
+func (c *Context) SetFirstParty() {
+	SetValue(c, firstPartyKey, true)
+}
+
+// FirstParty reports whether the request user logged in through the session
+func (c *Context) FirstParty() bool {
+	first, _ := GetValue(c, firstPartyKey)
+	return first
+}
+
+// TokenCan reports whether the request may use ability. A personal access
+// token must grant it; a JWT must list it in its "scopes" (or OAuth2
+// "scope") claim. Users logged in through the session (first-party pages)
+// may use every ability; everyone else, e.g. users of other token guards,
+// none.
+func (c *Context) TokenCan(ability string) bool {
+	if token := c.AccessToken(); token != nil {
+		return token.Can(ability)
+	}
+	if claims := c.Claims(); claims != nil {
+		return AbilityGranted(claimScopes(claims), ability)
+	}
+	return c.FirstParty() && c.Check()
+}
+
+// AbilityGranted reports whether granted covers ability: "*" grants all,
+// and "orders:*" every ability starting with "orders:"
+func AbilityGranted(granted []string, ability string) bool {
+	for _, a := range granted {
+		if a == "*" || a == ability ||
+			(strings.HasSuffix(a, "*") && strings.HasPrefix(ability, a[:len(a)-1])) {
+			return true
+		}
+	}
+	return false
+}
+
+// claimScopes reads the "scopes" claim, a list or a space separated
+// string, or else the OAuth2 "scope" claim
+func claimScopes(claims map[string]interface{}) []string {
+	raw, ok := claims["scopes"]
+	if !ok {
+		raw = claims["scope"]
+	}
+	switch v := raw.(type) {
+	case string:
+		return strings.Fields(v)
+	case []string:
+		return v
+	case []interface{}:
+		scopes := make([]string, 0, len(v))
+		for _, s := range v {
+			if str, ok := s.(string); ok {
+				scopes = append(scopes, str)
+			}
+		}
+		return scopes
+	}
+	return nil
+}
+
+// TokenCant is the opposite of TokenCan
+func (c *Context) TokenCant(ability string) bool {
+	return !c.TokenCan(ability)
+}
+
+var claimsKey = NewKey[map[string]interface{}]("claims")
+
+// SetClaims is called by the jwt guard with the verified token claims
+func (c *Context) SetClaims(claims map[string]interface{}) {
+	SetValue(c, claimsKey, claims)
+}
+
+// Claims returns the verified JWT claims of the request, nil without a JWT
+func (c *Context) Claims() map[string]interface{} {
+	claims, _ := GetValue(c, claimsKey)
+	return claims
+}
+
+// Claim returns one claim, e.g. c.Claim("sub"), nil when missing
+func (c *Context) Claim(name string) interface{} {
+	return c.Claims()[name]
+}
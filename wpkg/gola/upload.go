@@ -0,0 +1,512 @@
+// pkg/gola/upload.go
+package gola
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/aasoft24/golara/wpkg/configs"
+	"github.com/aasoft24/golara/wpkg/helpers"
+	"github.com/aasoft24/golara/wpkg/imaging"
+)
+
+// Defaults used when config.yaml has no upload section
+const (
+	defaultUploadRoot     = "storage/app"
+	defaultMaxMemory      = 32 << 20
+	defaultMaxFileSize    = 10 << 20
+	defaultMaxRequestSize = 64 << 20
+)
+
+var (
+	ErrFileTooLarge   = errors.New("uploaded file is too large")
+	ErrFileType       = errors.New("uploaded file type is not allowed")
+	ErrUnsafeUploadTo = errors.New("upload directory is outside the storage root")
+)
+
+// StoreOptions controls UploadedFile.Store
+type StoreOptions struct {
+	Root         string   // storage root, defaults to upload.root
+	MaxSize      int64    // bytes, defaults to upload.max_file_size; -1 disables the check
+	AllowedMimes []string // sniffed MIME types, e.g. "image/png" or "image/*"; HTML, XML and SVG only when listed exactly
+	Name         string   // fixed file name (sanitized); the sniffed extension is appended
+	RandomName   bool     // random name instead of the sha256 of the content
+}
+
+// StoredFile describes a file written by Store
+type StoredFile struct {
+	Path         string // path relative to the storage root, e.g. avatars/ab12….png
+	FullPath     string
+	Name         string
+	OriginalName string // sanitized client file name
+	Extension    string // derived from the sniffed MIME type, never from the client
+	MIME         string
+	Size         int64
+	SHA256       string
+}
+
+// UploadedFile is a file of the current multipart request. The body is
+// streamed into a temp file while parsing, hashed and sniffed on the way;
+// temp files that were not stored are removed when the request ends.
+type UploadedFile struct {
+	Field    string
+	Filename string               // client file name as sent, see ClientName
+	Header   textproto.MIMEHeader // part headers as sent by the client
+	Err      error
+	ctx      *Context
+	path     string // temp file, or the stored file once moved
+	moved    bool   // path was renamed into storage and must outlive the request
+	size     int64
+	sum      string // hex sha256, computed while writing
+	mime     string // sniffed from the first 512 bytes
+}
+
+// maxFormParts bounds the number of parts of a multipart body, as net/http does
+const maxFormParts = 1000
+
+func (c *Context) uploadConfig() configs.UploadConfig {
+	cfg := c.Config
+	if cfg == nil {
+		cfg = configs.GConfig
+	}
+	if cfg == nil {
+		return configs.UploadConfig{}
+	}
+	return cfg.Upload
+}
+
+// uploadRoot returns upload.root or the default storage root
+func (c *Context) uploadRoot() string {
+	if root := c.uploadConfig().Root; root != "" {
+		return root
+	}
+	return defaultUploadRoot
+}
+
+// limitBody caps the request body at upload.max_request_size (64 MB unless
+// configured, -1 disables it) before anything reads it, so every parser
+// (CSRF token, Input, File) stays within the limit
+func (c *Context) limitBody(w http.ResponseWriter) {
+	if c.Request == nil || c.Request.Body == nil || c.Request.Body == http.NoBody {
+		return
+	}
+	limit := c.uploadConfig().MaxRequestSize
+	if limit == 0 {
+		limit = defaultMaxRequestSize
+	}
+	if limit > 0 {
+		c.Request.Body = http.MaxBytesReader(w, c.Request.Body, limit)
+	}
+}
+
+// ParseForm parses the urlencoded or multipart body once and fills
+// Request.Form and Request.PostForm. Use it instead of Request.ParseForm,
+// Request.ParseMultipartForm or Request.FormValue, which would read the
+// multipart body a second time.
+func (c *Context) ParseForm() error {
+	if isMultipart(c.Request) {
+		return c.parseMultipart()
+	}
+	return c.Request.ParseForm()
+}
+
+func isMultipart(r *http.Request) bool {
+	return strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data")
+}
+
+// parseMultipart reads the multipart body once
+func (c *Context) parseMultipart() error {
+	if !c.multipartRead {
+		c.multipartRead = true
+		c.multipartErr = c.readMultipart()
+	}
+	return c.multipartErr
+}
+
+// readMultipart walks the parts with Request.MultipartReader. Values are kept
+// in memory up to upload.max_memory; files go straight to temp files below
+// the upload root, so a large upload never sits in memory.
+func (c *Context) readMultipart() error {
+	if !isMultipart(c.Request) {
+		return http.ErrNotMultipart
+	}
+	query, _ := url.ParseQuery(c.Request.URL.RawQuery)
+	values := url.Values{}
+	// like Request.ParseMultipartForm: PostForm holds the body, Form adds the query
+	defer func() {
+		form := url.Values{}
+		for k, v := range values {
+			form[k] = append(form[k], v...)
+		}
+		for k, v := range query {
+			form[k] = append(form[k], v...)
+		}
+		c.Request.PostForm = values
+		c.Request.Form = form
+	}()
+
+	mr, err := c.Request.MultipartReader()
+	if err != nil {
+		return err
+	}
+	maxMemory := c.uploadConfig().MaxMemory
+	if maxMemory <= 0 {
+		maxMemory = defaultMaxMemory
+	}
+	for parts := 0; ; parts++ {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if parts >= maxFormParts {
+			return multipart.ErrMessageTooLarge
+		}
+		field := part.FormName()
+		if field == "" {
+			continue
+		}
+		if part.FileName() == "" {
+			b, err := io.ReadAll(io.LimitReader(part, maxMemory+1))
+			if err != nil {
+				return err
+			}
+			if maxMemory -= int64(len(b)); maxMemory < 0 {
+				return multipart.ErrMessageTooLarge
+			}
+			values.Add(field, string(b))
+			continue
+		}
+		f, err := c.spool(field, part)
+		if err != nil {
+			return err
+		}
+		if c.uploads == nil {
+			c.uploads = make(map[string][]*UploadedFile)
+		}
+		c.uploads[field] = append(c.uploads[field], f)
+	}
+}
+
+// spool writes one file part to a temp file, hashing and sniffing it while writing
+func (c *Context) spool(field string, part *multipart.Part) (*UploadedFile, error) {
+	dir := filepath.Join(c.uploadRoot(), ".tmp")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	tmp, err := os.CreateTemp(dir, "upload-*")
+	if err != nil {
+		return nil, err
+	}
+	hash := sha256.New()
+	head := &headWriter{max: 512}
+	size, err := io.Copy(io.MultiWriter(tmp, hash, head), part)
+	var mimeType string
+	if err == nil {
+		mimeType, _ = helpers.SniffContentType(bytes.NewReader(head.buf))
+		mimeType = helpers.RefineZipMIME(mimeType, tmp, size)
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return nil, err
+	}
+	return &UploadedFile{
+		Field:    field,
+		Filename: part.FileName(),
+		Header:   part.Header,
+		ctx:      c,
+		path:     tmp.Name(),
+		size:     size,
+		sum:      hex.EncodeToString(hash.Sum(nil)),
+		mime:     mimeType,
+	}, nil
+}
+
+// headWriter keeps the first max bytes written to it, for sniffing
+type headWriter struct {
+	buf []byte
+	max int
+}
+
+func (h *headWriter) Write(p []byte) (int, error) {
+	if n := h.max - len(h.buf); n > 0 {
+		h.buf = append(h.buf, p[:min(n, len(p))]...)
+	}
+	return len(p), nil
+}
+
+// removeUploads deletes the temp files of the request that were not stored
+func (c *Context) removeUploads() {
+	for _, files := range c.uploads {
+		for _, f := range files {
+			if !f.moved {
+				os.Remove(f.path)
+			}
+		}
+	}
+	c.uploads = nil
+	c.multipartRead = false
+	c.multipartErr = nil
+}
+
+// File returns the uploaded file for key; errors surface from Store
+func (c *Context) File(key string) *UploadedFile {
+	if err := c.parseMultipart(); err != nil {
+		return &UploadedFile{Field: key, Err: err, ctx: c}
+	}
+	if files := c.uploads[key]; len(files) > 0 {
+		return files[0]
+	}
+	return &UploadedFile{Field: key, Err: http.ErrMissingFile, ctx: c}
+}
+
+// Files returns every uploaded file for key
+func (c *Context) Files(key string) []*UploadedFile {
+	if err := c.parseMultipart(); err != nil {
+		return nil
+	}
+	return c.uploads[key]
+}
+
+// Exists reports whether a file was sent
+func (f *UploadedFile) Exists() bool {
+	return f.Err == nil && f.path != ""
+}
+
+// Size returns the number of bytes received
+func (f *UploadedFile) Size() int64 {
+	return f.size
+}
+
+// ClientName returns the sanitized client file name
+func (f *UploadedFile) ClientName() string {
+	if f.Filename == "" {
+		return ""
+	}
+	return SanitizeFilename(f.Filename)
+}
+
+// MIME returns the type sniffed from the content
+func (f *UploadedFile) MIME() (string, error) {
+	if !f.Exists() {
+		return "", f.missing()
+	}
+	return f.mime, nil
+}
+
+// SHA256 returns the hex sha256 of the content
+func (f *UploadedFile) SHA256() string {
+	return f.sum
+}
+
+// Open opens the received content for reading
+func (f *UploadedFile) Open() (multipart.File, error) {
+	if !f.Exists() {
+		return nil, f.missing()
+	}
+	return os.Open(f.path)
+}
+
+// Image decodes the upload for processing:
+//
+//	ctx.File("photo").Image().Fit(300, 300).Encode(w, "webp-or-jpeg", 80)
+func (f *UploadedFile) Image() *imaging.Image {
+	src, err := f.Open()
+	if err != nil {
+		return imaging.FromError(err)
+	}
+	defer src.Close()
+	return imaging.Decode(src)
+}
+
+func (f *UploadedFile) missing() error {
+	if f.Err != nil {
+		return f.Err
+	}
+	return http.ErrMissingFile
+}
+
+// Store moves the file into dir below the storage root. The name is the
+// sha256 of the content (or random) plus the extension of the sniffed MIME type.
+// HTML, XML and SVG are refused with ErrFileType unless AllowedMimes lists them.
+func (f *UploadedFile) Store(dir string, opts ...StoreOptions) (*StoredFile, error) {
+	if !f.Exists() {
+		return nil, f.missing()
+	}
+	var opt StoreOptions
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+
+	cfg := f.ctx.uploadConfig()
+	root := opt.Root
+	if root == "" {
+		root = f.ctx.uploadRoot()
+	}
+	maxSize := opt.MaxSize
+	if maxSize == 0 {
+		maxSize = cfg.MaxFileSize
+	}
+	if maxSize == 0 {
+		maxSize = defaultMaxFileSize
+	}
+
+	if maxSize > 0 && f.size > maxSize {
+		return nil, ErrFileTooLarge
+	}
+	if !mimeAllowed(f.mime, opt.AllowedMimes) {
+		return nil, ErrFileType
+	}
+
+	targetDir, relDir, err := safeJoin(root, dir)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(targetDir, 0755); err != nil {
+		return nil, err
+	}
+
+	ext := helpers.ExtensionForMime(f.mime)
+	base := f.sum
+	switch {
+	case opt.Name != "":
+		base = strings.TrimSuffix(SanitizeFilename(opt.Name), filepath.Ext(opt.Name))
+	case opt.RandomName:
+		base = randomHex(20)
+	}
+	name := base
+	if ext != "" {
+		name += "." + ext
+	}
+
+	fullPath := filepath.Join(targetDir, name)
+	if err := f.moveTo(fullPath); err != nil {
+		return nil, err
+	}
+	_ = os.Chmod(fullPath, 0644)
+
+	return &StoredFile{
+		Path:         filepath.ToSlash(filepath.Join(relDir, name)),
+		FullPath:     fullPath,
+		Name:         name,
+		OriginalName: f.ClientName(),
+		Extension:    ext,
+		MIME:         f.mime,
+		Size:         f.size,
+		SHA256:       f.sum,
+	}, nil
+}
+
+// moveTo renames the temp file to dst. A file that was already stored, or a
+// root on another file system, is copied instead.
+func (f *UploadedFile) moveTo(dst string) error {
+	if !f.moved && os.Rename(f.path, dst) == nil {
+		f.path, f.moved = dst, true
+		return nil
+	}
+	src, err := os.Open(f.path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	tmp, err := os.CreateTemp(filepath.Dir(dst), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // no-op after a successful rename
+	_, err = io.Copy(tmp, src)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), dst)
+}
+
+// StoreAs stores the file under a fixed name (extension still comes from the content)
+func (f *UploadedFile) StoreAs(dir, name string, opts ...StoreOptions) (*StoredFile, error) {
+	var opt StoreOptions
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	opt.Name = name
+	return f.Store(dir, opt)
+}
+
+// safeJoin resolves dir below root and rejects absolute paths and ".." escapes
+func safeJoin(root, dir string) (string, string, error) {
+	if filepath.IsAbs(dir) || strings.Contains(dir, "\x00") {
+		return "", "", ErrUnsafeUploadTo
+	}
+	rel := filepath.Clean("/" + filepath.FromSlash(dir))[1:]
+	full := filepath.Join(root, rel)
+
+	check, err := filepath.Rel(root, full)
+	if err != nil || check == ".." || strings.HasPrefix(check, ".."+string(filepath.Separator)) {
+		return "", "", ErrUnsafeUploadTo
+	}
+	return full, rel, nil
+}
+
+var unsafeFilenameChars = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
+
+// SanitizeFilename strips directories and anything but letters, digits, dot, dash and underscore
+func SanitizeFilename(name string) string {
+	name = filepath.Base(strings.ReplaceAll(name, "\\", "/"))
+	name = unsafeFilenameChars.ReplaceAllString(name, "_")
+	name = strings.TrimLeft(name, ".")
+	if len(name) > 200 {
+		name = name[:200]
+	}
+	if name == "" || name == "_" {
+		return "file"
+	}
+	return name
+}
+
+// mimeAllowed checks a sniffed type against StoreOptions.AllowedMimes. With
+// no list every type but active content is allowed; active content (HTML,
+// XML, SVG) must be listed exactly, "text/*" does not cover text/html.
+func mimeAllowed(mimeType string, allowed []string) bool {
+	active := helpers.IsActiveContent(mimeType)
+	if len(allowed) == 0 {
+		return !active
+	}
+	for _, a := range allowed {
+		if a == mimeType {
+			return true
+		}
+		if !active && strings.HasSuffix(a, "/*") && strings.HasPrefix(mimeType, strings.TrimSuffix(a, "*")) {
+			return true
+		}
+	}
+	return false
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		panic(fmt.Sprintf("crypto/rand failed: %v", err))
+	}
+	return hex.EncodeToString(b)
+}
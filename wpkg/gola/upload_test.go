@@ -0,0 +1,248 @@
+// pkg/gola/upload_test.go
+package gola
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aasoft24/golara/wpkg/configs"
+)
+
+func TestSanitizeFilename(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"photo.png", "photo.png"},
+		{"../../etc/passwd", "passwd"},
+		{`..\..\windows\win.ini`, "win.ini"},
+		{"/abs/path/report.pdf", "report.pdf"},
+		{"my photo (1).jpg", "my_photo_1_.jpg"},
+		{".htaccess", "htaccess"},
+		{"...", "file"},
+		{"", "file"},
+		{"ফাইল.txt", "_.txt"},
+		{"a\x00b.png", "a_b.png"},
+		{strings.Repeat("a", 300) + ".png", strings.Repeat("a", 200)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SanitizeFilename(tt.name); got != tt.want {
+				t.Fatalf("SanitizeFilename(%q) = %q, want %q", tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSafeJoin(t *testing.T) {
+	root := filepath.FromSlash("/srv/storage")
+	tests := []struct {
+		dir     string
+		wantRel string
+		wantErr bool
+	}{
+		{"avatars", "avatars", false},
+		{"avatars/2024", filepath.FromSlash("avatars/2024"), false},
+		{"", "", false},
+		{"a/../b", "b", false},
+		{"../secrets", "secrets", false}, // cleaned below the root, not above it
+		{"a/../../..", "", false},
+		{"/etc", "", true},
+		{"a\x00b", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.dir, func(t *testing.T) {
+			full, rel, err := safeJoin(root, tt.dir)
+			if tt.wantErr {
+				if !errors.Is(err, ErrUnsafeUploadTo) {
+					t.Fatalf("safeJoin(%q) error = %v, want ErrUnsafeUploadTo", tt.dir, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if rel != tt.wantRel || full != filepath.Join(root, tt.wantRel) {
+				t.Fatalf("safeJoin(%q) = %q, %q; want rel %q", tt.dir, full, rel, tt.wantRel)
+			}
+		})
+	}
+}
+
+type testPart struct {
+	field, filename string
+	content         []byte
+}
+
+// uploadContext builds a multipart request and a context storing below root
+func uploadContext(t *testing.T, root string, upload configs.UploadConfig, parts ...testPart) *Context {
+	t.Helper()
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	for _, p := range parts {
+		var err error
+		if p.filename == "" {
+			err = mw.WriteField(p.field, string(p.content))
+		} else {
+			var fw io.Writer
+			if fw, err = mw.CreateFormFile(p.field, p.filename); err == nil {
+				_, err = fw.Write(p.content)
+			}
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	mw.Close()
+	r := httptest.NewRequest("POST", "/upload?page=2", &body)
+	r.Header.Set("Content-Type", mw.FormDataContentType())
+	upload.Root = root
+	c := AcquireContext(httptest.NewRecorder(), r)
+	c.Config = &configs.Config{Upload: upload}
+	c.limitBody(httptest.NewRecorder()) // AcquireContext applied the global config
+	return c
+}
+
+var pngHead = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\x0dIHDR")
+
+func TestUploadedFileStore(t *testing.T) {
+	sum := func(b []byte) string {
+		s := sha256.Sum256(b)
+		return hex.EncodeToString(s[:])
+	}
+	html := []byte("<!DOCTYPE html><script>alert(1)</script>")
+	svg := []byte(`<?xml version="1.0"?><svg xmlns="http://www.w3.org/2000/svg"></svg>`)
+
+	tests := []struct {
+		name     string
+		part     testPart
+		dir      string
+		opts     StoreOptions
+		wantPath string // relative path; "{sum}" is the sha256 of the content
+		wantErr  error
+	}{
+		{"content addressed", testPart{"doc", "../../x.PNG", pngHead}, "avatars", StoreOptions{}, "avatars/{sum}.png", nil},
+		{"extension from content", testPart{"doc", "invoice.exe", []byte("%PDF-1.7\n")}, "docs", StoreOptions{}, "docs/{sum}.pdf", nil},
+		{"fixed name", testPart{"doc", "x.png", pngHead}, "a/../b", StoreOptions{Name: "../me.jpg"}, "b/me.png", nil},
+		{"allowed wildcard", testPart{"doc", "x.png", pngHead}, "img", StoreOptions{AllowedMimes: []string{"image/*"}}, "img/{sum}.png", nil},
+		{"not allowed", testPart{"doc", "x.pdf", []byte("%PDF-1.7\n")}, "img", StoreOptions{AllowedMimes: []string{"image/*"}}, "", ErrFileType},
+		{"html refused by default", testPart{"doc", "x.png", html}, "x", StoreOptions{}, "", ErrFileType},
+		{"xml refused by default", testPart{"doc", "x.svg", svg}, "x", StoreOptions{}, "", ErrFileType},
+		{"html not covered by text/*", testPart{"doc", "x.txt", html}, "x", StoreOptions{AllowedMimes: []string{"text/*"}}, "", ErrFileType},
+		{"html listed exactly", testPart{"doc", "x.html", html}, "x", StoreOptions{AllowedMimes: []string{"text/html"}}, "x/{sum}.html", nil},
+		{"too large", testPart{"doc", "x.png", append(pngHead, make([]byte, 100)...)}, "x", StoreOptions{MaxSize: 50}, "", ErrFileTooLarge},
+		{"size check disabled", testPart{"doc", "x.png", append(pngHead, make([]byte, 100)...)}, "x", StoreOptions{MaxSize: -1}, "x/{sum}.png", nil},
+		{"absolute dir", testPart{"doc", "x.png", pngHead}, "/etc", StoreOptions{}, "", ErrUnsafeUploadTo},
+		{"missing file", testPart{"other", "x.png", pngHead}, "x", StoreOptions{}, "", http.ErrMissingFile},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			c := uploadContext(t, root, configs.UploadConfig{}, tt.part)
+			defer ReleaseContext(c)
+
+			stored, err := c.File("doc").Store(tt.dir, tt.opts)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Store() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := strings.ReplaceAll(tt.wantPath, "{sum}", sum(tt.part.content))
+			if stored.Path != want {
+				t.Fatalf("Path = %q, want %q", stored.Path, want)
+			}
+			if stored.SHA256 != sum(tt.part.content) || stored.Size != int64(len(tt.part.content)) {
+				t.Fatalf("SHA256, Size = %s, %d", stored.SHA256, stored.Size)
+			}
+			if stored.FullPath != filepath.Join(root, filepath.FromSlash(want)) {
+				t.Fatalf("FullPath = %q outside %q", stored.FullPath, root)
+			}
+			got, err := os.ReadFile(stored.FullPath)
+			if err != nil || !bytes.Equal(got, tt.part.content) {
+				t.Fatalf("stored content = %q, %v", got, err)
+			}
+		})
+	}
+}
+
+func TestMultipartStreaming(t *testing.T) {
+	root := t.TempDir()
+	content := append(pngHead, bytes.Repeat([]byte("x"), 1<<20)...)
+	c := uploadContext(t, root, configs.UploadConfig{MaxMemory: 1024},
+		testPart{field: "title", content: []byte("Hello")},
+		testPart{"photos", "a.png", content},
+		testPart{"photos", "b.png", pngHead},
+	)
+
+	// values come from the same single pass over the body
+	if c.Input("title") != "Hello" || !c.HasInput("title") || c.FormValue("page") != "2" {
+		t.Fatalf("title = %q, page = %q", c.Input("title"), c.FormValue("page"))
+	}
+	files := c.Files("photos")
+	if len(files) != 2 || files[0].Size() != int64(len(content)) {
+		t.Fatalf("files = %v", files)
+	}
+	if mimeType, _ := files[0].MIME(); mimeType != "image/png" {
+		t.Fatalf("MIME = %q", mimeType)
+	}
+
+	stored, err := files[0].Store("photos")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a second Store copies, the first moved the temp file
+	if _, err := files[0].Store("copies"); err != nil {
+		t.Fatal(err)
+	}
+	ReleaseContext(c)
+
+	if _, err := os.Stat(stored.FullPath); err != nil {
+		t.Fatalf("stored file removed with the request: %v", err)
+	}
+	left, _ := os.ReadDir(filepath.Join(root, ".tmp"))
+	if len(left) != 0 {
+		t.Fatalf("%d temp files left after the request", len(left))
+	}
+}
+
+func TestMultipartLimits(t *testing.T) {
+	tests := []struct {
+		name   string
+		upload configs.UploadConfig
+		parts  []testPart
+	}{
+		{"request size", configs.UploadConfig{MaxRequestSize: 512}, []testPart{{"doc", "a.png", bytes.Repeat([]byte("x"), 1024)}}},
+		{"form values in memory", configs.UploadConfig{MaxMemory: 16}, []testPart{{field: "note", content: bytes.Repeat([]byte("x"), 64)}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			c := uploadContext(t, root, tt.upload, tt.parts...)
+			if err := c.ParseForm(); err == nil {
+				t.Fatal("ParseForm() accepted a body over the limit")
+			}
+			if f := c.File("doc"); f.Exists() {
+				t.Fatal("File() exists after a failed parse")
+			}
+			ReleaseContext(c)
+			left, _ := os.ReadDir(filepath.Join(root, ".tmp"))
+			if len(left) != 0 {
+				t.Fatalf("%d temp files left", len(left))
+			}
+		})
+	}
+}
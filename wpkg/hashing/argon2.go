@@ -0,0 +1,102 @@
+// pkg/hashing/argon2.go
+package hashing
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// Argon2id defaults, the RFC 9106 second recommended option
+const (
+	DefaultArgonMemory  = 64 * 1024 // KiB
+	DefaultArgonTime    = 3
+	DefaultArgonThreads = 4
+
+	argonSaltLen = 16
+	argonKeyLen  = 32
+)
+
+// Argon2id hashes with argon2id in the PHC string format
+// ($argon2id$v=19$m=65536,t=3,p=4$salt$hash), compatible with PHP and others
+type Argon2id struct {
+	Memory  uint32 // KiB
+	Time    uint32
+	Threads uint8
+}
+
+func NewArgon2id(memory, time uint32, threads uint8) *Argon2id {
+	if memory == 0 {
+		memory = DefaultArgonMemory
+	}
+	if time == 0 {
+		time = DefaultArgonTime
+	}
+	if threads == 0 {
+		threads = DefaultArgonThreads
+	}
+	return &Argon2id{Memory: memory, Time: time, Threads: threads}
+}
+
+func (a *Argon2id) Make(password string) (string, error) {
+	salt := make([]byte, argonSaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(password), salt, a.Time, a.Memory, a.Threads, argonKeyLen)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, a.Memory, a.Time, a.Threads,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+func (a *Argon2id) Check(password, hash string) bool {
+	p, err := parseArgon(hash)
+	if err != nil {
+		return false
+	}
+	key := argon2.IDKey([]byte(password), p.salt, p.time, p.memory, p.threads, uint32(len(p.key)))
+	return subtle.ConstantTimeCompare(key, p.key) == 1
+}
+
+func (a *Argon2id) NeedsRehash(hash string) bool {
+	p, err := parseArgon(hash)
+	return err != nil || p.memory != a.Memory || p.time != a.Time || p.threads != a.Threads
+}
+
+func (a *Argon2id) Owns(hash string) bool {
+	return strings.HasPrefix(hash, "$argon2id$")
+}
+
+type argonParams struct {
+	memory, time uint32
+	threads      uint8
+	salt, key    []byte
+}
+
+func parseArgon(hash string) (*argonParams, error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return nil, ErrUnknownHash
+	}
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return nil, ErrUnknownHash
+	}
+	p := &argonParams{}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.memory, &p.time, &p.threads); err != nil {
+		return nil, ErrUnknownHash
+	}
+	var err error
+	if p.salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+		return nil, ErrUnknownHash
+	}
+	if p.key, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil || len(p.key) == 0 {
+		return nil, ErrUnknownHash
+	}
+	return p, nil
+}
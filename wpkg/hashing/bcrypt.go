@@ -0,0 +1,41 @@
+// pkg/hashing/bcrypt.go
+package hashing
+
+import (
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// DefaultBcryptRounds is used when hashing.bcrypt_rounds is not set
+const DefaultBcryptRounds = 12
+
+// Bcrypt hashes with bcrypt; passwords longer than 72 bytes are rejected
+type Bcrypt struct {
+	Rounds int
+}
+
+func NewBcrypt(rounds int) *Bcrypt {
+	if rounds < bcrypt.MinCost || rounds > bcrypt.MaxCost {
+		rounds = DefaultBcryptRounds
+	}
+	return &Bcrypt{Rounds: rounds}
+}
+
+func (b *Bcrypt) Make(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), b.Rounds)
+	return string(hash), err
+}
+
+func (b *Bcrypt) Check(password, hash string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
+
+func (b *Bcrypt) NeedsRehash(hash string) bool {
+	cost, err := bcrypt.Cost([]byte(hash))
+	return err != nil || cost != b.Rounds
+}
+
+func (b *Bcrypt) Owns(hash string) bool {
+	return strings.HasPrefix(hash, "$2a$") || strings.HasPrefix(hash, "$2b$") || strings.HasPrefix(hash, "$2y$")
+}
@@ -0,0 +1,107 @@
+// pkg/hashing/hashing.go
+package hashing
+
+import (
+	"errors"
+	"strings"
+	"sync"
+
+	"github.com/aasoft24/golara/wpkg/configs"
+)
+
+var ErrUnknownHash = errors.New("hashing: unknown hash format")
+
+// Hasher hashes passwords with one algorithm
+type Hasher interface {
+	// Make hashes password with a random salt
+	Make(password string) (string, error)
+	// Check reports whether password matches hash
+	Check(password, hash string) bool
+	// NeedsRehash reports whether hash was made with other parameters
+	NeedsRehash(hash string) bool
+	// Owns reports whether hash was made by this algorithm
+	Owns(hash string) bool
+}
+
+var (
+	mu       sync.RWMutex
+	instance Hasher
+)
+
+// FromConfig returns the hasher selected by the hashing section of config.yaml
+func FromConfig(cfg configs.HashingConfig) Hasher {
+	switch strings.ToLower(cfg.Driver) {
+	case "argon2id", "argon":
+		return NewArgon2id(cfg.ArgonMemory, cfg.ArgonTime, cfg.ArgonThreads)
+	}
+	return NewBcrypt(cfg.BcryptRounds)
+}
+
+// Default returns the app hasher, built from the loaded config on first use
+func Default() Hasher {
+	mu.RLock()
+	h := instance
+	mu.RUnlock()
+	if h != nil {
+		return h
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if instance == nil {
+		var cfg configs.HashingConfig
+		if configs.GConfig != nil {
+			cfg = configs.GConfig.Hashing
+		}
+		instance = FromConfig(cfg)
+	}
+	return instance
+}
+
+// SetDefault replaces the app hasher, e.g. with cheaper parameters in tests
+func SetDefault(h Hasher) {
+	mu.Lock()
+	defer mu.Unlock()
+	instance = h
+}
+
+// hashers knows every supported format, so Check accepts hashes made before
+// the driver was switched
+func hashers() []Hasher {
+	return []Hasher{Default(), NewBcrypt(0), NewArgon2id(0, 0, 0)}
+}
+
+// Make hashes password with the app hasher
+func Make(password string) (string, error) {
+	return Default().Make(password)
+}
+
+// Check reports whether password matches hash, whatever algorithm made it
+func Check(password, hash string) bool {
+	for _, h := range hashers() {
+		if h.Owns(hash) {
+			return h.Check(password, hash)
+		}
+	}
+	return false
+}
+
+// NeedsRehash reports whether hash was made with another algorithm or
+// other parameters than the app hasher
+func NeedsRehash(hash string) bool {
+	h := Default()
+	return !h.Owns(hash) || h.NeedsRehash(hash)
+}
+
+// Verify checks password and, when the hash is outdated, returns a new hash
+// to store, so logins upgrade hashes transparently
+func Verify(password, hash string) (ok bool, newHash string, err error) {
+	if !Check(password, hash) {
+		return false, "", nil
+	}
+	if !NeedsRehash(hash) {
+		return true, "", nil
+	}
+	newHash, err = Make(password)
+	return true, newHash, err
+}
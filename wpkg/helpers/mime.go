@@ -0,0 +1,175 @@
+// pkg/helpers/mime.go
+package helpers
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strings"
+)
+
+// oleMIME is reported for OLE compound files (legacy doc, xls, ppt), which
+// http.DetectContentType only knows as application/octet-stream
+const oleMIME = "application/x-ole-storage"
+
+// oleMagic starts every OLE compound file
+var oleMagic = []byte{0xD0, 0xCF, 0x11, 0xE0, 0xA1, 0xB1, 0x1A, 0xE1}
+
+// Office Open XML documents are zip containers; RefineZipMIME reports these
+// types for zips with the matching entries
+const (
+	docxMIME = "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
+	xlsxMIME = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	pptxMIME = "application/vnd.openxmlformats-officedocument.presentationml.presentation"
+)
+
+// extensionMimes maps file extensions to the MIME types SniffContentType
+// (and RefineZipMIME for Office documents) reports for their content
+var extensionMimes = map[string][]string{
+	"jpg":  {"image/jpeg"},
+	"jpeg": {"image/jpeg"},
+	"png":  {"image/png"},
+	"gif":  {"image/gif"},
+	"webp": {"image/webp"},
+	"bmp":  {"image/bmp"},
+	"ico":  {"image/x-icon"},
+	"pdf":  {"application/pdf"},
+	"zip":  {"application/zip"},
+	"gz":   {"application/x-gzip"},
+	"rar":  {"application/x-rar-compressed"},
+	"docx": {docxMIME},
+	"xlsx": {xlsxMIME},
+	"pptx": {pptxMIME},
+	"doc":  {oleMIME, "application/msword"},
+	"xls":  {oleMIME, "application/vnd.ms-excel"},
+	"txt":  {"text/plain"},
+	"csv":  {"text/plain", "text/csv"},
+	"json": {"text/plain", "application/json"},
+	"xml":  {"text/xml", "application/xml"},
+	"svg":  {"text/xml", "text/plain", "image/svg+xml"},
+	"html": {"text/html"},
+	"mp3":  {"audio/mpeg"},
+	"wav":  {"audio/wave"},
+	"ogg":  {"application/ogg", "audio/ogg"},
+	"mp4":  {"video/mp4"},
+	"webm": {"video/webm"},
+	"avi":  {"video/avi"},
+}
+
+// activeMimes are rendered by browsers and can run script on the site's origin
+var activeMimes = map[string]bool{
+	"text/html":             true,
+	"text/xml":              true,
+	"application/xml":       true,
+	"application/xhtml+xml": true,
+	"image/svg+xml":         true,
+}
+
+// IsActiveContent reports whether a sniffed MIME type is HTML, XML or SVG,
+// which must not be stored and served back as uploaded
+func IsActiveContent(mimeType string) bool {
+	return activeMimes[mimeType]
+}
+
+// SniffContentType detects the MIME type of r from its first 512 bytes,
+// without parameters such as "; charset=utf-8"
+func SniffContentType(r io.Reader) (string, error) {
+	buff := make([]byte, 512)
+	n, err := io.ReadFull(r, buff)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return "", err
+	}
+	if bytes.HasPrefix(buff[:n], oleMagic) {
+		return oleMIME, nil
+	}
+	mimeType := http.DetectContentType(buff[:n])
+	if i := strings.Index(mimeType, ";"); i >= 0 {
+		mimeType = mimeType[:i]
+	}
+	return strings.TrimSpace(mimeType), nil
+}
+
+// DetectFileMIME sniffs the MIME type of an uploaded file from its content
+func DetectFileMIME(file *multipart.FileHeader) (string, error) {
+	src, err := file.Open()
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+	mimeType, err := SniffContentType(src)
+	if err != nil {
+		return "", err
+	}
+	return RefineZipMIME(mimeType, src, file.Size), nil
+}
+
+// ooxmlDirs maps the main folder of an Office Open XML package to its type
+var ooxmlDirs = map[string]string{
+	"word/": docxMIME,
+	"xl/":   xlsxMIME,
+	"ppt/":  pptxMIME,
+}
+
+// RefineZipMIME reports a docx, xlsx or pptx type for a zip that holds
+// [Content_Types].xml and the matching word/, xl/ or ppt/ folder. Only the
+// zip directory is read, the parts themselves are not checked. Other types
+// and plain zips are returned unchanged.
+func RefineZipMIME(mimeType string, r io.ReaderAt, size int64) string {
+	if mimeType != "application/zip" {
+		return mimeType
+	}
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return mimeType
+	}
+	contentTypes, office := false, ""
+	for _, f := range zr.File {
+		if f.Name == "[Content_Types].xml" {
+			contentTypes = true
+			continue
+		}
+		if office != "" {
+			continue
+		}
+		for dir, t := range ooxmlDirs {
+			if strings.HasPrefix(f.Name, dir) {
+				office = t
+			}
+		}
+	}
+	if contentTypes && office != "" {
+		return office
+	}
+	return mimeType
+}
+
+// MimeMatchesExtension reports whether a sniffed MIME type is acceptable for ext
+func MimeMatchesExtension(mimeType, ext string) bool {
+	ext = strings.ToLower(strings.TrimPrefix(ext, "."))
+	for _, m := range extensionMimes[ext] {
+		if m == mimeType {
+			return true
+		}
+	}
+	return false
+}
+
+// ExtensionForMime returns the preferred extension for a sniffed MIME type
+func ExtensionForMime(mimeType string) string {
+	switch mimeType {
+	case "image/jpeg":
+		return "jpg"
+	case "text/plain":
+		return "txt"
+	case "application/zip":
+		return "zip"
+	}
+	for ext, mimes := range extensionMimes {
+		if len(mimes) == 1 && mimes[0] == mimeType {
+			return ext
+		}
+	}
+	return ""
+}
@@ -0,0 +1,64 @@
+// pkg/helpers/mime_test.go
+package helpers
+
+import (
+	"archive/zip"
+	"bytes"
+	"testing"
+)
+
+func zipOf(t *testing.T, names ...string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, name := range names {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte("x"))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestOfficeDocumentMIME(t *testing.T) {
+	tests := []struct {
+		name    string
+		content []byte
+		want    string
+		ext     string // extension the content must be accepted for
+		notExt  string // and one it must not
+	}{
+		{"plain zip", zipOf(t, "a.txt"), "application/zip", "zip", "docx"},
+		{"docx", zipOf(t, "[Content_Types].xml", "word/document.xml"), docxMIME, "docx", "zip"},
+		{"xlsx", zipOf(t, "[Content_Types].xml", "xl/workbook.xml"), xlsxMIME, "xlsx", "docx"},
+		{"pptx", zipOf(t, "[Content_Types].xml", "ppt/presentation.xml"), pptxMIME, "pptx", "xlsx"},
+		{"folder without content types", zipOf(t, "word/document.xml"), "application/zip", "zip", "docx"},
+		{"content types without folder", zipOf(t, "[Content_Types].xml", "evil.html"), "application/zip", "zip", "docx"},
+		{"png", []byte("\x89PNG\r\n\x1a\n0000"), "image/png", "png", "docx"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sniffed, err := SniffContentType(bytes.NewReader(tt.content))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := RefineZipMIME(sniffed, bytes.NewReader(tt.content), int64(len(tt.content)))
+			if got != tt.want {
+				t.Fatalf("MIME = %q, want %q", got, tt.want)
+			}
+			if !MimeMatchesExtension(got, tt.ext) {
+				t.Errorf("%s is not accepted for .%s", got, tt.ext)
+			}
+			if MimeMatchesExtension(got, tt.notExt) {
+				t.Errorf("%s is accepted for .%s", got, tt.notExt)
+			}
+			if ext := ExtensionForMime(got); ext != tt.ext {
+				t.Errorf("ExtensionForMime(%q) = %q, want %q", got, ext, tt.ext)
+			}
+		})
+	}
+}
@@ -8,3 +8,13 @@ type SafeUser struct {
 	Status  string
 	Balance float64
 }
+
+// GetAuthID lets a SafeUser be logged in by the auth package
+func (u SafeUser) GetAuthID() uint {
+	return u.ID
+}
+
+// GetAuthPassword is empty, a SafeUser never carries the hash
+func (u SafeUser) GetAuthPassword() string {
+	return ""
+}
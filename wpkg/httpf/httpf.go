@@ -2,6 +2,7 @@ package httpf
 
 import (
 	"bytes"
+	"context"
 	"crypto/tls"
 	"encoding/json"
 	"errors"
@@ -40,6 +41,7 @@ type HttpClient struct {
 	asForm       bool
 	acceptJson   bool
 	insecureSkip bool
+	ctx          context.Context
 }
 
 func New() *HttpClient {
@@ -55,6 +57,20 @@ func (h *HttpClient) Timeout(d time.Duration) *HttpClient {
 	return h
 }
 
+// WithContext cancels requests (and pending retries) when ctx is done;
+// a *gola.Context can be passed directly
+func (h *HttpClient) WithContext(ctx context.Context) *HttpClient {
+	h.ctx = ctx
+	return h
+}
+
+func (h *HttpClient) context() context.Context {
+	if h.ctx == nil {
+		return context.Background()
+	}
+	return h.ctx
+}
+
 func (h *HttpClient) Retry(times int, delay time.Duration) *HttpClient {
 	h.retries = times
 	h.retryDelay = delay
@@ -117,7 +133,7 @@ func (h *HttpClient) Attach(url, fieldName, filePath string, extra map[string]st
 	}
 	writer.Close()
 
-	req, err := http.NewRequest("POST", url, body)
+	req, err := http.NewRequestWithContext(h.context(), "POST", url, body)
 	if err != nil {
 		return &Response{Err: err}
 	}
@@ -136,10 +152,14 @@ func (h *HttpClient) do(req *http.Request) *Response {
 
 	for i := 0; i <= h.retries; i++ {
 		resp, err = h.client.Do(req)
-		if err == nil {
+		if err == nil || i == h.retries {
 			break
 		}
-		time.Sleep(h.retryDelay)
+		select {
+		case <-req.Context().Done():
+			return &Response{Err: req.Context().Err()}
+		case <-time.After(h.retryDelay):
+		}
 	}
 	if err != nil {
 		return &Response{Err: err}
@@ -177,7 +197,7 @@ func (h *HttpClient) request(method, url string, data interface{}) *Response {
 		}
 	}
 
-	req, err := http.NewRequest(method, url, body)
+	req, err := http.NewRequestWithContext(h.context(), method, url, body)
 	if err != nil {
 		return &Response{Err: err}
 	}
@@ -0,0 +1,173 @@
+// pkg/i18n/middleware.go
+package i18n
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/aasoft24/golara/wpkg/gola"
+	"github.com/aasoft24/golara/wpkg/view"
+)
+
+// Where the chosen locale is remembered between requests
+const (
+	SessionKey = "locale"
+	CookieName = "locale"
+)
+
+func init() {
+	// parse-time placeholders; Middleware rebinds them to the request locale
+	view.AddFuncs(Funcs(""))
+}
+
+// Middleware resolves the request locale from the URL prefix (or a :locale
+// route param), the session, the locale cookie, then Accept-Language.
+func Middleware(next func(ctx *gola.Context)) func(ctx *gola.Context) {
+	return func(ctx *gola.Context) {
+		locale, fromURL := resolveLocale(ctx, Default)
+
+		// remember an explicit URL choice for following requests
+		if fromURL && ctx.Session != nil {
+			ctx.Session.Set(SessionKey, locale)
+		}
+
+		ctx.Set("locale", locale)
+		ctx.Header("Content-Language", locale)
+		ctx.AddTemplateFuncs(Funcs(locale))
+
+		next(ctx)
+	}
+}
+
+func resolveLocale(ctx *gola.Context, t *Translator) (string, bool) {
+	if l := ctx.Param("locale"); l != "" && t.IsSupported(l) {
+		return l, true
+	}
+	segment := strings.SplitN(strings.TrimPrefix(ctx.Request.URL.Path, "/"), "/", 2)[0]
+	if segment != "" && t.IsSupported(segment) {
+		return segment, true
+	}
+
+	if ctx.Session != nil {
+		if l, ok := ctx.Session.Get(SessionKey).(string); ok && t.IsSupported(l) {
+			return l, false
+		}
+	}
+
+	if l, err := ctx.GetCookie(CookieName); err == nil && t.IsSupported(l) {
+		return l, false
+	}
+
+	if l := matchAcceptLanguage(ctx.Request.Header.Get("Accept-Language"), t); l != "" {
+		return l, false
+	}
+
+	return t.Locale, false
+}
+
+// matchAcceptLanguage picks the supported locale with the highest q weight
+func matchAcceptLanguage(header string, t *Translator) string {
+	type tag struct {
+		name string
+		q    float64
+	}
+	var tags []tag
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ";")
+		name := strings.TrimSpace(fields[0])
+		if name == "" || name == "*" {
+			continue
+		}
+		q := 1.0
+		for _, f := range fields[1:] {
+			if v, ok := strings.CutPrefix(strings.TrimSpace(f), "q="); ok {
+				if parsed, err := strconv.ParseFloat(v, 64); err == nil {
+					q = parsed
+				}
+			}
+		}
+		tags = append(tags, tag{name: name, q: q})
+	}
+	sort.SliceStable(tags, func(i, j int) bool { return tags[i].q > tags[j].q })
+
+	for _, tg := range tags {
+		if t.IsSupported(tg.name) {
+			return tg.name
+		}
+		if base := strings.SplitN(tg.name, "-", 2)[0]; t.IsSupported(base) {
+			return base
+		}
+	}
+	return ""
+}
+
+// SetLocale stores the user's choice in the session and a year-long cookie,
+// e.g. from a language switcher route
+func SetLocale(ctx *gola.Context, locale string) {
+	if !Default.IsSupported(locale) {
+		return
+	}
+	ctx.Set("locale", locale)
+	if ctx.Session != nil {
+		ctx.Session.Set(SessionKey, locale)
+	}
+	http.SetCookie(ctx.Writer, &http.Cookie{
+		Name:     CookieName,
+		Value:    locale,
+		Path:     "/",
+		MaxAge:   365 * 24 * 60 * 60,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// T translates key in the request locale
+func T(ctx *gola.Context, key string, params ...map[string]interface{}) string {
+	return Default.Get(ctx.Locale(), key, params...)
+}
+
+// Choice pluralizes key in the request locale
+func Choice(ctx *gola.Context, key string, count float64, params ...map[string]interface{}) string {
+	return Default.Choice(ctx.Locale(), key, count, params...)
+}
+
+// Funcs returns the "__", "trans_choice" and "locale" template functions for locale:
+//
+//	{{ __ "messages.welcome" "name" .User.Name }}
+//	{{ trans_choice "messages.apples" 3 }}
+func Funcs(locale string) template.FuncMap {
+	current := func() string {
+		if locale != "" {
+			return locale
+		}
+		return Default.Locale
+	}
+	return template.FuncMap{
+		"__": func(key string, args ...interface{}) string {
+			return Default.Get(current(), key, templateParams(args))
+		},
+		"trans_choice": func(key string, count interface{}, args ...interface{}) string {
+			n, _ := strconv.ParseFloat(fmt.Sprintf("%v", count), 64)
+			return Default.Choice(current(), key, n, templateParams(args))
+		},
+		"locale": current,
+	}
+}
+
+// templateParams accepts a single map or "key", value pairs
+func templateParams(args []interface{}) map[string]interface{} {
+	if len(args) == 1 {
+		if m, ok := args[0].(map[string]interface{}); ok {
+			return m
+		}
+	}
+	params := make(map[string]interface{}, len(args)/2)
+	for i := 0; i+1 < len(args); i += 2 {
+		params[fmt.Sprintf("%v", args[i])] = args[i+1]
+	}
+	return params
+}
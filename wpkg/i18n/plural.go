@@ -0,0 +1,191 @@
+// pkg/i18n/plural.go
+package i18n
+
+import (
+	"math"
+	"strings"
+)
+
+// CLDR plural categories
+const (
+	Zero  = "zero"
+	One   = "one"
+	Two   = "two"
+	Few   = "few"
+	Many  = "many"
+	Other = "other"
+)
+
+// PluralRule maps a number to its CLDR category
+type PluralRule func(n float64) string
+
+type pluralSpec struct {
+	rule       PluralRule
+	categories []string // order used by "form|form|form" lines
+}
+
+// Rules from the CLDR plural rules chart, grouped by language
+var pluralRules = map[string]pluralSpec{}
+
+func init() {
+	oneOther := pluralSpec{rule: ruleOneOther, categories: []string{One, Other}}
+	for _, lang := range []string{"en", "de", "nl", "sv", "da", "no", "nb", "fi", "et", "it", "es", "el", "hu", "tr", "ur", "sw", "bg", "ca"} {
+		pluralRules[lang] = oneOther
+	}
+
+	zeroOneOther := pluralSpec{rule: ruleZeroOrOne, categories: []string{One, Other}}
+	for _, lang := range []string{"bn", "hi", "gu", "kn", "mr", "fa", "zu", "am", "as"} {
+		pluralRules[lang] = zeroOneOther
+	}
+	pluralRules["fr"] = pluralSpec{rule: ruleFrench, categories: []string{One, Other}}
+	pluralRules["pt"] = pluralSpec{rule: ruleFrench, categories: []string{One, Other}}
+
+	otherOnly := pluralSpec{rule: func(float64) string { return Other }, categories: []string{Other}}
+	for _, lang := range []string{"ja", "zh", "ko", "th", "vi", "id", "ms", "my", "lo", "km"} {
+		pluralRules[lang] = otherOnly
+	}
+
+	slavic := pluralSpec{rule: ruleEastSlavic, categories: []string{One, Few, Many, Other}}
+	for _, lang := range []string{"ru", "uk", "be"} {
+		pluralRules[lang] = slavic
+	}
+	pluralRules["pl"] = pluralSpec{rule: rulePolish, categories: []string{One, Few, Many, Other}}
+	pluralRules["cs"] = pluralSpec{rule: ruleCzech, categories: []string{One, Few, Many, Other}}
+	pluralRules["sk"] = pluralRules["cs"]
+	pluralRules["ar"] = pluralSpec{rule: ruleArabic, categories: []string{Zero, One, Two, Few, Many, Other}}
+}
+
+// RegisterPluralRule adds or replaces the plural rule of a language
+func RegisterPluralRule(lang string, rule PluralRule, categories ...string) {
+	if len(categories) == 0 {
+		categories = []string{One, Other}
+	}
+	pluralRules[lang] = pluralSpec{rule: rule, categories: categories}
+}
+
+func specFor(locale string) pluralSpec {
+	if spec, ok := pluralRules[locale]; ok {
+		return spec
+	}
+	if spec, ok := pluralRules[strings.SplitN(locale, "-", 2)[0]]; ok {
+		return spec
+	}
+	return pluralRules["en"]
+}
+
+// PluralCategory returns the CLDR category of n in locale
+func PluralCategory(locale string, n float64) string {
+	return specFor(locale).rule(n)
+}
+
+// Categories returns the plural categories of locale in "form|form" order
+func Categories(locale string) []string {
+	return specFor(locale).categories
+}
+
+func isInteger(n float64) bool {
+	return n == math.Trunc(n)
+}
+
+func isPluralForms(m map[string]interface{}) bool {
+	if _, ok := m[Other]; !ok {
+		return false
+	}
+	for k := range m {
+		switch k {
+		case Zero, One, Two, Few, Many, Other:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// one: i = 1 and v = 0
+func ruleOneOther(n float64) string {
+	if n == 1 {
+		return One
+	}
+	return Other
+}
+
+// one: i = 0 or n = 1
+func ruleZeroOrOne(n float64) string {
+	if math.Abs(n) < 1 || n == 1 {
+		return One
+	}
+	return Other
+}
+
+// one: i = 0,1
+func ruleFrench(n float64) string {
+	if math.Abs(n) < 2 {
+		return One
+	}
+	return Other
+}
+
+func ruleEastSlavic(n float64) string {
+	if !isInteger(n) {
+		return Other
+	}
+	i := int64(math.Abs(n))
+	switch {
+	case i%10 == 1 && i%100 != 11:
+		return One
+	case i%10 >= 2 && i%10 <= 4 && (i%100 < 12 || i%100 > 14):
+		return Few
+	default:
+		return Many
+	}
+}
+
+func rulePolish(n float64) string {
+	if !isInteger(n) {
+		return Other
+	}
+	i := int64(math.Abs(n))
+	switch {
+	case i == 1:
+		return One
+	case i%10 >= 2 && i%10 <= 4 && (i%100 < 12 || i%100 > 14):
+		return Few
+	default:
+		return Many
+	}
+}
+
+func ruleCzech(n float64) string {
+	if !isInteger(n) {
+		return Many
+	}
+	switch i := int64(math.Abs(n)); {
+	case i == 1:
+		return One
+	case i >= 2 && i <= 4:
+		return Few
+	default:
+		return Other
+	}
+}
+
+func ruleArabic(n float64) string {
+	if !isInteger(n) {
+		return Other
+	}
+	i := int64(math.Abs(n))
+	switch {
+	case i == 0:
+		return Zero
+	case i == 1:
+		return One
+	case i == 2:
+		return Two
+	case i%100 >= 3 && i%100 <= 10:
+		return Few
+	case i%100 >= 11 && i%100 <= 99:
+		return Many
+	default:
+		return Other
+	}
+}
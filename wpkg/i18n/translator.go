@@ -0,0 +1,301 @@
+// pkg/i18n/translator.go
+package i18n
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/aasoft24/golara/wpkg/configs"
+	"gopkg.in/yaml.v3"
+)
+
+// Translator holds the lines of every locale, keyed "file.key.nested"
+type Translator struct {
+	Locale         string
+	FallbackLocale string
+	Locales        []string // supported locales, in preference order
+
+	path  string
+	lines map[string]map[string]interface{}
+	mu    sync.RWMutex
+}
+
+// Default translator used by the package level helpers
+var Default = NewTranslator("resources/lang", "en", "en")
+
+// NewTranslator creates a translator reading resources/lang/{locale}/*.json|yaml
+func NewTranslator(path, locale, fallback string) *Translator {
+	return &Translator{
+		Locale:         locale,
+		FallbackLocale: fallback,
+		path:           path,
+		lines:          make(map[string]map[string]interface{}),
+	}
+}
+
+// Init configures the default translator from config.yaml and loads the lang files
+func Init() error {
+	cfg := configs.GConfig
+	if cfg != nil {
+		if cfg.App.Locale != "" {
+			Default.Locale = cfg.App.Locale
+		}
+		if cfg.App.FallbackLocale != "" {
+			Default.FallbackLocale = cfg.App.FallbackLocale
+		}
+		Default.Locales = cfg.App.Locales
+	}
+	return Default.Load()
+}
+
+// Load (re)reads all locale directories below the lang path
+func (t *Translator) Load() error {
+	entries, err := os.ReadDir(t.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	lines := make(map[string]map[string]interface{})
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		locale := entry.Name()
+		lines[locale] = make(map[string]interface{})
+
+		files, _ := filepath.Glob(filepath.Join(t.path, locale, "*"))
+		for _, file := range files {
+			ext := filepath.Ext(file)
+			if ext != ".json" && ext != ".yaml" && ext != ".yml" {
+				continue
+			}
+			data, err := os.ReadFile(file)
+			if err != nil {
+				return err
+			}
+
+			var parsed map[string]interface{}
+			if ext == ".json" {
+				err = json.Unmarshal(data, &parsed)
+			} else {
+				err = yaml.Unmarshal(data, &parsed)
+			}
+			if err != nil {
+				return fmt.Errorf("i18n: %s: %v", file, err)
+			}
+
+			group := strings.TrimSuffix(filepath.Base(file), ext)
+			flatten(lines[locale], group, parsed)
+		}
+	}
+
+	t.mu.Lock()
+	t.lines = lines
+	t.mu.Unlock()
+	return nil
+}
+
+// AddLines registers lines for a locale at runtime, keys like "messages.welcome"
+func (t *Translator) AddLines(locale string, lines map[string]interface{}) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.lines[locale] == nil {
+		t.lines[locale] = make(map[string]interface{})
+	}
+	flatten(t.lines[locale], "", lines)
+}
+
+// flatten turns nested maps into dotted keys. Maps made only of plural
+// categories ("one", "other", ...) are kept as a single line.
+func flatten(dst map[string]interface{}, prefix string, src map[string]interface{}) {
+	for k, v := range src {
+		key := k
+		if prefix != "" {
+			key = prefix + "." + k
+		}
+		if nested, ok := v.(map[string]interface{}); ok {
+			if isPluralForms(nested) {
+				forms := make(map[string]string, len(nested))
+				for cat, form := range nested {
+					forms[cat] = fmt.Sprintf("%v", form)
+				}
+				dst[key] = forms
+				continue
+			}
+			flatten(dst, key, nested)
+			continue
+		}
+		dst[key] = v
+	}
+}
+
+// HasLocale reports whether lang files were loaded for locale
+func (t *Translator) HasLocale(locale string) bool {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	_, ok := t.lines[locale]
+	return ok
+}
+
+// Supported returns the configured locales, or every loaded locale
+func (t *Translator) Supported() []string {
+	if len(t.Locales) > 0 {
+		return t.Locales
+	}
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	locales := make([]string, 0, len(t.lines))
+	for locale := range t.lines {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales)
+	return locales
+}
+
+// IsSupported reports whether locale can be selected by the middleware
+func (t *Translator) IsSupported(locale string) bool {
+	for _, l := range t.Supported() {
+		if l == locale {
+			return true
+		}
+	}
+	return false
+}
+
+// line finds a key in locale, its base language ("bn-BD" => "bn") and the fallback
+func (t *Translator) line(locale, key string) (interface{}, string, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	candidates := []string{locale}
+	if base := strings.SplitN(locale, "-", 2)[0]; base != locale {
+		candidates = append(candidates, base)
+	}
+	candidates = append(candidates, t.FallbackLocale)
+
+	for _, l := range candidates {
+		if v, ok := t.lines[l][key]; ok {
+			return v, l, true
+		}
+	}
+	return nil, "", false
+}
+
+// Get translates key into locale; the key itself is returned when missing
+func (t *Translator) Get(locale, key string, params ...map[string]interface{}) string {
+	if locale == "" {
+		locale = t.Locale
+	}
+	v, _, ok := t.line(locale, key)
+	if !ok {
+		return replaceParams(key, params...)
+	}
+	switch line := v.(type) {
+	case string:
+		return replaceParams(line, params...)
+	case map[string]string:
+		return replaceParams(line[Other], params...)
+	default:
+		return replaceParams(fmt.Sprintf("%v", line), params...)
+	}
+}
+
+// Choice translates a pluralized key using the CLDR rules of the locale.
+// Lines are either "apple|apples" (forms in the order of the locale's categories)
+// or a map such as {"one": ":count apple", "other": ":count apples"}.
+// :count is always available as a placeholder.
+func (t *Translator) Choice(locale, key string, count float64, params ...map[string]interface{}) string {
+	if locale == "" {
+		locale = t.Locale
+	}
+	merged := map[string]interface{}{"count": formatCount(count)}
+	for _, p := range params {
+		for k, v := range p {
+			merged[k] = v
+		}
+	}
+
+	v, lineLocale, ok := t.line(locale, key)
+	if !ok {
+		return replaceParams(key, merged)
+	}
+
+	category := PluralCategory(lineLocale, count)
+	switch line := v.(type) {
+	case map[string]string:
+		if form, ok := line[category]; ok {
+			return replaceParams(form, merged)
+		}
+		return replaceParams(line[Other], merged)
+	default:
+		forms := strings.Split(fmt.Sprintf("%v", line), "|")
+		cats := Categories(lineLocale)
+		for i, cat := range cats {
+			if cat == category && i < len(forms) {
+				return replaceParams(strings.TrimSpace(forms[i]), merged)
+			}
+		}
+		return replaceParams(strings.TrimSpace(forms[len(forms)-1]), merged)
+	}
+}
+
+func formatCount(count float64) string {
+	return strconv.FormatFloat(count, 'f', -1, 64)
+}
+
+// replaceParams swaps :name placeholders, also :Name and :NAME for capitalized values
+func replaceParams(line string, params ...map[string]interface{}) string {
+	if len(params) == 0 || !strings.Contains(line, ":") {
+		return line
+	}
+	merged := map[string]string{}
+	for _, p := range params {
+		for k, v := range p {
+			merged[k] = fmt.Sprintf("%v", v)
+		}
+	}
+
+	keys := make([]string, 0, len(merged))
+	for k := range merged {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return len(keys[i]) > len(keys[j]) })
+
+	pairs := []string{}
+	for _, k := range keys {
+		v := merged[k]
+		pairs = append(pairs,
+			":"+strings.ToUpper(k), strings.ToUpper(v),
+			":"+capitalize(k), capitalize(v),
+			":"+k, v,
+		)
+	}
+	return strings.NewReplacer(pairs...).Replace(line)
+}
+
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+	r := []rune(s)
+	return strings.ToUpper(string(r[0])) + string(r[1:])
+}
+
+// Trans translates key with the default translator
+func Trans(locale, key string, params ...map[string]interface{}) string {
+	return Default.Get(locale, key, params...)
+}
+
+// TransChoice pluralizes key with the default translator
+func TransChoice(locale, key string, count float64, params ...map[string]interface{}) string {
+	return Default.Choice(locale, key, count, params...)
+}
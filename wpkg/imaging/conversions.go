@@ -0,0 +1,93 @@
+// pkg/imaging/conversions.go
+package imaging
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+)
+
+// Resize modes for conversions
+const (
+	ModeFit  = "fit"
+	ModeFill = "fill"
+	ModeCrop = "crop"
+)
+
+// Conversion is a named output size, e.g. "thumb" => 150x150 fill as jpeg
+type Conversion struct {
+	Mode    string // fit (default), fill or crop
+	Width   int
+	Height  int
+	Format  string // "", jpeg, png, "webp-or-jpeg", ...
+	Quality int
+}
+
+var (
+	conversionsMu sync.RWMutex
+	conversions   = map[string]Conversion{}
+)
+
+// RegisterConversion adds a named conversion, usually from a service provider
+func RegisterConversion(name string, c Conversion) {
+	conversionsMu.Lock()
+	defer conversionsMu.Unlock()
+	conversions[name] = c
+}
+
+// GetConversion returns a registered conversion
+func GetConversion(name string) (Conversion, bool) {
+	conversionsMu.RLock()
+	defer conversionsMu.RUnlock()
+	c, ok := conversions[name]
+	return c, ok
+}
+
+// Apply runs the conversion's resize mode
+func (i *Image) Apply(c Conversion) *Image {
+	switch c.Mode {
+	case ModeFill:
+		return i.Fill(c.Width, c.Height)
+	case ModeCrop:
+		return i.Crop(c.Width, c.Height)
+	default:
+		return i.Fit(c.Width, c.Height)
+	}
+}
+
+// Converted is one produced conversion
+type Converted struct {
+	Name   string
+	Path   string
+	Format string
+	Width  int
+	Height int
+}
+
+// SaveConversions writes every named conversion to dir as {base}-{name}.{ext}
+func (i *Image) SaveConversions(dir, base string, names ...string) (map[string]Converted, error) {
+	if i.err != nil {
+		return nil, i.err
+	}
+	out := make(map[string]Converted, len(names))
+	for _, name := range names {
+		c, ok := GetConversion(name)
+		if !ok {
+			return out, fmt.Errorf("imaging: unknown conversion %q", name)
+		}
+		converted := i.Apply(c)
+		path, err := converted.Save(filepath.Join(dir, base+"-"+name), c.Format, c.Quality)
+		if err != nil {
+			return out, err
+		}
+		_, format, _ := converted.resolveEncoder(c.Format)
+		out[name] = Converted{
+			Name:   name,
+			Path:   path,
+			Format: format,
+			Width:  converted.Width(),
+			Height: converted.Height(),
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,133 @@
+// pkg/imaging/exif.go
+package imaging
+
+import (
+	"encoding/binary"
+	"image"
+)
+
+// jpegOrientation reads the EXIF Orientation tag (1-8) from raw JPEG bytes.
+// It returns 1 (normal) when there is no EXIF block or it can't be parsed.
+func jpegOrientation(data []byte) int {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return 1
+	}
+	pos := 2
+	for pos+4 <= len(data) {
+		if data[pos] != 0xFF {
+			return 1
+		}
+		marker := data[pos+1]
+		if marker == 0xDA || marker == 0xD9 { // start of scan / end of image
+			return 1
+		}
+		size := int(binary.BigEndian.Uint16(data[pos+2:]))
+		if size < 2 || pos+2+size > len(data) {
+			return 1
+		}
+		segment := data[pos+4 : pos+2+size]
+		if marker == 0xE1 && len(segment) > 6 && string(segment[:6]) == "Exif\x00\x00" {
+			return tiffOrientation(segment[6:])
+		}
+		pos += 2 + size
+	}
+	return 1
+}
+
+func tiffOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd+2 > len(tiff) {
+		return 1
+	}
+	entries := int(order.Uint16(tiff[ifd:]))
+	for i := 0; i < entries; i++ {
+		entry := ifd + 2 + i*12
+		if entry+12 > len(tiff) {
+			return 1
+		}
+		if order.Uint16(tiff[entry:]) == 0x0112 {
+			v := int(order.Uint16(tiff[entry+8:]))
+			if v >= 1 && v <= 8 {
+				return v
+			}
+			return 1
+		}
+	}
+	return 1
+}
+
+// applyOrientation rotates/flips img so it displays upright
+func applyOrientation(img image.Image, orientation int) image.Image {
+	switch orientation {
+	case 2:
+		return flipH(img)
+	case 3:
+		return rotate180(img)
+	case 4:
+		return flipV(img)
+	case 5:
+		return flipH(rotate90(img))
+	case 6:
+		return rotate90(img)
+	case 7:
+		return flipH(rotate270(img))
+	case 8:
+		return rotate270(img)
+	}
+	return img
+}
+
+// transform builds a dst of size w x h where dst(x,y) = src(fn(x,y))
+func transform(img image.Image, w, h int, fn func(x, y int) (int, int)) *image.NRGBA {
+	src := toNRGBA(img)
+	dst := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			sx, sy := fn(x, y)
+			copy(dst.Pix[y*dst.Stride+x*4:y*dst.Stride+x*4+4], src.Pix[sy*src.Stride+sx*4:])
+		}
+	}
+	return dst
+}
+
+// rotate90 rotates clockwise
+func rotate90(img image.Image) image.Image {
+	b := img.Bounds()
+	w, h := b.Dy(), b.Dx()
+	return transform(img, w, h, func(x, y int) (int, int) { return y, b.Dy() - 1 - x })
+}
+
+// rotate270 rotates counter-clockwise
+func rotate270(img image.Image) image.Image {
+	b := img.Bounds()
+	w, h := b.Dy(), b.Dx()
+	return transform(img, w, h, func(x, y int) (int, int) { return b.Dx() - 1 - y, x })
+}
+
+func rotate180(img image.Image) image.Image {
+	b := img.Bounds()
+	return transform(img, b.Dx(), b.Dy(), func(x, y int) (int, int) { return b.Dx() - 1 - x, b.Dy() - 1 - y })
+}
+
+func flipH(img image.Image) image.Image {
+	b := img.Bounds()
+	return transform(img, b.Dx(), b.Dy(), func(x, y int) (int, int) { return b.Dx() - 1 - x, y })
+}
+
+func flipV(img image.Image) image.Image {
+	b := img.Bounds()
+	return transform(img, b.Dx(), b.Dy(), func(x, y int) (int, int) { return x, b.Dy() - 1 - y })
+}
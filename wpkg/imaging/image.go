@@ -0,0 +1,318 @@
+// pkg/imaging/image.go
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/aasoft24/golara/wpkg/configs"
+	"golang.org/x/image/bmp"
+	_ "golang.org/x/image/webp" // decoder only, see RegisterEncoder
+)
+
+var (
+	ErrUnsupportedFormat = errors.New("unsupported image format")
+	ErrImageTooLarge     = errors.New("image is too large")
+)
+
+// Defaults used when config.yaml sets no upload.max_image_* limits
+const (
+	defaultMaxImageSize   = 20 << 20
+	defaultMaxImagePixels = 40_000_000
+)
+
+// limits returns upload.max_image_size and upload.max_image_pixels, <= 0
+// meaning unlimited
+func limits() (size, pixels int64) {
+	size, pixels = defaultMaxImageSize, defaultMaxImagePixels
+	if cfg := configs.GConfig; cfg != nil {
+		if cfg.Upload.MaxImageSize != 0 {
+			size = cfg.Upload.MaxImageSize
+		}
+		if cfg.Upload.MaxImagePixels != 0 {
+			pixels = cfg.Upload.MaxImagePixels
+		}
+	}
+	return size, pixels
+}
+
+// Encoder writes img in a format; quality is 1-100 where it applies
+type Encoder func(w io.Writer, img image.Image, quality int) error
+
+var (
+	encodersMu sync.RWMutex
+	encoders   = map[string]Encoder{
+		"jpeg": func(w io.Writer, img image.Image, quality int) error {
+			return jpeg.Encode(w, flatten(img), &jpeg.Options{Quality: quality})
+		},
+		"png": func(w io.Writer, img image.Image, quality int) error {
+			enc := png.Encoder{CompressionLevel: png.BestCompression}
+			return enc.Encode(w, img)
+		},
+		"gif": func(w io.Writer, img image.Image, quality int) error {
+			return gif.Encode(w, img, nil)
+		},
+		"bmp": func(w io.Writer, img image.Image, quality int) error {
+			return bmp.Encode(w, img)
+		},
+	}
+)
+
+// RegisterEncoder plugs in an extra output format, e.g. a webp encoder
+func RegisterEncoder(format string, enc Encoder) {
+	encodersMu.Lock()
+	defer encodersMu.Unlock()
+	encoders[format] = enc
+}
+
+func encoderFor(format string) (Encoder, bool) {
+	encodersMu.RLock()
+	defer encodersMu.RUnlock()
+	enc, ok := encoders[format]
+	return enc, ok
+}
+
+// Image is a decoded, upright image. Every operation returns a new Image,
+// so one upload can feed several conversions. Errors are carried along the
+// chain and returned by Encode/Save.
+type Image struct {
+	img    image.Image
+	format string // source format: jpeg, png, gif
+	err    error
+}
+
+// Decode reads an image, applying the EXIF orientation of JPEGs.
+// Re-encoding never copies EXIF/metadata, so output is always stripped.
+// Images over upload.max_image_size bytes or upload.max_image_pixels are
+// rejected with ErrImageTooLarge; the pixel count is read from the header
+// before anything is decoded.
+func Decode(r io.Reader) *Image {
+	maxSize, maxPixels := limits()
+	if maxSize > 0 {
+		r = io.LimitReader(r, maxSize+1)
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return &Image{err: err}
+	}
+	if maxSize > 0 && int64(len(data)) > maxSize {
+		return &Image{err: ErrImageTooLarge}
+	}
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return &Image{err: fmt.Errorf("%w: %v", ErrUnsupportedFormat, err)}
+	}
+	if maxPixels > 0 && int64(cfg.Width)*int64(cfg.Height) > maxPixels {
+		return &Image{err: ErrImageTooLarge}
+	}
+	img, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return &Image{err: fmt.Errorf("%w: %v", ErrUnsupportedFormat, err)}
+	}
+	if format == "jpeg" {
+		img = applyOrientation(img, jpegOrientation(data))
+	}
+	return &Image{img: img, format: format}
+}
+
+// Open decodes an image file
+func Open(path string) *Image {
+	f, err := os.Open(path)
+	if err != nil {
+		return &Image{err: err}
+	}
+	defer f.Close()
+	return Decode(f)
+}
+
+// FromImage wraps an already decoded image
+func FromImage(img image.Image) *Image {
+	return &Image{img: img, format: "png"}
+}
+
+// FromError starts a chain that only returns err
+func FromError(err error) *Image {
+	return &Image{err: err}
+}
+
+func (i *Image) with(img image.Image) *Image {
+	return &Image{img: img, format: i.format}
+}
+
+// Err returns the first error of the chain
+func (i *Image) Err() error { return i.err }
+
+// Image returns the underlying image.Image
+func (i *Image) Image() image.Image { return i.img }
+
+// Format returns the decoded source format
+func (i *Image) Format() string { return i.format }
+
+// Width of the current image
+func (i *Image) Width() int {
+	if i.err != nil {
+		return 0
+	}
+	return i.img.Bounds().Dx()
+}
+
+// Height of the current image
+func (i *Image) Height() int {
+	if i.err != nil {
+		return 0
+	}
+	return i.img.Bounds().Dy()
+}
+
+// Resize scales to exactly w x h; a zero side keeps the aspect ratio
+func (i *Image) Resize(w, h int) *Image {
+	if i.err != nil {
+		return i
+	}
+	sw, sh := i.Width(), i.Height()
+	switch {
+	case w <= 0 && h <= 0:
+		return i
+	case w <= 0:
+		w = max(1, sw*h/sh)
+	case h <= 0:
+		h = max(1, sh*w/sw)
+	}
+	return i.with(resample(i.img, w, h))
+}
+
+// Fit scales down to fit inside w x h keeping the aspect ratio (never upscales)
+func (i *Image) Fit(w, h int) *Image {
+	if i.err != nil {
+		return i
+	}
+	sw, sh := i.Width(), i.Height()
+	if sw <= w && sh <= h {
+		return i
+	}
+	scale := min(float64(w)/float64(sw), float64(h)/float64(sh))
+	return i.with(resample(i.img, max(1, int(float64(sw)*scale+0.5)), max(1, int(float64(sh)*scale+0.5))))
+}
+
+// Fill scales to cover w x h and crops the overflow from the center
+func (i *Image) Fill(w, h int) *Image {
+	if i.err != nil {
+		return i
+	}
+	sw, sh := i.Width(), i.Height()
+	scale := max(float64(w)/float64(sw), float64(h)/float64(sh))
+	rw, rh := max(w, int(float64(sw)*scale+0.5)), max(h, int(float64(sh)*scale+0.5))
+	return i.with(resample(i.img, rw, rh)).Crop(w, h)
+}
+
+// Crop cuts a w x h area from the center without scaling
+func (i *Image) Crop(w, h int) *Image {
+	if i.err != nil {
+		return i
+	}
+	sw, sh := i.Width(), i.Height()
+	x, y := (sw-w)/2, (sh-h)/2
+	return i.CropRect(max(0, x), max(0, y), w, h)
+}
+
+// CropRect cuts the rectangle at x,y with size w x h
+func (i *Image) CropRect(x, y, w, h int) *Image {
+	if i.err != nil {
+		return i
+	}
+	b := i.img.Bounds()
+	r := image.Rect(b.Min.X+x, b.Min.Y+y, b.Min.X+x+w, b.Min.Y+y+h)
+	return i.with(crop(i.img, r))
+}
+
+// Encode writes the image. format is jpeg, png, gif, a registered format,
+// "a-or-b" (first available encoder) or "" for the source format.
+func (i *Image) Encode(w io.Writer, format string, quality int) error {
+	if i.err != nil {
+		return i.err
+	}
+	enc, _, err := i.resolveEncoder(format)
+	if err != nil {
+		return err
+	}
+	if quality <= 0 || quality > 100 {
+		quality = 85
+	}
+	return enc(w, i.img, quality)
+}
+
+// Bytes encodes the image into memory and returns the chosen format
+func (i *Image) Bytes(format string, quality int) ([]byte, string, error) {
+	if i.err != nil {
+		return nil, "", i.err
+	}
+	_, chosen, err := i.resolveEncoder(format)
+	if err != nil {
+		return nil, "", err
+	}
+	var buf bytes.Buffer
+	if err := i.Encode(&buf, chosen, quality); err != nil {
+		return nil, "", err
+	}
+	return buf.Bytes(), chosen, nil
+}
+
+// Save encodes into path; the extension of the chosen format replaces path's own
+func (i *Image) Save(path, format string, quality int) (string, error) {
+	data, chosen, err := i.Bytes(format, quality)
+	if err != nil {
+		return "", err
+	}
+	path = strings.TrimSuffix(path, filepath.Ext(path)) + "." + Extension(chosen)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", err
+	}
+	return path, os.WriteFile(path, data, 0644)
+}
+
+func (i *Image) resolveEncoder(format string) (Encoder, string, error) {
+	if format == "" {
+		format = i.format
+	}
+	for _, candidate := range strings.Split(format, "-or-") {
+		candidate = strings.ToLower(strings.TrimSpace(candidate))
+		if candidate == "jpg" {
+			candidate = "jpeg"
+		}
+		if enc, ok := encoderFor(candidate); ok {
+			return enc, candidate, nil
+		}
+	}
+	return nil, "", fmt.Errorf("%w: %s", ErrUnsupportedFormat, format)
+}
+
+// Extension returns the file extension for an encoder format
+func Extension(format string) string {
+	if format == "jpeg" {
+		return "jpg"
+	}
+	return format
+}
+
+// flatten puts transparent images on white, as JPEG has no alpha
+func flatten(img image.Image) image.Image {
+	if o, ok := img.(interface{ Opaque() bool }); ok && o.Opaque() {
+		return img
+	}
+	b := img.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(dst, dst.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.Draw(dst, dst.Bounds(), img, b.Min, draw.Over)
+	return dst
+}
@@ -0,0 +1,213 @@
+// pkg/imaging/image_test.go
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/aasoft24/golara/wpkg/configs"
+)
+
+var (
+	red  = color.NRGBA{R: 255, A: 255}
+	blue = color.NRGBA{B: 255, A: 255}
+)
+
+// tiffIFD is an EXIF TIFF block with a single Orientation entry
+func tiffIFD(order binary.ByteOrder, orientation uint16) []byte {
+	b := make([]byte, 26)
+	if order == binary.LittleEndian {
+		copy(b, "II")
+	} else {
+		copy(b, "MM")
+	}
+	order.PutUint16(b[2:], 42)
+	order.PutUint32(b[4:], 8) // first IFD
+	order.PutUint16(b[8:], 1) // one entry
+	order.PutUint16(b[10:], 0x0112)
+	order.PutUint16(b[12:], 3) // SHORT
+	order.PutUint32(b[14:], 1)
+	order.PutUint16(b[18:], orientation)
+	return b
+}
+
+// withExif puts an APP1 segment holding tiff right after the JPEG's SOI
+func withExif(jpg, tiff []byte) []byte {
+	payload := append([]byte("Exif\x00\x00"), tiff...)
+	seg := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(seg[2:], uint16(len(payload)+2))
+	out := append([]byte{}, jpg[:2]...)
+	out = append(out, seg...)
+	out = append(out, payload...)
+	return append(out, jpg[2:]...)
+}
+
+func encodeJPEG(t *testing.T, w, h int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, image.NewNRGBA(image.Rect(0, 0, w, h)), nil); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func encodePNG(t *testing.T, w, h int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewNRGBA(image.Rect(0, 0, w, h))); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestJPEGOrientation(t *testing.T) {
+	jpg := encodeJPEG(t, 4, 2)
+	tests := []struct {
+		name string
+		data []byte
+		want int
+	}{
+		{"no exif", jpg, 1},
+		{"little endian", withExif(jpg, tiffIFD(binary.LittleEndian, 6)), 6},
+		{"big endian", withExif(jpg, tiffIFD(binary.BigEndian, 8)), 8},
+		{"out of range", withExif(jpg, tiffIFD(binary.LittleEndian, 9)), 1},
+		{"bad byte order", withExif(jpg, append([]byte("XX"), tiffIFD(binary.BigEndian, 6)[2:]...)), 1},
+		{"truncated tiff", withExif(jpg, tiffIFD(binary.BigEndian, 6)[:12]), 1},
+		{"not a jpeg", encodePNG(t, 4, 2), 1},
+		{"empty", nil, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := jpegOrientation(tt.data); got != tt.want {
+				t.Fatalf("jpegOrientation() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyOrientation(t *testing.T) {
+	// stored is 2x3, red in its top-left corner and blue in its top-right
+	stored := image.NewNRGBA(image.Rect(0, 0, 2, 3))
+	stored.Set(0, 0, red)
+	stored.Set(1, 0, blue)
+	corner := func(img image.Image, name string) color.Color {
+		b := img.Bounds()
+		x, y := b.Min.X, b.Min.Y
+		if name[1] == 'r' {
+			x = b.Max.X - 1
+		}
+		if name[0] == 'b' {
+			y = b.Max.Y - 1
+		}
+		return img.At(x, y)
+	}
+
+	tests := []struct {
+		orientation int
+		w, h        int
+		red, blue   string // the corners red and blue end up in
+	}{
+		{1, 2, 3, "tl", "tr"},
+		{2, 2, 3, "tr", "tl"},
+		{3, 2, 3, "br", "bl"},
+		{4, 2, 3, "bl", "br"},
+		{5, 3, 2, "tl", "bl"},
+		{6, 3, 2, "tr", "br"},
+		{7, 3, 2, "br", "tr"},
+		{8, 3, 2, "bl", "tl"},
+	}
+	for _, tt := range tests {
+		t.Run(string(rune('0'+tt.orientation)), func(t *testing.T) {
+			got := applyOrientation(stored, tt.orientation)
+			if b := got.Bounds(); b.Dx() != tt.w || b.Dy() != tt.h {
+				t.Fatalf("size = %dx%d, want %dx%d", b.Dx(), b.Dy(), tt.w, tt.h)
+			}
+			if c := color.NRGBAModel.Convert(corner(got, tt.red)); c != red {
+				t.Errorf("%s corner = %v, want red", tt.red, c)
+			}
+			if c := color.NRGBAModel.Convert(corner(got, tt.blue)); c != blue {
+				t.Errorf("%s corner = %v, want blue", tt.blue, c)
+			}
+		})
+	}
+}
+
+func TestDecodeOrientation(t *testing.T) {
+	jpg := encodeJPEG(t, 4, 2)
+	tests := []struct {
+		name string
+		data []byte
+		w, h int
+	}{
+		{"no exif", jpg, 4, 2},
+		{"rotated", withExif(jpg, tiffIFD(binary.BigEndian, 6)), 2, 4},
+		{"flipped", withExif(jpg, tiffIFD(binary.LittleEndian, 3)), 4, 2},
+		// EXIF is only read from JPEGs
+		{"png", encodePNG(t, 4, 2), 4, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img := Decode(bytes.NewReader(tt.data))
+			if err := img.Err(); err != nil {
+				t.Fatal(err)
+			}
+			if img.Width() != tt.w || img.Height() != tt.h {
+				t.Fatalf("size = %dx%d, want %dx%d", img.Width(), img.Height(), tt.w, tt.h)
+			}
+		})
+	}
+}
+
+// hugePNG is a valid PNG header claiming w x h, without the pixel data
+func hugePNG(t *testing.T, w, h uint32) []byte {
+	t.Helper()
+	data := encodePNG(t, 1, 1)
+	// signature (8), IHDR length and type (8), then width and height
+	binary.BigEndian.PutUint32(data[16:], w)
+	binary.BigEndian.PutUint32(data[20:], h)
+	binary.BigEndian.PutUint32(data[29:], crc32.ChecksumIEEE(data[12:29]))
+	return data
+}
+
+func TestDecodeLimits(t *testing.T) {
+	saved := configs.GConfig
+	t.Cleanup(func() { configs.GConfig = saved })
+
+	small := encodePNG(t, 10, 10)
+	huge := hugePNG(t, 100_000, 100_000)
+	limit := func(size, pixels int64) *configs.Config {
+		return &configs.Config{Upload: configs.UploadConfig{MaxImageSize: size, MaxImagePixels: pixels}}
+	}
+	tests := []struct {
+		name    string
+		cfg     *configs.Config
+		data    []byte
+		wantErr error
+	}{
+		{"defaults", nil, small, nil},
+		{"defaults, huge header", nil, huge, ErrImageTooLarge},
+		{"unset keeps defaults", limit(0, 0), huge, ErrImageTooLarge},
+		{"over max size", limit(int64(len(small))-1, 0), small, ErrImageTooLarge},
+		{"at max size", limit(int64(len(small)), 0), small, nil},
+		{"over max pixels", limit(0, 99), small, ErrImageTooLarge},
+		{"at max pixels", limit(0, 100), small, nil},
+		{"unlimited size", limit(-1, 0), small, nil},
+		{"not an image", nil, []byte("hello"), ErrUnsupportedFormat},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configs.GConfig = tt.cfg
+			err := Decode(bytes.NewReader(tt.data)).Err()
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Decode() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,170 @@
+// pkg/imaging/resize.go
+package imaging
+
+import (
+	"image"
+	"image/draw"
+	"math"
+)
+
+// Catmull-Rom cubic, a good sharp default for thumbnails
+const filterSupport = 2.0
+
+func catmullRom(x float64) float64 {
+	x = math.Abs(x)
+	switch {
+	case x < 1:
+		return (1.5*x-2.5)*x*x + 1
+	case x < 2:
+		return ((-0.5*x+2.5)*x-4)*x + 2
+	}
+	return 0
+}
+
+type contribution struct {
+	start   int
+	weights []float64
+}
+
+// contributions precomputes, for every destination pixel, the source pixels
+// and weights along one axis
+func contributions(srcSize, dstSize int) []contribution {
+	scale := float64(srcSize) / float64(dstSize)
+	filterScale := math.Max(scale, 1)
+	support := filterSupport * filterScale
+
+	out := make([]contribution, dstSize)
+	for i := range out {
+		center := (float64(i)+0.5)*scale - 0.5
+		start := int(math.Ceil(center - support))
+		end := int(math.Floor(center + support))
+
+		var weights []float64
+		sum := 0.0
+		for j := start; j <= end; j++ {
+			w := catmullRom((float64(j) - center) / filterScale)
+			weights = append(weights, w)
+			sum += w
+		}
+		if sum != 0 {
+			for k := range weights {
+				weights[k] /= sum
+			}
+		}
+		out[i] = contribution{start: start, weights: weights}
+	}
+	return out
+}
+
+func clampInt(v, lo, hi int) int {
+	if v < lo {
+		return lo
+	}
+	if v > hi {
+		return hi
+	}
+	return v
+}
+
+func clampByte(v float64) uint8 {
+	if v <= 0 {
+		return 0
+	}
+	if v >= 255 {
+		return 255
+	}
+	return uint8(v + 0.5)
+}
+
+// toNRGBA converts any image to an NRGBA with origin (0,0)
+func toNRGBA(src image.Image) *image.NRGBA {
+	if n, ok := src.(*image.NRGBA); ok && n.Rect.Min == (image.Point{}) {
+		return n
+	}
+	b := src.Bounds()
+	dst := image.NewNRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(dst, dst.Bounds(), src, b.Min, draw.Src)
+	return dst
+}
+
+// resample scales src to w x h with a separable Catmull-Rom filter.
+// Colors are weighted by alpha so transparent edges don't bleed.
+func resample(src image.Image, w, h int) *image.NRGBA {
+	in := toNRGBA(src)
+	if in.Rect.Dx() == w && in.Rect.Dy() == h {
+		return in
+	}
+	return resampleV(resampleH(in, w), h)
+}
+
+func resampleH(src *image.NRGBA, w int) *image.NRGBA {
+	srcW, srcH := src.Rect.Dx(), src.Rect.Dy()
+	if srcW == w {
+		return src
+	}
+	dst := image.NewNRGBA(image.Rect(0, 0, w, srcH))
+	contribs := contributions(srcW, w)
+
+	for y := 0; y < srcH; y++ {
+		row := src.Pix[y*src.Stride:]
+		for x, c := range contribs {
+			var r, g, b, a float64
+			for k, weight := range c.weights {
+				sx := clampInt(c.start+k, 0, srcW-1) * 4
+				pa := float64(row[sx+3]) * weight
+				r += float64(row[sx]) * pa
+				g += float64(row[sx+1]) * pa
+				b += float64(row[sx+2]) * pa
+				a += pa
+			}
+			writePixel(dst.Pix[y*dst.Stride+x*4:], r, g, b, a)
+		}
+	}
+	return dst
+}
+
+func resampleV(src *image.NRGBA, h int) *image.NRGBA {
+	srcW, srcH := src.Rect.Dx(), src.Rect.Dy()
+	if srcH == h {
+		return src
+	}
+	dst := image.NewNRGBA(image.Rect(0, 0, srcW, h))
+	contribs := contributions(srcH, h)
+
+	for y, c := range contribs {
+		for x := 0; x < srcW; x++ {
+			var r, g, b, a float64
+			for k, weight := range c.weights {
+				sy := clampInt(c.start+k, 0, srcH-1)
+				p := src.Pix[sy*src.Stride+x*4:]
+				pa := float64(p[3]) * weight
+				r += float64(p[0]) * pa
+				g += float64(p[1]) * pa
+				b += float64(p[2]) * pa
+				a += pa
+			}
+			writePixel(dst.Pix[y*dst.Stride+x*4:], r, g, b, a)
+		}
+	}
+	return dst
+}
+
+func writePixel(p []uint8, r, g, b, a float64) {
+	if a <= 0 {
+		p[0], p[1], p[2], p[3] = 0, 0, 0, 0
+		return
+	}
+	p[0] = clampByte(r / a)
+	p[1] = clampByte(g / a)
+	p[2] = clampByte(b / a)
+	p[3] = clampByte(a)
+}
+
+// crop copies the rectangle r (clipped to src) into a new image
+func crop(src image.Image, r image.Rectangle) *image.NRGBA {
+	in := toNRGBA(src)
+	r = r.Intersect(in.Rect)
+	dst := image.NewNRGBA(image.Rect(0, 0, r.Dx(), r.Dy()))
+	draw.Draw(dst, dst.Bounds(), in, r.Min, draw.Src)
+	return dst
+}
@@ -2,43 +2,156 @@
 package middleware
 
 import (
+	"crypto/subtle"
 	"net/http"
+	"strings"
+	"sync"
 
+	"github.com/aasoft24/golara/wpkg/configs"
 	"github.com/aasoft24/golara/wpkg/gola"
+	"github.com/aasoft24/golara/wpkg/session"
 )
 
-// Correct middleware signature for your router
+// XSRFCookie carries the token to JavaScript, which sends it back in the
+// X-XSRF-TOKEN header (axios and others do this automatically)
+const XSRFCookie = "XSRF-TOKEN"
+
+// defaultCSRFExcept is used when config.yaml has no csrf.except list
+var defaultCSRFExcept = []string{"/api/*", "/webhook/*", "/health/*"}
+
+var (
+	csrfMu     sync.RWMutex
+	csrfExcept []string
+)
+
+// CSRFExcept adds URIs that skip CSRF verification, on top of csrf.except
+func CSRFExcept(patterns ...string) {
+	csrfMu.Lock()
+	defer csrfMu.Unlock()
+	csrfExcept = append(csrfExcept, patterns...)
+}
+
+// CSRFExcluded reports whether path matches an excluded URI
+func CSRFExcluded(path string) bool {
+	patterns := defaultCSRFExcept
+	if cfg := configs.GConfig; cfg != nil && cfg.CSRF.Except != nil {
+		patterns = cfg.CSRF.Except
+	}
+	csrfMu.RLock()
+	patterns = append(append([]string{}, patterns...), csrfExcept...)
+	csrfMu.RUnlock()
+
+	for _, p := range patterns {
+		if prefix, ok := strings.CutSuffix(p, "*"); ok {
+			if strings.HasPrefix(path, prefix) {
+				return true
+			}
+		} else if path == p {
+			return true
+		}
+	}
+	return false
+}
+
+// CSRF verifies the session token on state-changing requests. The token is
+// read from the _token field, the X-CSRF-Token header or the X-XSRF-TOKEN
+// header (the XSRF-TOKEN cookie echoed back by JavaScript).
 func CSRF(next func(ctx *gola.Context)) func(ctx *gola.Context) {
 	return func(ctx *gola.Context) {
+		if ctx.Session != nil {
+			session.Token(ctx.Session)
+			setXSRFCookie(ctx)
+		}
+
 		// Skip safe methods
 		if ctx.Request.Method == "GET" ||
 			ctx.Request.Method == "HEAD" ||
-			ctx.Request.Method == "OPTIONS" {
+			ctx.Request.Method == "OPTIONS" ||
+			CSRFExcluded(ctx.Request.URL.Path) {
 			next(ctx)
 			return
 		}
 
-		// Check CSRF token
-		token := ctx.Request.FormValue("_token")
-		if token == "" {
-			// Also check header for AJAX requests
-			token = ctx.Request.Header.Get("X-CSRF-Token")
-		}
-
-		if token == "" || len(token) < 10 {
-			if ctx.Request.Header.Get("Content-Type") == "application/json" {
+		if !tokensMatch(ctx) {
+			if strings.HasPrefix(ctx.Request.Header.Get("Content-Type"), "application/json") {
 				ctx.JSON(http.StatusForbidden, map[string]interface{}{
 					"error": "CSRF token missing or invalid",
 				})
 				return
 			}
-			//ctx.SetFlash("error", "CSRF token missing or invalid")
 			ctx.HTML(http.StatusForbidden, "CSRF token missing or invalid")
-			//next(ctx)
-
 			return
 		}
 
 		next(ctx)
 	}
 }
+
+// XSRFToken sends the XSRF-TOKEN cookie on every response, so JavaScript
+// has the token before its first state-changing request. Register it
+// globally after the session middleware.
+func XSRFToken(next func(ctx *gola.Context)) func(ctx *gola.Context) {
+	return func(ctx *gola.Context) {
+		if ctx.Session != nil {
+			session.Token(ctx.Session)
+			setXSRFCookie(ctx)
+		}
+		next(ctx)
+	}
+}
+
+// tokensMatch compares the request token with the session one in constant time
+func tokensMatch(ctx *gola.Context) bool {
+	if ctx.Session == nil {
+		return false
+	}
+	expected, _ := ctx.Session.Get(session.TokenKey).(string)
+	if expected == "" {
+		return false
+	}
+	_ = ctx.ParseForm()
+	token := ctx.Request.PostFormValue("_token")
+	if token == "" {
+		token = ctx.Request.Header.Get("X-CSRF-Token")
+	}
+	if token == "" {
+		token = ctx.Request.Header.Get("X-XSRF-TOKEN")
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(expected)) == 1
+}
+
+var xsrfQueuedKey = gola.NewKey[bool]("csrf.xsrf_queued")
+
+// setXSRFCookie sends the token JavaScript-readable just before the headers,
+// so a token rotated by the handler (login, logout) is the one sent. It runs
+// once per request, whether XSRFToken, CSRF or both call it.
+func setXSRFCookie(ctx *gola.Context) {
+	if queued, _ := gola.GetValue(ctx, xsrfQueuedKey); queued {
+		return
+	}
+	gola.SetValue(ctx, xsrfQueuedKey, true)
+	opts := session.DefaultOptions()
+	if ctx.SessionManager != nil {
+		opts = ctx.SessionManager.Options()
+	}
+	sess, w, secure := ctx.Session, ctx.Writer, ctx.Request.TLS != nil
+	current, _ := ctx.Request.Cookie(XSRFCookie)
+	w.Before(func() {
+		token, _ := sess.Get(session.TokenKey).(string)
+		if token == "" || (current != nil && current.Value == token) {
+			return
+		}
+		cookie := &http.Cookie{
+			Name:     XSRFCookie,
+			Value:    token,
+			Path:     opts.Path,
+			Domain:   opts.Domain,
+			Secure:   opts.Secure || secure,
+			SameSite: opts.SameSite,
+		}
+		if !opts.ExpireOnClose {
+			cookie.MaxAge = int(opts.Lifetime.Seconds())
+		}
+		http.SetCookie(w, cookie)
+	})
+}
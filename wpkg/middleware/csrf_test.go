@@ -0,0 +1,146 @@
+// pkg/middleware/csrf_test.go
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/aasoft24/golara/wpkg/gola"
+	"github.com/aasoft24/golara/wpkg/session"
+)
+
+// csrfRequest runs mw for r with a fresh session holding token (none if
+// empty) and reports whether the handler ran
+func csrfRequest(t *testing.T, mw func(next func(ctx *gola.Context)) func(ctx *gola.Context), r *http.Request, token string, handler func(ctx *gola.Context)) (*httptest.ResponseRecorder, bool) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	c := gola.NewContext(w, r)
+	if token != "" {
+		m := session.NewManager(session.NewMemoryStore(), "go_session")
+		s, err := m.Start(c.Writer, r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s.Set(session.TokenKey, token)
+		c.Session, c.SessionManager = s, m
+	}
+	called := false
+	mw(func(ctx *gola.Context) {
+		called = true
+		if handler != nil {
+			handler(ctx)
+		}
+	})(c)
+	if !c.Writer.Written() {
+		c.Writer.WriteHeader(http.StatusOK)
+	}
+	return w, called
+}
+
+func TestCSRF(t *testing.T) {
+	const token = "session-token"
+	form := func(v url.Values) *http.Request {
+		r := httptest.NewRequest("POST", "/posts", strings.NewReader(v.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		return r
+	}
+	withHeader := func(r *http.Request, key, value string) *http.Request {
+		r.Header.Set(key, value)
+		return r
+	}
+
+	tests := []struct {
+		name    string
+		r       *http.Request
+		token   string // session token, "" for no session
+		allowed bool
+	}{
+		{"get is safe", httptest.NewRequest("GET", "/posts", nil), token, true},
+		{"options is safe", httptest.NewRequest("OPTIONS", "/posts", nil), token, true},
+		{"form token", form(url.Values{"_token": {token}}), token, true},
+		{"csrf header", withHeader(httptest.NewRequest("DELETE", "/posts/1", nil), "X-CSRF-Token", token), token, true},
+		{"xsrf header", withHeader(httptest.NewRequest("PUT", "/posts/1", nil), "X-XSRF-TOKEN", token), token, true},
+		{"missing token", form(url.Values{"title": {"x"}}), token, false},
+		{"wrong token", form(url.Values{"_token": {"other"}}), token, false},
+		{"token prefix", form(url.Values{"_token": {token[:5]}}), token, false},
+		{"no session", form(url.Values{"_token": {token}}), "", false},
+		{"excluded path", httptest.NewRequest("POST", "/api/posts", nil), token, true},
+		{"excluded prefix only", httptest.NewRequest("POST", "/apiary", nil), token, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, called := csrfRequest(t, CSRF, tt.r, tt.token, nil)
+			if called != tt.allowed {
+				t.Fatalf("handler ran = %v, want %v (status %d)", called, tt.allowed, w.Code)
+			}
+			if !tt.allowed && w.Code != http.StatusForbidden {
+				t.Fatalf("status = %d, want 403", w.Code)
+			}
+		})
+	}
+}
+
+func TestCSRFJSONRejection(t *testing.T) {
+	r := httptest.NewRequest("POST", "/posts", strings.NewReader(`{}`))
+	r.Header.Set("Content-Type", "application/json")
+	w, _ := csrfRequest(t, CSRF, r, "session-token", nil)
+	if w.Code != http.StatusForbidden || !strings.Contains(w.Body.String(), `"error"`) {
+		t.Fatalf("response = %d %q, want a 403 JSON error", w.Code, w.Body.String())
+	}
+}
+
+func TestXSRFCookie(t *testing.T) {
+	const token = "session-token"
+	xsrf := func(w *httptest.ResponseRecorder) []*http.Cookie {
+		var out []*http.Cookie
+		for _, c := range w.Result().Cookies() {
+			if c.Name == XSRFCookie {
+				out = append(out, c)
+			}
+		}
+		return out
+	}
+	tests := []struct {
+		name    string
+		mw      func(next func(ctx *gola.Context)) func(ctx *gola.Context)
+		cookie  string                  // XSRF-TOKEN the browser already has
+		handler func(ctx *gola.Context) // runs before the headers are sent
+		want    string                  // cookie value sent, "" for none
+	}{
+		{"first visit", XSRFToken, "", nil, token},
+		{"csrf middleware sends it too", CSRF, "", nil, token},
+		{"both middleware send it once", func(next func(ctx *gola.Context)) func(ctx *gola.Context) {
+			return XSRFToken(CSRF(next))
+		}, "", nil, token},
+		{"browser has it", XSRFToken, token, nil, ""},
+		{"stale cookie", XSRFToken, "old", nil, token},
+		{"rotated by the handler", XSRFToken, token, func(ctx *gola.Context) {
+			ctx.Session.Set(session.TokenKey, "rotated")
+		}, "rotated"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/", nil)
+			if tt.cookie != "" {
+				r.AddCookie(&http.Cookie{Name: XSRFCookie, Value: tt.cookie})
+			}
+			w, _ := csrfRequest(t, tt.mw, r, token, tt.handler)
+			got := xsrf(w)
+			if tt.want == "" {
+				if len(got) != 0 {
+					t.Fatalf("XSRF cookies = %v, want none", got)
+				}
+				return
+			}
+			if len(got) != 1 || got[0].Value != tt.want {
+				t.Fatalf("XSRF cookies = %v, want one with %q", got, tt.want)
+			}
+			if got[0].HttpOnly {
+				t.Fatal("the XSRF cookie must be readable by JavaScript")
+			}
+		})
+	}
+}
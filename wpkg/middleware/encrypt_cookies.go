@@ -0,0 +1,98 @@
+// pkg/middleware/encrypt_cookies.go
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/aasoft24/golara/wpkg/configs"
+	"github.com/aasoft24/golara/wpkg/encryption"
+	"github.com/aasoft24/golara/wpkg/gola"
+)
+
+// EncryptCookies decrypts incoming cookies and encrypts outgoing ones with
+// the app key. Cookies named in except, the session cookie (encrypted or
+// random already) and XSRF-TOKEN (read by JavaScript) are left as they are.
+// Register it first, so the cookies of every later middleware pass through it;
+// cookies that fail to decrypt are dropped from the request.
+func EncryptCookies(except ...string) func(next func(ctx *gola.Context)) func(ctx *gola.Context) {
+	skip := map[string]bool{XSRFCookie: true}
+	for _, name := range except {
+		skip[name] = true
+	}
+
+	return func(next func(ctx *gola.Context)) func(ctx *gola.Context) {
+		return func(ctx *gola.Context) {
+			enc, err := encryption.Default()
+			if err != nil {
+				ctx.Error(http.StatusInternalServerError, err.Error())
+				return
+			}
+			excluded := func(name string) bool {
+				return skip[name] || name == sessionCookieName()
+			}
+
+			ctx.Request = decryptCookies(ctx.Request, enc, excluded)
+
+			// registered first, so it runs after the hooks of later middleware
+			w := ctx.Writer
+			w.Before(func() {
+				encryptCookies(w.Header(), enc, excluded)
+			})
+
+			next(ctx)
+		}
+	}
+}
+
+// sessionCookieName is the cookie of the session middleware
+func sessionCookieName() string {
+	if cfg := configs.GConfig; cfg != nil && cfg.Session.Cookie != "" {
+		return cfg.Session.Cookie
+	}
+	return "go_session"
+}
+
+// decryptCookies returns r with the Cookie header replaced by the decrypted values
+func decryptCookies(r *http.Request, enc *encryption.Encrypter, excluded func(string) bool) *http.Request {
+	cookies := r.Cookies()
+	if len(cookies) == 0 {
+		return r
+	}
+	r = r.Clone(r.Context())
+	r.Header.Del("Cookie")
+	for _, cookie := range cookies {
+		if !excluded(cookie.Name) {
+			plain, err := enc.Open(cookie.Value, []byte(cookie.Name))
+			if err != nil {
+				continue
+			}
+			cookie.Value = string(plain)
+		}
+		r.AddCookie(cookie)
+	}
+	return r
+}
+
+// encryptCookies rewrites the Set-Cookie headers; deletions are kept as is
+func encryptCookies(h http.Header, enc *encryption.Encrypter, excluded func(string) bool) {
+	lines := h.Values("Set-Cookie")
+	if len(lines) == 0 {
+		return
+	}
+	out := make([]string, 0, len(lines))
+	for _, line := range lines {
+		cookie, err := http.ParseSetCookie(line)
+		if err != nil || excluded(cookie.Name) || cookie.Value == "" || cookie.MaxAge < 0 {
+			out = append(out, line)
+			continue
+		}
+		value, err := enc.Seal([]byte(cookie.Value), []byte(cookie.Name))
+		if err != nil {
+			out = append(out, line)
+			continue
+		}
+		cookie.Value = value
+		out = append(out, cookie.String())
+	}
+	h["Set-Cookie"] = out
+}
@@ -0,0 +1,93 @@
+// pkg/middleware/encrypt_cookies_test.go
+package middleware
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aasoft24/golara/wpkg/encryption"
+	"github.com/aasoft24/golara/wpkg/gola"
+)
+
+func TestEncryptCookiesRotation(t *testing.T) {
+	oldKey := bytes.Repeat([]byte("o"), encryption.KeySize)
+	newKey := bytes.Repeat([]byte("n"), encryption.KeySize)
+	otherKey := bytes.Repeat([]byte("x"), encryption.KeySize)
+	before, _ := encryption.New(oldKey)
+	rotated, _ := encryption.New(newKey, oldKey)
+	other, _ := encryption.New(otherKey)
+	encryption.SetDefault(rotated)
+	t.Cleanup(func() { encryption.SetDefault(nil) })
+
+	seal := func(e *encryption.Encrypter, name, value string) string {
+		sealed, err := e.Seal([]byte(value), []byte(name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return sealed
+	}
+
+	tests := []struct {
+		name   string
+		cookie *http.Cookie
+		want   string // value the handler reads, "" when dropped
+	}{
+		{"sealed with the previous key", &http.Cookie{Name: "prefs", Value: seal(before, "prefs", "dark")}, "dark"},
+		{"sealed with the current key", &http.Cookie{Name: "prefs", Value: seal(rotated, "prefs", "dark")}, "dark"},
+		{"sealed with an unknown key", &http.Cookie{Name: "prefs", Value: seal(other, "prefs", "dark")}, ""},
+		{"sealed for another cookie", &http.Cookie{Name: "prefs", Value: seal(rotated, "cart", "dark")}, ""},
+		{"plain value", &http.Cookie{Name: "prefs", Value: "dark"}, ""},
+		{"xsrf cookie is not encrypted", &http.Cookie{Name: XSRFCookie, Value: "token"}, "token"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/", nil)
+			r.AddCookie(tt.cookie)
+			c := gola.NewContext(httptest.NewRecorder(), r)
+			got := "missing"
+			EncryptCookies()(func(ctx *gola.Context) {
+				got, _ = ctx.GetCookie(tt.cookie.Name)
+			})(c)
+			if got != tt.want {
+				t.Fatalf("handler read %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncryptCookiesOutgoing(t *testing.T) {
+	oldKey := bytes.Repeat([]byte("o"), encryption.KeySize)
+	rotated, _ := encryption.New(bytes.Repeat([]byte("n"), encryption.KeySize), oldKey)
+	before, _ := encryption.New(oldKey)
+	encryption.SetDefault(rotated)
+	t.Cleanup(func() { encryption.SetDefault(nil) })
+
+	w := httptest.NewRecorder()
+	c := gola.NewContext(w, httptest.NewRequest("GET", "/", nil))
+	EncryptCookies()(func(ctx *gola.Context) {
+		ctx.QueueCookie(&http.Cookie{Name: "prefs", Value: "dark"})
+		ctx.QueueCookie(&http.Cookie{Name: "old", MaxAge: -1})
+		ctx.QueueCookie(&http.Cookie{Name: XSRFCookie, Value: "token"})
+		ctx.Writer.WriteHeader(http.StatusOK)
+	})(c)
+
+	sent := map[string]*http.Cookie{}
+	for _, cookie := range w.Result().Cookies() {
+		sent[cookie.Name] = cookie
+	}
+	plain, err := rotated.Open(sent["prefs"].Value, []byte("prefs"))
+	if err != nil || string(plain) != "dark" {
+		t.Fatalf("prefs = %q, %v; want it sealed with the current key", plain, err)
+	}
+	if _, err := before.Open(sent["prefs"].Value, []byte("prefs")); err == nil {
+		t.Fatal("prefs was sealed with the previous key")
+	}
+	if sent["old"] == nil || sent["old"].MaxAge >= 0 {
+		t.Fatalf("deletion = %v, want it sent as is", sent["old"])
+	}
+	if sent[XSRFCookie] == nil || sent[XSRFCookie].Value != "token" {
+		t.Fatalf("%s = %v, want it unencrypted", XSRFCookie, sent[XSRFCookie])
+	}
+}
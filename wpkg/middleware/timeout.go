@@ -0,0 +1,236 @@
+// pkg/middleware/timeout.go
+package middleware
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aasoft24/golara/wpkg/gola"
+)
+
+// Timeout gives every request a deadline. Handlers see it through ctx (and any
+// ORM / httpf call made WithContext(ctx)), so queries and outgoing requests are
+// cancelled when it passes. If the handler hasn't finished by then the client
+// gets 503 (or the given status, e.g. http.StatusGatewayTimeout) right away:
+// the complete response, with Content-Length, is flushed at the deadline.
+//
+// The response is buffered until the handler returns, so streaming and
+// hijacking are not available behind this middleware. The middleware still
+// waits for the handler to return before it returns itself, because the
+// pooled Context is released afterwards; until then the connection can't
+// serve the client's next request.
+//
+//	router.Use(middleware.Timeout(10 * time.Second))
+func Timeout(d time.Duration, status ...int) func(next func(ctx *gola.Context)) func(ctx *gola.Context) {
+	code := http.StatusServiceUnavailable
+	if len(status) > 0 {
+		code = status[0]
+	}
+
+	return func(next func(ctx *gola.Context)) func(ctx *gola.Context) {
+		return func(ctx *gola.Context) {
+			req := ctx.Request
+			tctx, cancel := context.WithTimeout(req.Context(), d)
+			defer cancel()
+
+			orig := ctx.Writer
+			tw := newTimeoutWriter(orig)
+			ctx.WithContext(tctx)
+			ctx.Writer = tw
+
+			done := make(chan struct{})
+			var panicVal interface{}
+			go func() {
+				defer func() {
+					if p := recover(); p != nil {
+						panicVal = p
+					}
+					tw.finish(tctx.Err() == nil)
+					close(done)
+				}()
+				next(ctx)
+			}()
+
+			select {
+			case <-done:
+			case <-tctx.Done():
+			}
+			// a client that went away gets nothing, only the deadline is answered
+			if errors.Is(tctx.Err(), context.DeadlineExceeded) && tw.timeout() {
+				writeTimeout(orig, req, code)
+			}
+			// the handler still uses ctx, which goes back to the pool after us
+			<-done
+
+			ctx.Writer = orig
+			ctx.Request = req
+			if panicVal != nil {
+				panic(panicVal)
+			}
+			tw.flushTo(orig)
+		}
+	}
+}
+
+// writeTimeout sends the whole timeout response at once, so the client has
+// it even though the handler may keep running
+func writeTimeout(w gola.ResponseWriter, r *http.Request, code int) {
+	body := "Request timed out"
+	contentType := "text/plain; charset=utf-8"
+	if strings.Contains(r.Header.Get("Accept"), "application/json") {
+		body = `{"error":"Request timed out"}`
+		contentType = "application/json; charset=utf-8"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	w.WriteHeader(code)
+	io.WriteString(w, body)
+	w.Flush()
+}
+
+// timeoutWriter buffers the handler's response so it can be dropped on timeout
+type timeoutWriter struct {
+	mu          sync.Mutex
+	header      http.Header
+	buf         bytes.Buffer
+	status      int
+	wroteHeader bool
+	timedOut    bool
+	finished    bool
+	beforeFuncs []func()
+}
+
+func newTimeoutWriter(orig gola.ResponseWriter) *timeoutWriter {
+	return &timeoutWriter{header: orig.Header().Clone(), status: http.StatusOK}
+}
+
+// timeout drops the buffered response, later writes fail with http.ErrHandlerTimeout.
+// It returns false when the handler finished before the deadline.
+func (tw *timeoutWriter) timeout() bool {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.finished {
+		return false
+	}
+	tw.timedOut = true
+	return true
+}
+
+func (tw *timeoutWriter) finish(inTime bool) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	tw.finished = inTime
+}
+
+// flushTo copies the buffered response, running Before hooks on the real writer
+func (tw *timeoutWriter) flushTo(w gola.ResponseWriter) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut {
+		return
+	}
+	dst := w.Header()
+	for k := range dst {
+		delete(dst, k)
+	}
+	for k, v := range tw.header {
+		dst[k] = v
+	}
+	for _, fn := range tw.beforeFuncs {
+		w.Before(fn)
+	}
+	if !tw.wroteHeader {
+		return
+	}
+	w.WriteHeader(tw.status)
+	w.Write(tw.buf.Bytes())
+}
+
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.header
+}
+
+func (tw *timeoutWriter) WriteHeader(code int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut || tw.wroteHeader {
+		return
+	}
+	tw.wroteHeader = true
+	tw.status = code
+}
+
+func (tw *timeoutWriter) Write(b []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	tw.wroteHeader = true
+	return tw.buf.Write(b)
+}
+
+func (tw *timeoutWriter) ReadFrom(r io.Reader) (int64, error) {
+	return io.Copy(struct{ io.Writer }{tw}, r)
+}
+
+func (tw *timeoutWriter) Status() int {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	return tw.status
+}
+
+func (tw *timeoutWriter) Size() int {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if !tw.wroteHeader {
+		return -1
+	}
+	return tw.buf.Len()
+}
+
+func (tw *timeoutWriter) Written() bool {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	return tw.wroteHeader
+}
+
+// Before hooks are handed to the real writer once the handler finishes
+func (tw *timeoutWriter) Before(fn func()) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	tw.beforeFuncs = append(tw.beforeFuncs, fn)
+}
+
+// Flush is a no-op, the response is sent when the handler returns
+func (tw *timeoutWriter) Flush() {}
+
+func (tw *timeoutWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return nil, nil, errors.New("middleware: hijacking is not supported behind Timeout")
+}
+
+func (tw *timeoutWriter) Push(string, *http.PushOptions) error {
+	return http.ErrNotSupported
+}
+
+// Unwrap hides the real writer so http.ResponseController can't bypass the buffer
+func (tw *timeoutWriter) Unwrap() http.ResponseWriter {
+	return struct{ http.ResponseWriter }{bufferedWriter{tw}}
+}
+
+type bufferedWriter struct {
+	tw *timeoutWriter
+}
+
+func (b bufferedWriter) Header() http.Header         { return b.tw.Header() }
+func (b bufferedWriter) Write(p []byte) (int, error) { return b.tw.Write(p) }
+func (b bufferedWriter) WriteHeader(code int)        { b.tw.WriteHeader(code) }
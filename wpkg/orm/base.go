@@ -1,6 +1,8 @@
 package orm
 
 import (
+	"context"
+
 	"github.com/aasoft24/golara/wpkg/database"
 	"gorm.io/gorm"
 )
@@ -13,6 +15,12 @@ func NewQuery[T any](model T) *Query[T] {
 	return &Query[T]{db: database.DB.Model(&model)}
 }
 
+// WithContext runs the query with ctx, so it is cancelled with the request
+func (q *Query[T]) WithContext(ctx context.Context) *Query[T] {
+	q.db = q.db.WithContext(ctx)
+	return q
+}
+
 func (q *Query[T]) Where(query interface{}, args ...interface{}) *Query[T] {
 	q.db = q.db.Where(query, args...)
 	return q
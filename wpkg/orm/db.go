@@ -1,6 +1,8 @@
 package orm
 
 import (
+	"context"
+
 	"github.com/aasoft24/golara/wpkg/database"
 	"gorm.io/gorm"
 )
@@ -13,6 +15,12 @@ func DB() *DBQuery {
 	return &DBQuery{tx: database.DB}
 }
 
+// WithContext runs the query with ctx, so it is cancelled with the request
+func (q *DBQuery) WithContext(ctx context.Context) *DBQuery {
+	q.tx = q.tx.WithContext(ctx)
+	return q
+}
+
 func (q *DBQuery) Table(name string) *DBQuery {
 	q.tx = q.tx.Table(name)
 	return q
@@ -0,0 +1,62 @@
+// pkg/rbac/middleware.go
+package rbac
+
+import (
+	"strings"
+
+	"github.com/aasoft24/golara/wpkg/auth"
+	"github.com/aasoft24/golara/wpkg/authz"
+	"github.com/aasoft24/golara/wpkg/gola"
+)
+
+var teamKey = gola.NewKey[uint]("rbac.team")
+
+// SetTeam scopes the request's role and permission middleware to team,
+// e.g. from a team middleware that reads the :team route parameter
+func SetTeam(c *gola.Context, team uint) {
+	gola.SetValue(c, teamKey, team)
+}
+
+// Team returns the request's team, 0 when only global roles apply
+func Team(c *gola.Context) uint {
+	team, _ := gola.GetValue(c, teamKey)
+	return team
+}
+
+// RoleMiddleware lets users through that have one of roles in the request
+// team (see SetTeam); registered as "role", e.g. "role:admin|manager"
+func RoleMiddleware(roles ...string) func(next func(ctx *gola.Context)) func(ctx *gola.Context) {
+	return require(strings.Join(roles, "|"), Default.HasRole)
+}
+
+// PermissionMiddleware lets users through that have one of permissions;
+// registered as "permission", e.g. "permission:orders.refund"
+func PermissionMiddleware(permissions ...string) func(next func(ctx *gola.Context)) func(ctx *gola.Context) {
+	return require(strings.Join(permissions, "|"), Default.HasPermissionTo)
+}
+
+func require(names string, has func(auth.Authenticatable, string, ...uint) bool) func(next func(ctx *gola.Context)) func(ctx *gola.Context) {
+	return func(next func(ctx *gola.Context)) func(ctx *gola.Context) {
+		return func(ctx *gola.Context) {
+			user := auth.User(ctx)
+			if user == nil || !has(user, names, Team(ctx)) {
+				authz.Forbidden(ctx, nil)
+				return
+			}
+			next(ctx)
+		}
+	}
+}
+
+// RegisterGate makes gate checks pass for users holding a global
+// permission of the same name, so authz.Allows(user, "orders.refund") and
+// the can middleware see database permissions. Denials fall through to the
+// gate's abilities and policies.
+func RegisterGate(gate *authz.Gate) {
+	gate.Before(func(user auth.Authenticatable, ability string, args ...interface{}) (bool, bool) {
+		if Default.HasPermissionTo(user, ability) {
+			return true, true
+		}
+		return false, false
+	})
+}
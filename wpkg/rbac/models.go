@@ -0,0 +1,63 @@
+// pkg/rbac/models.go
+package rbac
+
+import "time"
+
+// Role is a row of the roles table, e.g. admin, manager, staff
+type Role struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	Name        string    `gorm:"size:100;uniqueIndex" json:"name"`
+	Description string    `gorm:"size:255" json:"description"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+func (Role) TableName() string {
+	return "roles"
+}
+
+// Permission is a row of the permissions table, e.g. orders.refund
+type Permission struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	Name        string    `gorm:"size:100;uniqueIndex" json:"name"`
+	Description string    `gorm:"size:255" json:"description"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+func (Permission) TableName() string {
+	return "permissions"
+}
+
+// RolePermission grants a permission to everyone with the role
+type RolePermission struct {
+	RoleID       uint `gorm:"primaryKey"`
+	PermissionID uint `gorm:"primaryKey;index"`
+}
+
+func (RolePermission) TableName() string {
+	return "role_has_permissions"
+}
+
+// UserRole assigns a role to a user, in one team or globally (TeamID 0)
+type UserRole struct {
+	UserID uint `gorm:"primaryKey"`
+	RoleID uint `gorm:"primaryKey;index"`
+	TeamID uint `gorm:"primaryKey"`
+}
+
+func (UserRole) TableName() string {
+	return "user_has_roles"
+}
+
+// UserPermission grants a permission to a user directly, in one team or
+// globally (TeamID 0)
+type UserPermission struct {
+	UserID       uint `gorm:"primaryKey"`
+	PermissionID uint `gorm:"primaryKey;index"`
+	TeamID       uint `gorm:"primaryKey"`
+}
+
+func (UserPermission) TableName() string {
+	return "user_has_permissions"
+}
@@ -0,0 +1,460 @@
+// pkg/rbac/rbac.go
+package rbac
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aasoft24/golara/wpkg/auth"
+	"github.com/aasoft24/golara/wpkg/cache"
+	"github.com/aasoft24/golara/wpkg/database"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrRoleNotFound = errors.New("rbac: role not found")
+
+// RBAC stores roles and permissions in the database and caches what each
+// user has per team. Every change flushes the cache.
+type RBAC struct {
+	db    *gorm.DB
+	cache cache.Cache
+	// TTL is how long a user's roles and permissions stay cached
+	TTL time.Duration
+}
+
+// New uses db, or database.DB when nil, and c, or a memory cache when nil.
+// With several app instances c must be shared, or changes show up late.
+func New(db *gorm.DB, c cache.Cache) *RBAC {
+	if c == nil {
+		c = cache.NewMemoryCache()
+	}
+	return &RBAC{db: db, cache: c, TTL: 24 * time.Hour}
+}
+
+// Default is used by the package functions and the middleware
+var Default = New(nil, nil)
+
+func (r *RBAC) conn() *gorm.DB {
+	if r.db != nil {
+		return r.db
+	}
+	return database.DB
+}
+
+// Migrate creates or updates the roles, permissions and pivot tables
+func (r *RBAC) Migrate() error {
+	return r.conn().AutoMigrate(&Role{}, &Permission{}, &RolePermission{}, &UserRole{}, &UserPermission{})
+}
+
+// legacyRole is a row of a legacy role column, e.g. users.role
+type legacyRole struct {
+	ID   uint
+	Role string
+}
+
+func (r *RBAC) legacyRoles(db *gorm.DB, table, column string) ([]legacyRole, error) {
+	var rows []legacyRole
+	err := db.Table(table).
+		Select("id, " + column + " AS role").
+		Where(column + " IS NOT NULL AND " + column + " <> ''").
+		Scan(&rows).Error
+	return rows, err
+}
+
+// CopyRoleColumn copies a legacy role column, e.g. users.role, into global
+// role assignments and returns how many rows had a role. Roles that don't
+// exist yet are created. The column is kept; check the assignments, then
+// call DropRoleColumn. Running it again copies nothing twice.
+func (r *RBAC) CopyRoleColumn(table, column string) (int, error) {
+	db := r.conn()
+	if !db.Migrator().HasColumn(table, column) {
+		return 0, nil
+	}
+	copied := 0
+	err := db.Transaction(func(tx *gorm.DB) error {
+		rows, err := r.legacyRoles(tx, table, column)
+		if err != nil {
+			return err
+		}
+		roles := make(map[string]uint)
+		for _, row := range rows {
+			name := strings.TrimSpace(row.Role)
+			if name == "" {
+				continue
+			}
+			id, ok := roles[name]
+			if !ok {
+				var role Role
+				if err := tx.Where(Role{Name: name}).FirstOrCreate(&role).Error; err != nil {
+					return err
+				}
+				id, roles[name] = role.ID, role.ID
+			}
+			err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+				Create(&UserRole{UserID: row.ID, RoleID: id}).Error
+			if err != nil {
+				return err
+			}
+			copied++
+		}
+		return nil
+	})
+	r.Flush()
+	return copied, err
+}
+
+// DropRoleColumn drops a legacy role column once CopyRoleColumn has run. It
+// refuses while a row's role has no matching global assignment, so no role
+// is lost. The drop can't be undone; back up the table first.
+func (r *RBAC) DropRoleColumn(table, column string) error {
+	db := r.conn()
+	if !db.Migrator().HasColumn(table, column) {
+		return nil
+	}
+	rows, err := r.legacyRoles(db, table, column)
+	if err != nil {
+		return err
+	}
+	for _, row := range rows {
+		name := strings.TrimSpace(row.Role)
+		if name == "" {
+			continue
+		}
+		var n int64
+		err := db.Model(&UserRole{}).
+			Joins("JOIN roles ON roles.id = user_has_roles.role_id").
+			Where("user_has_roles.user_id = ? AND user_has_roles.team_id = 0 AND roles.name = ?", row.ID, name).
+			Count(&n).Error
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return fmt.Errorf("rbac: %s %d has role %q in %s.%s but no assignment, run CopyRoleColumn first", table, row.ID, name, table, column)
+		}
+	}
+	// raw, the sqlite migrator can only drop columns of a model
+	return db.Exec("ALTER TABLE ? DROP COLUMN ?", clause.Table{Name: table}, clause.Column{Name: column}).Error
+}
+
+func teamOf(team []uint) uint {
+	if len(team) > 0 {
+		return team[0]
+	}
+	return 0
+}
+
+// ==== Roles and permissions ==== //
+
+// CreateRole creates role if missing and grants it permissions
+func (r *RBAC) CreateRole(name string, permissions ...string) (*Role, error) {
+	var role Role
+	if err := r.conn().Where(Role{Name: name}).FirstOrCreate(&role).Error; err != nil {
+		return nil, err
+	}
+	if len(permissions) > 0 {
+		if err := r.GivePermissionToRole(name, permissions...); err != nil {
+			return nil, err
+		}
+	}
+	r.Flush()
+	return &role, nil
+}
+
+// DeleteRole deletes role and takes it from every user
+func (r *RBAC) DeleteRole(name string) error {
+	defer r.Flush()
+	return r.conn().Transaction(func(tx *gorm.DB) error {
+		var role Role
+		if err := tx.Where("name = ?", name).Take(&role).Error; err != nil {
+			return notFound(err)
+		}
+		if err := tx.Where("role_id = ?", role.ID).Delete(&RolePermission{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("role_id = ?", role.ID).Delete(&UserRole{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&role).Error
+	})
+}
+
+func notFound(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrRoleNotFound
+	}
+	return err
+}
+
+// CreatePermission creates permission if missing
+func (r *RBAC) CreatePermission(name string) (*Permission, error) {
+	var perm Permission
+	err := r.conn().Where(Permission{Name: name}).FirstOrCreate(&perm).Error
+	return &perm, err
+}
+
+// DeletePermission deletes permission and takes it from roles and users
+func (r *RBAC) DeletePermission(name string) error {
+	defer r.Flush()
+	return r.conn().Transaction(func(tx *gorm.DB) error {
+		var perm Permission
+		if err := tx.Where("name = ?", name).Take(&perm).Error; errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if err := tx.Where("permission_id = ?", perm.ID).Delete(&RolePermission{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("permission_id = ?", perm.ID).Delete(&UserPermission{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&perm).Error
+	})
+}
+
+// GivePermissionToRole grants permissions, created when missing, to role
+func (r *RBAC) GivePermissionToRole(role string, permissions ...string) error {
+	defer r.Flush()
+	roleID, err := r.roleID(role)
+	if err != nil {
+		return err
+	}
+	for _, name := range permissions {
+		perm, err := r.CreatePermission(name)
+		if err != nil {
+			return err
+		}
+		err = r.conn().Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&RolePermission{RoleID: roleID, PermissionID: perm.ID}).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RevokePermissionFromRole takes permissions from role
+func (r *RBAC) RevokePermissionFromRole(role string, permissions ...string) error {
+	defer r.Flush()
+	roleID, err := r.roleID(role)
+	if err != nil {
+		return err
+	}
+	return r.conn().Where("role_id = ? AND permission_id IN (?)", roleID,
+		r.conn().Model(&Permission{}).Select("id").Where("name IN ?", permissions)).
+		Delete(&RolePermission{}).Error
+}
+
+func (r *RBAC) roleID(name string) (uint, error) {
+	var role Role
+	if err := r.conn().Select("id").Where("name = ?", name).Take(&role).Error; err != nil {
+		return 0, notFound(err)
+	}
+	return role.ID, nil
+}
+
+// ==== Users ==== //
+
+// AssignRole gives user role, in team when given, otherwise globally
+func (r *RBAC) AssignRole(user auth.Authenticatable, role string, team ...uint) error {
+	defer r.Flush()
+	roleID, err := r.roleID(role)
+	if err != nil {
+		return err
+	}
+	return r.conn().Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&UserRole{UserID: user.GetAuthID(), RoleID: roleID, TeamID: teamOf(team)}).Error
+}
+
+// RemoveRole takes role from user, in team when given
+func (r *RBAC) RemoveRole(user auth.Authenticatable, role string, team ...uint) error {
+	defer r.Flush()
+	roleID, err := r.roleID(role)
+	if err != nil {
+		return err
+	}
+	return r.conn().Where("user_id = ? AND role_id = ? AND team_id = ?", user.GetAuthID(), roleID, teamOf(team)).
+		Delete(&UserRole{}).Error
+}
+
+// SyncRoles makes roles the user's only roles in team (0 for global ones)
+func (r *RBAC) SyncRoles(user auth.Authenticatable, team uint, roles ...string) error {
+	defer r.Flush()
+	return r.conn().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ? AND team_id = ?", user.GetAuthID(), team).Delete(&UserRole{}).Error; err != nil {
+			return err
+		}
+		for _, role := range roles {
+			var found Role
+			if err := tx.Select("id").Where("name = ?", role).Take(&found).Error; err != nil {
+				return notFound(err)
+			}
+			if err := tx.Create(&UserRole{UserID: user.GetAuthID(), RoleID: found.ID, TeamID: team}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// GivePermissionTo grants user a permission directly, in team when given
+func (r *RBAC) GivePermissionTo(user auth.Authenticatable, permission string, team ...uint) error {
+	defer r.Flush()
+	perm, err := r.CreatePermission(permission)
+	if err != nil {
+		return err
+	}
+	return r.conn().Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&UserPermission{UserID: user.GetAuthID(), PermissionID: perm.ID, TeamID: teamOf(team)}).Error
+}
+
+// RevokePermissionTo takes a direct permission from user; permissions of
+// the user's roles stay
+func (r *RBAC) RevokePermissionTo(user auth.Authenticatable, permission string, team ...uint) error {
+	defer r.Flush()
+	return r.conn().Where("user_id = ? AND team_id = ? AND permission_id IN (?)", user.GetAuthID(), teamOf(team),
+		r.conn().Model(&Permission{}).Select("id").Where("name = ?", permission)).
+		Delete(&UserPermission{}).Error
+}
+
+// HasRole reports whether user has role in team, or globally. "a|b"
+// matches either role. Lookup errors count as no.
+func (r *RBAC) HasRole(user auth.Authenticatable, role string, team ...uint) bool {
+	g, err := r.grants(user, teamOf(team))
+	if err != nil {
+		return false
+	}
+	for _, name := range strings.Split(role, "|") {
+		if slices.Contains(g.Roles, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// HasPermissionTo reports whether user has permission in team, or
+// globally, directly or through a role. "a|b" matches either.
+func (r *RBAC) HasPermissionTo(user auth.Authenticatable, permission string, team ...uint) bool {
+	g, err := r.grants(user, teamOf(team))
+	if err != nil {
+		return false
+	}
+	for _, name := range strings.Split(permission, "|") {
+		if slices.Contains(g.Permissions, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Roles lists user's role names in team, global roles included
+func (r *RBAC) Roles(user auth.Authenticatable, team ...uint) ([]string, error) {
+	g, err := r.grants(user, teamOf(team))
+	if err != nil {
+		return nil, err
+	}
+	return g.Roles, nil
+}
+
+// Permissions lists user's permission names in team, global ones included
+func (r *RBAC) Permissions(user auth.Authenticatable, team ...uint) ([]string, error) {
+	g, err := r.grants(user, teamOf(team))
+	if err != nil {
+		return nil, err
+	}
+	return g.Permissions, nil
+}
+
+// ==== Cache ==== //
+
+// grants is what a user has in a team, cached as one entry
+type grants struct {
+	Roles       []string `json:"roles"`
+	Permissions []string `json:"permissions"`
+}
+
+const versionKey = "rbac:version"
+
+// Flush invalidates every cached lookup by moving to a new cache version,
+// so entries of every user and team are dropped at once
+func (r *RBAC) Flush() {
+	cache.SetJSON(r.cache, versionKey, strconv.FormatInt(time.Now().UnixNano(), 36), r.TTL)
+}
+
+func (r *RBAC) version() string {
+	var v string
+	if err := cache.GetJSON(r.cache, versionKey, &v); err != nil || v == "" {
+		r.Flush()
+		cache.GetJSON(r.cache, versionKey, &v)
+	}
+	return v
+}
+
+func (r *RBAC) grants(user auth.Authenticatable, team uint) (*grants, error) {
+	if user == nil {
+		return &grants{}, nil
+	}
+	key := fmt.Sprintf("rbac:%s:user:%d:team:%d", r.version(), user.GetAuthID(), team)
+	var g grants
+	if cache.GetJSON(r.cache, key, &g) == nil {
+		return &g, nil
+	}
+	teams := []uint{0, team}
+	err := r.conn().Model(&Role{}).
+		Joins("JOIN user_has_roles ON user_has_roles.role_id = roles.id").
+		Where("user_has_roles.user_id = ? AND user_has_roles.team_id IN ?", user.GetAuthID(), teams).
+		Distinct().Pluck("roles.name", &g.Roles).Error
+	if err != nil {
+		return nil, err
+	}
+	viaRoles := r.conn().Table("role_has_permissions").Select("role_has_permissions.permission_id").
+		Joins("JOIN user_has_roles ON user_has_roles.role_id = role_has_permissions.role_id").
+		Where("user_has_roles.user_id = ? AND user_has_roles.team_id IN ?", user.GetAuthID(), teams)
+	direct := r.conn().Table("user_has_permissions").Select("permission_id").
+		Where("user_id = ? AND team_id IN ?", user.GetAuthID(), teams)
+	err = r.conn().Model(&Permission{}).
+		Where("id IN (?) OR id IN (?)", viaRoles, direct).
+		Pluck("name", &g.Permissions).Error
+	if err != nil {
+		return nil, err
+	}
+	cache.SetJSON(r.cache, key, g, r.TTL)
+	return &g, nil
+}
+
+// ==== Default ==== //
+
+// AssignRole gives user role with the Default store
+func AssignRole(user auth.Authenticatable, role string, team ...uint) error {
+	return Default.AssignRole(user, role, team...)
+}
+
+// RemoveRole takes role from user with the Default store
+func RemoveRole(user auth.Authenticatable, role string, team ...uint) error {
+	return Default.RemoveRole(user, role, team...)
+}
+
+// GivePermissionTo grants user permission with the Default store
+func GivePermissionTo(user auth.Authenticatable, permission string, team ...uint) error {
+	return Default.GivePermissionTo(user, permission, team...)
+}
+
+// RevokePermissionTo takes permission from user with the Default store
+func RevokePermissionTo(user auth.Authenticatable, permission string, team ...uint) error {
+	return Default.RevokePermissionTo(user, permission, team...)
+}
+
+// HasRole checks user's roles with the Default store
+func HasRole(user auth.Authenticatable, role string, team ...uint) bool {
+	return Default.HasRole(user, role, team...)
+}
+
+// HasPermissionTo checks user's permissions with the Default store
+func HasPermissionTo(user auth.Authenticatable, permission string, team ...uint) bool {
+	return Default.HasPermissionTo(user, permission, team...)
+}
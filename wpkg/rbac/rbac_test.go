@@ -0,0 +1,271 @@
+// pkg/rbac/rbac_test.go
+package rbac
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/aasoft24/golara/wpkg/cache"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+type testUser struct {
+	ID   uint
+	Role string
+}
+
+func (testUser) TableName() string { return "users" }
+
+func (u *testUser) GetAuthID() uint         { return u.ID }
+func (u *testUser) GetAuthPassword() string { return "" }
+
+func testDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: gormlogger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB.SetMaxOpenConns(1) // every connection would get its own memory database
+	t.Cleanup(func() { sqlDB.Close() })
+	return db
+}
+
+func testRBAC(t *testing.T, db *gorm.DB, c cache.Cache) *RBAC {
+	t.Helper()
+	r := New(db, c)
+	if err := r.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func must(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGrants(t *testing.T) {
+	user := &testUser{ID: 1}
+	tests := []struct {
+		name  string
+		setup func(t *testing.T, r *RBAC)
+		team  uint
+		roles map[string]bool
+		perms map[string]bool
+	}{
+		{
+			name: "global role and its permissions",
+			setup: func(t *testing.T, r *RBAC) {
+				_, err := r.CreateRole("manager", "orders.view", "orders.refund")
+				must(t, err)
+				must(t, r.AssignRole(user, "manager"))
+			},
+			roles: map[string]bool{"manager": true, "admin": false, "admin|manager": true},
+			perms: map[string]bool{"orders.refund": true, "orders.view|users.edit": true, "users.edit": false},
+		},
+		{
+			name: "global role applies in every team",
+			setup: func(t *testing.T, r *RBAC) {
+				_, err := r.CreateRole("manager", "orders.refund")
+				must(t, err)
+				must(t, r.AssignRole(user, "manager"))
+			},
+			team:  7,
+			roles: map[string]bool{"manager": true},
+			perms: map[string]bool{"orders.refund": true},
+		},
+		{
+			name: "team role only in its team",
+			setup: func(t *testing.T, r *RBAC) {
+				_, err := r.CreateRole("manager", "orders.refund")
+				must(t, err)
+				must(t, r.AssignRole(user, "manager", 8))
+			},
+			team:  7,
+			roles: map[string]bool{"manager": false},
+			perms: map[string]bool{"orders.refund": false},
+		},
+		{
+			name: "team role in its team",
+			setup: func(t *testing.T, r *RBAC) {
+				_, err := r.CreateRole("manager", "orders.refund")
+				must(t, err)
+				must(t, r.AssignRole(user, "manager", 7))
+			},
+			team:  7,
+			roles: map[string]bool{"manager": true},
+			perms: map[string]bool{"orders.refund": true},
+		},
+		{
+			name: "direct permission",
+			setup: func(t *testing.T, r *RBAC) {
+				must(t, r.GivePermissionTo(user, "reports.export"))
+			},
+			roles: map[string]bool{"manager": false},
+			perms: map[string]bool{"reports.export": true, "orders.refund": false},
+		},
+		{
+			name: "other user's role",
+			setup: func(t *testing.T, r *RBAC) {
+				_, err := r.CreateRole("admin", "users.edit")
+				must(t, err)
+				must(t, r.AssignRole(&testUser{ID: 2}, "admin"))
+			},
+			roles: map[string]bool{"admin": false},
+			perms: map[string]bool{"users.edit": false},
+		},
+		{
+			name: "revoking a direct permission keeps the role's",
+			setup: func(t *testing.T, r *RBAC) {
+				_, err := r.CreateRole("manager", "orders.refund")
+				must(t, err)
+				must(t, r.AssignRole(user, "manager"))
+				must(t, r.GivePermissionTo(user, "orders.refund"))
+				must(t, r.RevokePermissionTo(user, "orders.refund"))
+			},
+			perms: map[string]bool{"orders.refund": true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := testRBAC(t, testDB(t), nil)
+			tt.setup(t, r)
+			for role, want := range tt.roles {
+				if got := r.HasRole(user, role, tt.team); got != want {
+					t.Errorf("HasRole(%q, %d) = %v, want %v", role, tt.team, got, want)
+				}
+			}
+			for perm, want := range tt.perms {
+				if got := r.HasPermissionTo(user, perm, tt.team); got != want {
+					t.Errorf("HasPermissionTo(%q, %d) = %v, want %v", perm, tt.team, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestCacheInvalidation(t *testing.T) {
+	user := &testUser{ID: 1}
+	tests := []struct {
+		name   string
+		change func(r *RBAC) error
+		perm   string
+		want   bool
+	}{
+		{"assign role", func(r *RBAC) error { return r.AssignRole(user, "admin") }, "users.edit", true},
+		{"remove role", func(r *RBAC) error { return r.RemoveRole(user, "manager") }, "orders.refund", false},
+		{"sync roles", func(r *RBAC) error { return r.SyncRoles(user, 0, "admin") }, "orders.refund", false},
+		{"delete role", func(r *RBAC) error { return r.DeleteRole("manager") }, "orders.refund", false},
+		{"grant to role", func(r *RBAC) error { return r.GivePermissionToRole("manager", "orders.cancel") }, "orders.cancel", true},
+		{"revoke from role", func(r *RBAC) error { return r.RevokePermissionFromRole("manager", "orders.refund") }, "orders.refund", false},
+		{"delete permission", func(r *RBAC) error { return r.DeletePermission("orders.refund") }, "orders.refund", false},
+		{"grant to user", func(r *RBAC) error { return r.GivePermissionTo(user, "reports.export") }, "reports.export", true},
+		{"revoke from user", func(r *RBAC) error { return r.RevokePermissionTo(user, "invoices.view") }, "invoices.view", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, shared := testDB(t), cache.NewMemoryCache()
+			// two app instances sharing one cache
+			r, other := testRBAC(t, db, shared), New(db, shared)
+			_, err := r.CreateRole("manager", "orders.refund")
+			must(t, err)
+			_, err = r.CreateRole("admin", "users.edit")
+			must(t, err)
+			must(t, r.AssignRole(user, "manager"))
+			must(t, r.GivePermissionTo(user, "invoices.view"))
+
+			if got := r.HasPermissionTo(user, tt.perm); got == tt.want {
+				t.Fatalf("HasPermissionTo(%q) = %v before the change", tt.perm, got)
+			}
+			must(t, tt.change(other))
+			if got := r.HasPermissionTo(user, tt.perm); got != tt.want {
+				t.Fatalf("HasPermissionTo(%q) = %v after the change, want %v", tt.perm, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnknownRole(t *testing.T) {
+	r := testRBAC(t, testDB(t), nil)
+	user := &testUser{ID: 1}
+	tests := []struct {
+		name string
+		run  func() error
+	}{
+		{"assign", func() error { return r.AssignRole(user, "ghost") }},
+		{"remove", func() error { return r.RemoveRole(user, "ghost") }},
+		{"sync", func() error { return r.SyncRoles(user, 0, "ghost") }},
+		{"delete", func() error { return r.DeleteRole("ghost") }},
+		{"grant", func() error { return r.GivePermissionToRole("ghost", "orders.refund") }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.run(); !errors.Is(err, ErrRoleNotFound) {
+				t.Fatalf("error = %v, want ErrRoleNotFound", err)
+			}
+		})
+	}
+}
+
+func TestRoleColumn(t *testing.T) {
+	db := testDB(t)
+	must(t, db.AutoMigrate(&testUser{}))
+	users := []*testUser{{Role: "admin"}, {Role: " manager "}, {Role: ""}, {Role: "admin"}}
+	must(t, db.Create(users).Error)
+	r := testRBAC(t, db, nil)
+
+	for run := 0; run < 2; run++ {
+		n, err := r.CopyRoleColumn("users", "role")
+		must(t, err)
+		if n != 3 {
+			t.Fatalf("run %d: CopyRoleColumn() = %d, want 3", run, n)
+		}
+	}
+	var assigned int64
+	db.Model(&UserRole{}).Count(&assigned)
+	if assigned != 3 {
+		t.Fatalf("%d role assignments after two copies, want 3", assigned)
+	}
+	tests := []struct {
+		user *testUser
+		role string
+	}{
+		{users[0], "admin"},
+		{users[1], "manager"},
+		{users[3], "admin"},
+	}
+	for _, tt := range tests {
+		if !r.HasRole(tt.user, tt.role) {
+			t.Errorf("user %d lacks role %q", tt.user.ID, tt.role)
+		}
+	}
+	if r.HasRole(users[2], "admin|manager") {
+		t.Errorf("user %d without a role got one", users[2].ID)
+	}
+
+	must(t, r.RemoveRole(users[3], "admin"))
+	if err := r.DropRoleColumn("users", "role"); err == nil {
+		t.Fatal("DropRoleColumn() dropped a column with an uncopied role")
+	}
+	if !db.Migrator().HasColumn("users", "role") {
+		t.Fatal("the column is gone after a refused drop")
+	}
+	_, err := r.CopyRoleColumn("users", "role")
+	must(t, err)
+	must(t, r.DropRoleColumn("users", "role"))
+	if db.Migrator().HasColumn("users", "role") {
+		t.Fatal("the column is still there")
+	}
+	if n, err := r.CopyRoleColumn("users", "role"); err != nil || n != 0 {
+		t.Fatalf("CopyRoleColumn() without the column = %d, %v", n, err)
+	}
+	must(t, r.DropRoleColumn("users", "role"))
+}
@@ -0,0 +1,104 @@
+// pkg/routing/binding.go
+package routing
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/aasoft24/golara/wpkg/database"
+	"github.com/aasoft24/golara/wpkg/gola"
+	"gorm.io/gorm"
+)
+
+// Binder loads the model of a route parameter; nil, nil means not found
+type Binder func(value string) (interface{}, error)
+
+// MiddlewareFactory builds the middleware of an alias from its arguments,
+// e.g. "can:update,post" calls the "can" factory with "update", "post"
+type MiddlewareFactory func(args ...string) func(next func(ctx *gola.Context)) func(ctx *gola.Context)
+
+// Bind resolves the :param route parameter with resolve before the route
+// middleware runs (after group middleware such as auth), so handlers and
+// "can" checks get the model from ctx.Model(param). Unknown models are 404.
+// Group middleware runs before binding, so model checks belong on the route.
+func (r *Router) Bind(param string, resolve Binder) {
+	r.table.mu.Lock()
+	defer r.table.mu.Unlock()
+	if r.table.binders == nil {
+		r.table.binders = make(map[string]Binder)
+	}
+	r.table.binders[param] = resolve
+}
+
+// BindModel binds :param to the T whose primary key is the parameter, read
+// from db or database.DB when db is nil
+func BindModel[T any](r *Router, param string, db *gorm.DB) {
+	r.Bind(param, func(value string) (interface{}, error) {
+		conn := db
+		if conn == nil {
+			conn = database.DB
+		}
+		var model T
+		err := conn.Take(&model, "id = ?", value).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		} else if err != nil {
+			return nil, err
+		}
+		return &model, nil
+	})
+}
+
+// substituteBindings loads the bound parameters of the matched route
+func (t *routeTable) substituteBindings(next func(ctx *gola.Context)) func(ctx *gola.Context) {
+	return func(ctx *gola.Context) {
+		for _, p := range ctx.Params {
+			t.mu.RLock()
+			resolve, ok := t.binders[p.Key]
+			t.mu.RUnlock()
+			if !ok {
+				continue
+			}
+			model, err := resolve(p.Value)
+			if err != nil {
+				ctx.Error(http.StatusInternalServerError, "Internal Server Error")
+				return
+			}
+			if model == nil {
+				http.NotFound(ctx.Writer, ctx.Request)
+				return
+			}
+			ctx.SetModel(p.Key, model)
+		}
+		next(ctx)
+	}
+}
+
+// AliasMiddleware names a middleware factory for Middleware, e.g.
+// router.AliasMiddleware("can", ...) enables router.Middleware("can:update,post")
+func (r *Router) AliasMiddleware(name string, factory MiddlewareFactory) {
+	r.table.mu.Lock()
+	defer r.table.mu.Unlock()
+	if r.table.aliases == nil {
+		r.table.aliases = make(map[string]MiddlewareFactory)
+	}
+	r.table.aliases[name] = factory
+}
+
+// Middleware returns the middleware for spec, "alias" or "alias:arg1,arg2".
+// An unknown alias is a programming error and panics at route registration.
+func (r *Router) Middleware(spec string) MiddlewareFunc {
+	name, params, _ := strings.Cut(spec, ":")
+	r.table.mu.RLock()
+	factory, ok := r.table.aliases[name]
+	r.table.mu.RUnlock()
+	if !ok {
+		panic("routing: middleware alias " + name + " is not defined, register it with AliasMiddleware")
+	}
+	var args []string
+	if params != "" {
+		args = strings.Split(params, ",")
+	}
+	return factory(args...)
+}
@@ -4,9 +4,12 @@ package routing
 import (
 	"github.com/aasoft24/golara/wpkg/gola"
 
+	"fmt"
 	"net/http"
+	"net/url"
 	"regexp"
-	"strings"
+	"sync"
+	"sync/atomic"
 
 	"github.com/aasoft24/golara/wpkg/middleware"
 )
@@ -14,105 +17,215 @@ import (
 // MiddlewareFunc defines middleware signature
 type MiddlewareFunc func(func(ctx *gola.Context)) func(ctx *gola.Context)
 
+// paramPattern matches :param segments in route patterns
+var paramPattern = regexp.MustCompile(`:([a-zA-Z0-9_]+)`)
+
 // Route struct for each route
 type Route struct {
 	method      string
+	path        string // full pattern incl. group prefix, used for URL generation
+	name        string
+	table       *routeTable
 	pattern     *regexp.Regexp
 	paramNames  []string
 	handler     func(ctx *gola.Context)
 	middlewares []MiddlewareFunc
+
+	chain func(ctx *gola.Context) // handler wrapped in group + route middleware
+	final func(ctx *gola.Context) // chain wrapped in global middleware
+}
+
+// routeTable is shared by a router and all of its groups
+type routeTable struct {
+	mu       sync.RWMutex
+	routes   []*Route
+	names    map[string]*Route
+	global   []MiddlewareFunc
+	binders  map[string]Binder
+	aliases  map[string]MiddlewareFactory
+	compiled atomic.Bool
 }
 
 // Router struct
 type Router struct {
-	routes         *[]Route
-	middleware     []MiddlewareFunc
+	table          *routeTable
+	middleware     []MiddlewareFunc // group middleware, empty on the root router
 	TemplateEngine *gola.Context
 	prefix         string
+	group          bool
 }
 
 // NewRouter creates a new router
 func NewRouter(templateEngine *gola.Context) *Router {
-	routes := []Route{}
 	return &Router{
-		routes:         &routes,
+		table:          &routeTable{},
 		TemplateEngine: templateEngine,
 	}
 }
 
+// wrap composes middlewares around handler, first middleware outermost
+func wrap(handler func(ctx *gola.Context), middlewares []MiddlewareFunc) func(ctx *gola.Context) {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handler = middlewares[i](handler)
+	}
+	return handler
+}
+
+// compile wraps every route in the global middleware once, after the
+// route table or global middleware changed, instead of on every request
+func (t *routeTable) compile() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.compiled.Load() {
+		return
+	}
+	for _, route := range t.routes {
+		route.final = wrap(route.chain, t.global)
+	}
+	t.compiled.Store(true)
+}
+
+// withPending sends a fluent redirect (ctx.Back()...) left by the handler,
+// so middleware sees the written response
+func withPending(handler func(ctx *gola.Context)) func(ctx *gola.Context) {
+	return func(ctx *gola.Context) {
+		handler(ctx)
+		ctx.SendPending()
+	}
+}
+
 // AddRoute adds a route with pattern
-func (r *Router) AddRoute(method string, pattern string, handler func(ctx *gola.Context), middlewares ...MiddlewareFunc) {
+func (r *Router) AddRoute(method string, pattern string, handler func(ctx *gola.Context), middlewares ...MiddlewareFunc) *Route {
 	// apply group prefix
 	fullPattern := r.prefix + pattern
 
 	// Convert :param to regex
 	paramNames := []string{}
-	regexPattern := regexp.MustCompile(`:([a-zA-Z0-9_]+)`)
-	replacer := regexPattern.ReplaceAllStringFunc(fullPattern, func(m string) string {
+	replacer := paramPattern.ReplaceAllStringFunc(fullPattern, func(m string) string {
 		paramNames = append(paramNames, m[1:])
 		return "([^/]+)"
 	})
 	regex := regexp.MustCompile("^" + replacer + "$")
 
-	*r.routes = append(*r.routes, Route{
+	route := &Route{
 		method:      method,
+		path:        fullPattern,
+		table:       r.table,
 		pattern:     regex,
 		paramNames:  paramNames,
 		handler:     handler,
 		middlewares: middlewares,
+	}
+	// bound models are loaded between group and route middleware
+	route.chain = wrap(r.table.substituteBindings(wrap(withPending(handler), middlewares)), r.middleware)
+
+	r.table.mu.Lock()
+	r.table.routes = append(r.table.routes, route)
+	r.table.compiled.Store(false)
+	r.table.mu.Unlock()
+	return route
+}
+
+// Name names the route for URL generation and ctx.RedirectRoute
+func (rt *Route) Name(name string) *Route {
+	rt.table.mu.Lock()
+	defer rt.table.mu.Unlock()
+	if rt.table.names == nil {
+		rt.table.names = make(map[string]*Route)
+	}
+	rt.name = name
+	rt.table.names[name] = rt
+	return rt
+}
+
+// URL builds the path of a named route; params that are not in the
+// pattern are added as query string
+func (r *Router) URL(name string, params map[string]string) (string, error) {
+	r.table.mu.RLock()
+	route, ok := r.table.names[name]
+	r.table.mu.RUnlock()
+	if !ok {
+		return "", fmt.Errorf("route [%s] not defined", name)
+	}
+
+	used := make(map[string]bool, len(params))
+	missing := ""
+	path := paramPattern.ReplaceAllStringFunc(route.path, func(m string) string {
+		key := m[1:]
+		value, ok := params[key]
+		if !ok {
+			missing = key
+			return m
+		}
+		used[key] = true
+		return url.PathEscape(value)
 	})
+	if missing != "" {
+		return "", fmt.Errorf("missing parameter [%s] for route [%s]", missing, name)
+	}
+
+	query := url.Values{}
+	for k, v := range params {
+		if !used[k] {
+			query.Set(k, v)
+		}
+	}
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+	return path, nil
 }
 
 // ==== HTTP Methods ==== //
-func (r *Router) Get(pattern string, handler func(ctx *gola.Context), middlewares ...MiddlewareFunc) {
-	r.AddRoute("GET", pattern, handler, middlewares...)
+func (r *Router) Get(pattern string, handler func(ctx *gola.Context), middlewares ...MiddlewareFunc) *Route {
+	return r.AddRoute("GET", pattern, handler, middlewares...)
 }
 
-func (r *Router) PostCSRF(pattern string, handler func(ctx *gola.Context), middlewares ...MiddlewareFunc) {
+func (r *Router) PostCSRF(pattern string, handler func(ctx *gola.Context), middlewares ...MiddlewareFunc) *Route {
 	// Auto add CSRF middleware for POST requests
 	allMiddlewares := append([]MiddlewareFunc{middleware.CSRF}, middlewares...)
-	r.AddRoute("POST", pattern, handler, allMiddlewares...)
+	return r.AddRoute("POST", pattern, handler, allMiddlewares...)
 }
 
-func (r *Router) Post(pattern string, handler func(ctx *gola.Context), middlewares ...MiddlewareFunc) {
+func (r *Router) Post(pattern string, handler func(ctx *gola.Context), middlewares ...MiddlewareFunc) *Route {
 	if shouldAddCSRF(pattern) {
 		middlewares = append([]MiddlewareFunc{middleware.CSRF}, middlewares...)
 	}
-	r.AddRoute("POST", pattern, handler, middlewares...)
+	return r.AddRoute("POST", pattern, handler, middlewares...)
 }
 
-func (r *Router) PostNoCSRF(pattern string, handler func(ctx *gola.Context), middlewares ...MiddlewareFunc) {
+func (r *Router) PostNoCSRF(pattern string, handler func(ctx *gola.Context), middlewares ...MiddlewareFunc) *Route {
 	// CSRF without middleware
-	r.AddRoute("POST", pattern, handler, middlewares...)
+	return r.AddRoute("POST", pattern, handler, middlewares...)
 }
 
-func (r *Router) Put(pattern string, handler func(ctx *gola.Context), middlewares ...MiddlewareFunc) {
+func (r *Router) Put(pattern string, handler func(ctx *gola.Context), middlewares ...MiddlewareFunc) *Route {
 	// Auto add CSRF middleware for PUT requests
 	if shouldAddCSRF(pattern) {
 		middlewares = append([]MiddlewareFunc{middleware.CSRF}, middlewares...)
 	}
-	r.AddRoute("PUT", pattern, handler, middlewares...)
+	return r.AddRoute("PUT", pattern, handler, middlewares...)
 }
 
-func (r *Router) Patch(pattern string, handler func(ctx *gola.Context), middlewares ...MiddlewareFunc) {
+func (r *Router) Patch(pattern string, handler func(ctx *gola.Context), middlewares ...MiddlewareFunc) *Route {
 	// Auto add CSRF middleware for PATCH requests
 	//allMiddlewares := append([]MiddlewareFunc{middleware.CSRF}, middlewares...)
 	if shouldAddCSRF(pattern) {
 		middlewares = append([]MiddlewareFunc{middleware.CSRF}, middlewares...)
 	}
-	r.AddRoute("PATCH", pattern, handler, middlewares...)
+	return r.AddRoute("PATCH", pattern, handler, middlewares...)
 }
 
-func (r *Router) Delete(pattern string, handler func(ctx *gola.Context), middlewares ...MiddlewareFunc) {
-	r.AddRoute("DELETE", pattern, handler, middlewares...)
+func (r *Router) Delete(pattern string, handler func(ctx *gola.Context), middlewares ...MiddlewareFunc) *Route {
+	return r.AddRoute("DELETE", pattern, handler, middlewares...)
 }
 
-func (r *Router) Options(pattern string, handler func(ctx *gola.Context), middlewares ...MiddlewareFunc) {
-	r.AddRoute("OPTIONS", pattern, handler, middlewares...)
+func (r *Router) Options(pattern string, handler func(ctx *gola.Context), middlewares ...MiddlewareFunc) *Route {
+	return r.AddRoute("OPTIONS", pattern, handler, middlewares...)
 }
 
-func (r *Router) Head(pattern string, handler func(ctx *gola.Context), middlewares ...MiddlewareFunc) {
-	r.AddRoute("HEAD", pattern, handler, middlewares...)
+func (r *Router) Head(pattern string, handler func(ctx *gola.Context), middlewares ...MiddlewareFunc) *Route {
+	return r.AddRoute("HEAD", pattern, handler, middlewares...)
 }
 
 // Any will register handler for all methods
@@ -123,19 +236,13 @@ func (r *Router) Any(pattern string, handler func(ctx *gola.Context), middleware
 	}
 }
 
-func (r *Router) PutNoCSRF(pattern string, handler func(ctx *gola.Context), middlewares ...MiddlewareFunc) {
-	r.AddRoute("PUT", pattern, handler, middlewares...)
+func (r *Router) PutNoCSRF(pattern string, handler func(ctx *gola.Context), middlewares ...MiddlewareFunc) *Route {
+	return r.AddRoute("PUT", pattern, handler, middlewares...)
 }
 
-// Exclude multiple prefixes
+// shouldAddCSRF skips the URIs excluded by csrf.except or middleware.CSRFExcept
 func shouldAddCSRF(pattern string) bool {
-	excludedPrefixes := []string{"/api/", "/webhook/", "/health/"}
-	for _, prefix := range excludedPrefixes {
-		if strings.HasPrefix(pattern, prefix) {
-			return false
-		}
-	}
-	return true
+	return !middleware.CSRFExcluded(pattern)
 }
 
 func (r *Router) ServeFiles(prefix string, dir string) {
@@ -153,10 +260,11 @@ func (r *Router) ServeFiles(prefix string, dir string) {
 // ==== Group ==== //
 func (r *Router) Group(prefix string, middlewares ...MiddlewareFunc) *Router {
 	return &Router{
-		routes:         r.routes, // share the same slice pointer
+		table:          r.table, // share the same route table
 		middleware:     append(append([]MiddlewareFunc{}, r.middleware...), middlewares...),
 		TemplateEngine: r.TemplateEngine,
 		prefix:         r.prefix + prefix,
+		group:          true,
 	}
 }
 
@@ -174,51 +282,69 @@ func (r *Router) ServeStatic(urlPrefix string, folder string) {
 }
 
 // ==== Middleware ==== //
+// Use adds global middleware on the root router; on a group it applies to
+// routes registered on that group afterwards
 func (r *Router) Use(middleware MiddlewareFunc) {
-	r.middleware = append(r.middleware, middleware)
+	if r.group {
+		r.middleware = append(r.middleware, middleware)
+		return
+	}
+	r.table.mu.Lock()
+	r.table.global = append(r.table.global, middleware)
+	r.table.compiled.Store(false)
+	r.table.mu.Unlock()
 }
 
 // ==== ServeHTTP ==== //
 func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if !r.table.compiled.Load() {
+		r.table.compile()
+	}
+
 	path := req.URL.Path
 	method := req.Method
 
-	for _, route := range *r.routes {
+	r.table.mu.RLock()
+	routes := r.table.routes
+	r.table.mu.RUnlock()
+
+	for _, route := range routes {
 		if route.method != method {
 			continue
 		}
 
-		matches := route.pattern.FindStringSubmatch(path)
+		matches := route.pattern.FindStringSubmatchIndex(path)
 		if matches == nil {
 			continue
 		}
 
-		// build context
-		ctx := &gola.Context{
-			Writer:         w,
-			Request:        req,
-			Params:         map[string]string{},
-			TemplateEngine: r.TemplateEngine.TemplateEngine,
-		}
+		// build context from the pool
+		ctx := gola.AcquireContext(w, req)
+		ctx.TemplateEngine = r.TemplateEngine.TemplateEngine
+		ctx.SetURLGenerator(r)
 
 		// extract params
 		for i, name := range route.paramNames {
-			ctx.Params[name] = matches[i+1]
+			ctx.Params = append(ctx.Params, gola.Param{
+				Key:   name,
+				Value: path[matches[2*i+2]:matches[2*i+3]],
+			})
 		}
 
-		handler := route.handler
-
-		// apply route middleware
-		for i := len(route.middlewares) - 1; i >= 0; i-- {
-			handler = route.middlewares[i](handler)
-		}
-
-		// apply global middleware
-		for i := len(r.middleware) - 1; i >= 0; i-- {
-			handler = r.middleware[i](handler)
+		handler := route.final
+		if handler == nil { // registered after the last compile
+			r.table.compiled.Store(false)
+			r.table.compile()
+			handler = route.final
 		}
 
 		handler(ctx)
+		// a handler that wrote nothing still sends its headers through the
+		// wrapper, so the Before hooks (queued cookies, session, XSRF) run
+		if !ctx.Writer.Written() {
+			ctx.Writer.WriteHeader(http.StatusOK)
+		}
+		gola.ReleaseContext(ctx)
 		return
 	}
 
@@ -0,0 +1,72 @@
+// pkg/routing/router_test.go
+package routing
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aasoft24/golara/wpkg/gola"
+)
+
+// discardWriter keeps the benchmark to the router's own allocations
+type discardWriter struct {
+	header http.Header
+}
+
+func (w *discardWriter) Header() http.Header         { return w.header }
+func (w *discardWriter) Write(b []byte) (int, error) { return len(b), nil }
+func (w *discardWriter) WriteHeader(int)             {}
+
+func benchmarkRouter() *Router {
+	r := NewRouter(&gola.Context{})
+	r.Use(func(next func(ctx *gola.Context)) func(ctx *gola.Context) {
+		return func(ctx *gola.Context) { next(ctx) }
+	})
+	for _, path := range []string{"/", "/about", "/contact", "/posts", "/users"} {
+		r.Get(path, func(c *gola.Context) { c.Writer.WriteHeader(http.StatusOK) })
+	}
+	r.Get("/posts/:post/comments/:comment", func(c *gola.Context) {
+		c.Writer.WriteHeader(http.StatusOK)
+	})
+	return r
+}
+
+func TestServeHTTPRunsBeforeHooks(t *testing.T) {
+	r := NewRouter(&gola.Context{})
+	r.Get("/empty", func(c *gola.Context) {
+		c.QueueCookie(&http.Cookie{Name: "queued", Value: "1"})
+	})
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/empty", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", w.Code)
+	}
+	if got := w.Result().Cookies(); len(got) != 1 || got[0].Name != "queued" {
+		t.Fatalf("cookies = %v, want the queued cookie on a response without body", got)
+	}
+}
+
+func BenchmarkServeHTTP(b *testing.B) {
+	r := benchmarkRouter()
+	cases := []struct {
+		name string
+		path string
+	}{
+		{"static", "/users"},
+		{"params", "/posts/42/comments/7"},
+		{"not_found", "/missing"},
+	}
+	for _, tc := range cases {
+		b.Run(tc.name, func(b *testing.B) {
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			w := &discardWriter{header: http.Header{}}
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				r.ServeHTTP(w, req)
+			}
+		})
+	}
+}
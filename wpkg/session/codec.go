@@ -0,0 +1,88 @@
+// pkg/session/codec.go
+package session
+
+import (
+	"bytes"
+	"encoding/gob"
+	"strconv"
+	"time"
+)
+
+// UserIDKey is the session key holding the logged in user's ID;
+// DatabaseStore copies it to the user_id column
+const UserIDKey = "_user_id"
+
+// Meta is the request info a MetaStore keeps next to the payload
+type Meta struct {
+	UserID    uint
+	IPAddress string
+	UserAgent string
+}
+
+// MetaStore is a Store that also records who owns a session
+type MetaStore interface {
+	Store
+	SaveMeta(sessionID string, data map[string]interface{}, expiration time.Duration, meta Meta) error
+}
+
+func init() {
+	Register(
+		map[string]string{},
+		map[string][]string{},
+		map[string]interface{}{},
+		[]interface{}{},
+		[]FlashMessage{},
+		time.Time{},
+	)
+}
+
+// Register makes custom types storable in file, database, redis and cookie
+// sessions (see encoding/gob)
+func Register(values ...interface{}) {
+	for _, v := range values {
+		gob.Register(v)
+	}
+}
+
+func encode(data map[string]interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(data); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func decode(b []byte) (map[string]interface{}, error) {
+	data := make(map[string]interface{})
+	if len(b) == 0 {
+		return data, nil
+	}
+	if err := gob.NewDecoder(bytes.NewReader(b)).Decode(&data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// UserID returns the ID of the logged-in user, 0 for guests
+func UserID(s Session) uint {
+	return userID(s.Get(UserIDKey))
+}
+
+func userID(v interface{}) uint {
+	switch id := v.(type) {
+	case uint:
+		return id
+	case int:
+		return uint(id)
+	case int64:
+		return uint(id)
+	case uint64:
+		return uint(id)
+	case float64:
+		return uint(id)
+	case string:
+		n, _ := strconv.ParseUint(id, 10, 64)
+		return uint(n)
+	}
+	return 0
+}
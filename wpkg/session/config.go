@@ -0,0 +1,83 @@
+// pkg/session/config.go
+package session
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aasoft24/golara/wpkg/cache"
+	"github.com/aasoft24/golara/wpkg/configs"
+	"github.com/aasoft24/golara/wpkg/database"
+	"github.com/aasoft24/golara/wpkg/encryption"
+)
+
+// NewStore builds the store selected by the session driver; enc is only
+// needed by the cookie driver
+func NewStore(cfg configs.SessionConfig, enc *encryption.Encrypter) (Store, error) {
+	switch cfg.Driver {
+	case "", "memory":
+		return NewMemoryStore(), nil
+	case "file":
+		dir := cfg.Files
+		if dir == "" {
+			dir = "storage/framework/sessions"
+		}
+		return NewFileStore(dir)
+	case "database":
+		if database.DB == nil {
+			return nil, errors.New("session: database driver needs an initialised database")
+		}
+		store := NewDatabaseStore(database.DB, cfg.Table)
+		if err := store.Migrate(); err != nil {
+			return nil, err
+		}
+		return store, nil
+	case "redis":
+		if cache.RDB == nil {
+			return nil, errors.New("session: redis driver needs redis enabled")
+		}
+		return NewRedisStore(cache.RDB, cfg.RedisPrefix), nil
+	case "cookie":
+		if enc == nil {
+			return nil, fmt.Errorf("session: cookie driver: %w", encryption.ErrMissingKey)
+		}
+		return NewCookieStore(enc), nil
+	}
+	return nil, fmt.Errorf("session: unknown driver %q", cfg.Driver)
+}
+
+// FromConfig returns a Manager for the session section of config.yaml
+func FromConfig(cfg *configs.Config) (*Manager, error) {
+	var enc *encryption.Encrypter
+	if cfg.Session.Driver == "cookie" {
+		var err error
+		if enc, err = encryption.Default(); err != nil {
+			return nil, err
+		}
+	}
+	store, err := NewStore(cfg.Session, enc)
+	if err != nil {
+		return nil, err
+	}
+	name := cfg.Session.Cookie
+	if name == "" {
+		name = "go_session"
+	}
+	m := NewManager(store, name)
+	m.SetOptions(Options{
+		Lifetime:        time.Duration(cfg.Session.Lifetime) * time.Minute,
+		AbsoluteTimeout: time.Duration(cfg.Session.AbsoluteTimeout) * time.Minute,
+		ExpireOnClose:   cfg.Session.ExpireOnClose,
+		Domain:          cfg.Session.Domain,
+		Path:            cfg.Session.Path,
+		Secure:          cfg.Session.Secure,
+		SameSite:        ParseSameSite(cfg.Session.SameSite),
+		Partitioned:     cfg.Session.Partitioned,
+		Lock:            cfg.Session.Lock,
+		LockWait:        time.Duration(cfg.Session.LockWait) * time.Second,
+		LockTTL:         time.Duration(cfg.Session.LockTTL) * time.Second,
+		MergeOnSave:     cfg.Session.MergeOnSave,
+	})
+	return m, nil
+}
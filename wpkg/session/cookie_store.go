@@ -0,0 +1,89 @@
+// pkg/session/cookie_store.go
+package session
+
+import (
+	"errors"
+	"time"
+
+	"github.com/aasoft24/golara/wpkg/encryption"
+)
+
+// cookieIDKey keeps the session ID inside a cookie payload
+const cookieIDKey = "_id"
+
+// maxCookieSize is the limit browsers reliably accept for one cookie
+const maxCookieSize = 4096
+
+var (
+	ErrCookieTooLarge = errors.New("session: cookie payload exceeds 4KB")
+	ErrCookieInvalid  = errors.New("session: invalid or expired session cookie")
+)
+
+// cookieDriver is a Store that lives in the cookie itself; the Manager
+// writes the cookie instead of calling Save
+type cookieDriver interface {
+	Encode(name string, data map[string]interface{}, expiration time.Duration) (string, error)
+	Decode(name, value string) (map[string]interface{}, error)
+}
+
+// CookieStore keeps the whole session in an AES-GCM encrypted cookie.
+// Nothing is stored on the server, so keep sessions small (4KB).
+type CookieStore struct {
+	enc *encryption.Encrypter
+}
+
+// NewCookieStore encrypts with enc, usually encryption.Default()
+func NewCookieStore(enc *encryption.Encrypter) *CookieStore {
+	return &CookieStore{enc: enc}
+}
+
+// Encode encrypts data; the cookie name is authenticated, so a value can't
+// be moved to another cookie
+func (s *CookieStore) Encode(name string, data map[string]interface{}, expiration time.Duration) (string, error) {
+	plain, err := encode(map[string]interface{}{
+		"expires": time.Now().Add(expiration).Unix(),
+		"data":    data,
+	})
+	if err != nil {
+		return "", err
+	}
+	value, err := s.enc.Seal(plain, []byte(name))
+	if err != nil {
+		return "", err
+	}
+	if len(name)+len(value) > maxCookieSize {
+		return "", ErrCookieTooLarge
+	}
+	return value, nil
+}
+
+// Decode decrypts a cookie written by Encode
+func (s *CookieStore) Decode(name, value string) (map[string]interface{}, error) {
+	plain, err := s.enc.Open(value, []byte(name))
+	if err != nil {
+		return nil, ErrCookieInvalid
+	}
+	payload, err := decode(plain)
+	if err != nil {
+		return nil, ErrCookieInvalid
+	}
+	expires, _ := payload["expires"].(int64)
+	data, _ := payload["data"].(map[string]interface{})
+	if data == nil || time.Now().Unix() > expires {
+		return nil, ErrCookieInvalid
+	}
+	return data, nil
+}
+
+// Get, Save and Delete are no-ops: the data travels in the cookie
+func (s *CookieStore) Get(sessionID string) (map[string]interface{}, error) {
+	return make(map[string]interface{}), nil
+}
+
+func (s *CookieStore) Save(sessionID string, data map[string]interface{}, expiration time.Duration) error {
+	return nil
+}
+
+func (s *CookieStore) Delete(sessionID string) error {
+	return nil
+}
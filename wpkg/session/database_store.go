@@ -0,0 +1,161 @@
+// pkg/session/database_store.go
+package session
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SessionRecord is a row of the sessions table
+type SessionRecord struct {
+	ID           string    `gorm:"primaryKey;size:64"`
+	UserID       *uint     `gorm:"index"`
+	IPAddress    string    `gorm:"size:45"`
+	UserAgent    string    `gorm:"type:text"`
+	Payload      []byte    `gorm:"not null"`
+	LastActivity time.Time `gorm:"index"`
+	ExpiresAt    time.Time `gorm:"index"`
+}
+
+// SessionLock is a row of the <table>_locks table
+type SessionLock struct {
+	ID        string    `gorm:"primaryKey;size:64"`
+	Token     string    `gorm:"size:32"`
+	ExpiresAt time.Time `gorm:"index"`
+}
+
+// DatabaseStore keeps sessions in a GORM table, so the active sessions of a
+// user can be listed or removed (see ForUser)
+type DatabaseStore struct {
+	db    *gorm.DB
+	table string
+}
+
+func NewDatabaseStore(db *gorm.DB, table string) *DatabaseStore {
+	if table == "" {
+		table = "sessions"
+	}
+	store := &DatabaseStore{db: db, table: table}
+
+	// Start cleanup goroutine
+	go store.cleanup()
+
+	return store
+}
+
+// Migrate creates or updates the sessions and session locks tables
+func (s *DatabaseStore) Migrate() error {
+	if err := s.db.Table(s.table).AutoMigrate(&SessionRecord{}); err != nil {
+		return err
+	}
+	return s.db.Table(s.lockTable()).AutoMigrate(&SessionLock{})
+}
+
+func (s *DatabaseStore) lockTable() string {
+	return s.table + "_locks"
+}
+
+// Lock implements Locker with a row per locked session; the primary key
+// makes the insert fail while another request holds it
+func (s *DatabaseStore) Lock(sessionID string, ttl, wait time.Duration) (func(), error) {
+	token := lockToken()
+	err := pollLock(wait, func() (bool, error) {
+		err := s.db.Table(s.lockTable()).Where("id = ? AND expires_at < ?", sessionID, time.Now()).Delete(&SessionLock{}).Error
+		if err != nil {
+			return false, err
+		}
+		err = s.db.Table(s.lockTable()).Create(&SessionLock{
+			ID:        sessionID,
+			Token:     token,
+			ExpiresAt: time.Now().Add(ttl),
+		}).Error
+		if s.duplicateKey(err) {
+			return false, nil // held by another request
+		}
+		return err == nil, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return func() {
+		s.db.Table(s.lockTable()).Where("id = ? AND token = ?", sessionID, token).Delete(&SessionLock{})
+	}, nil
+}
+
+// duplicateKey reports whether err is a unique key violation, translated by
+// the driver since gorm.Config.TranslateError may be off
+func (s *DatabaseStore) duplicateKey(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return true
+	}
+	if t, ok := s.db.Dialector.(gorm.ErrorTranslator); ok {
+		return errors.Is(t.Translate(err), gorm.ErrDuplicatedKey)
+	}
+	return false
+}
+
+func (s *DatabaseStore) Get(sessionID string) (map[string]interface{}, error) {
+	var rec SessionRecord
+	err := s.db.Table(s.table).Where("id = ?", sessionID).Take(&rec).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return make(map[string]interface{}), nil
+	} else if err != nil {
+		return nil, err
+	}
+	if time.Now().After(rec.ExpiresAt) {
+		return make(map[string]interface{}), nil
+	}
+	return decode(rec.Payload)
+}
+
+func (s *DatabaseStore) Save(sessionID string, data map[string]interface{}, expiration time.Duration) error {
+	return s.SaveMeta(sessionID, data, expiration, Meta{})
+}
+
+func (s *DatabaseStore) SaveMeta(sessionID string, data map[string]interface{}, expiration time.Duration, meta Meta) error {
+	payload, err := encode(data)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	rec := SessionRecord{
+		ID:           sessionID,
+		IPAddress:    meta.IPAddress,
+		UserAgent:    meta.UserAgent,
+		Payload:      payload,
+		LastActivity: now,
+		ExpiresAt:    now.Add(expiration),
+	}
+	if meta.UserID != 0 {
+		rec.UserID = &meta.UserID
+	}
+	return s.db.Table(s.table).Save(&rec).Error
+}
+
+func (s *DatabaseStore) Delete(sessionID string) error {
+	return s.db.Table(s.table).Where("id = ?", sessionID).Delete(&SessionRecord{}).Error
+}
+
+// ForUser lists the active sessions of a user, newest first
+func (s *DatabaseStore) ForUser(userID uint) ([]SessionRecord, error) {
+	var recs []SessionRecord
+	err := s.db.Table(s.table).
+		Where("user_id = ? AND expires_at > ?", userID, time.Now()).
+		Order("last_activity DESC").
+		Find(&recs).Error
+	return recs, err
+}
+
+func (s *DatabaseStore) cleanup() {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		s.db.Table(s.table).Where("expires_at < ?", time.Now()).Delete(&SessionRecord{})
+	}
+}
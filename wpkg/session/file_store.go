@@ -0,0 +1,155 @@
+// pkg/session/file_store.go
+package session
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+var errInvalidID = errors.New("session: invalid session id")
+
+// FileStore keeps one gob file per session. The file's mtime is set to the
+// expiry, so expired sessions can be swept without reading them.
+type FileStore struct {
+	dir string
+}
+
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	store := &FileStore{dir: dir}
+
+	// Start cleanup goroutine
+	go store.cleanup()
+
+	return store, nil
+}
+
+func (s *FileStore) path(sessionID string) (string, error) {
+	if !validID(sessionID) {
+		return "", errInvalidID
+	}
+	return filepath.Join(s.dir, sessionID), nil
+}
+
+func (s *FileStore) Get(sessionID string) (map[string]interface{}, error) {
+	path, err := s.path(sessionID)
+	if err != nil {
+		return make(map[string]interface{}), nil
+	}
+	info, err := os.Stat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return make(map[string]interface{}), nil
+	} else if err != nil {
+		return nil, err
+	}
+	if time.Now().After(info.ModTime()) {
+		_ = os.Remove(path)
+		return make(map[string]interface{}), nil
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return decode(b)
+}
+
+func (s *FileStore) Save(sessionID string, data map[string]interface{}, expiration time.Duration) error {
+	path, err := s.path(sessionID)
+	if err != nil {
+		return err
+	}
+	b, err := encode(data)
+	if err != nil {
+		return err
+	}
+
+	// write to a temp file and rename, so readers never see half a session
+	tmp, err := os.CreateTemp(s.dir, ".tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	expires := time.Now().Add(expiration)
+	if err := os.Chtimes(tmp.Name(), expires, expires); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func (s *FileStore) Delete(sessionID string) error {
+	path, err := s.path(sessionID)
+	if err != nil {
+		return nil
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (s *FileStore) cleanup() {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		entries, err := os.ReadDir(s.dir)
+		if err != nil {
+			continue
+		}
+		now := time.Now()
+		for _, e := range entries {
+			info, err := e.Info()
+			if err == nil && !e.IsDir() && !strings.HasSuffix(e.Name(), ".lock") && now.After(info.ModTime()) {
+				_ = os.Remove(filepath.Join(s.dir, e.Name()))
+			}
+		}
+	}
+}
+
+// Lock implements Locker with an exclusive <id>.lock file; a lock file
+// older than ttl is considered abandoned
+func (s *FileStore) Lock(sessionID string, ttl, wait time.Duration) (func(), error) {
+	path, err := s.path(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	path += ".lock"
+	token := lockToken()
+	err = pollLock(wait, func() (bool, error) {
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if errors.Is(err, os.ErrExist) {
+			if info, err := os.Stat(path); err == nil && time.Since(info.ModTime()) > ttl {
+				_ = os.Remove(path)
+			}
+			return false, nil
+		} else if err != nil {
+			return false, err
+		}
+		_, err = f.WriteString(token)
+		f.Close()
+		return err == nil, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return func() {
+		if b, err := os.ReadFile(path); err == nil && string(b) == token {
+			_ = os.Remove(path)
+		}
+	}, nil
+}
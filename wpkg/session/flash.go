@@ -0,0 +1,139 @@
+// pkg/session/flash.go
+package session
+
+// Flash data lives for exactly one following request. Keys flashed during a
+// request are listed under flashNewKey; when the next request starts they
+// move to flashOldKey, and the request after that removes them.
+const (
+	flashNewKey      = "_flash.new"
+	flashOldKey      = "_flash.old"
+	flashMessagesKey = "_flash.messages"
+)
+
+// Flash message types
+const (
+	FlashSuccess = "success"
+	FlashInfo    = "info"
+	FlashWarning = "warning"
+	FlashError   = "error"
+)
+
+// FlashMessage is one typed message of the flash bag
+type FlashMessage struct {
+	Type    string
+	Message string
+}
+
+// Flash stores value for the next request
+func Flash(s Session, key string, value interface{}) {
+	s.Set(key, value)
+	s.Set(flashNewKey, addKey(flashKeys(s, flashNewKey), key))
+	s.Set(flashOldKey, removeKey(flashKeys(s, flashOldKey), key))
+}
+
+// AddFlash appends a typed message; several messages of any type can be flashed
+func AddFlash(s Session, typ, message string) {
+	var messages []FlashMessage
+	// messages still shown from the previous request are not carried over
+	if hasKey(flashKeys(s, flashNewKey), flashMessagesKey) {
+		messages, _ = s.Get(flashMessagesKey).([]FlashMessage)
+	}
+	messages = append(messages, FlashMessage{Type: typ, Message: message})
+	Flash(s, flashMessagesKey, messages)
+}
+
+// FlashMessages returns the flash messages, optionally only of the given types
+func FlashMessages(s Session, types ...string) []FlashMessage {
+	messages, _ := s.Get(flashMessagesKey).([]FlashMessage)
+	if len(types) == 0 {
+		return messages
+	}
+	var out []FlashMessage
+	for _, m := range messages {
+		if hasKey(types, m.Type) {
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
+// Reflash keeps all current flash data for one more request
+func Reflash(s Session) {
+	keys := flashKeys(s, flashNewKey)
+	for _, k := range flashKeys(s, flashOldKey) {
+		keys = addKey(keys, k)
+	}
+	s.Set(flashNewKey, keys)
+	s.Set(flashOldKey, []string{})
+}
+
+// Keep keeps the given flash keys for one more request
+func Keep(s Session, keys ...string) {
+	newKeys, oldKeys := flashKeys(s, flashNewKey), flashKeys(s, flashOldKey)
+	for _, k := range keys {
+		newKeys = addKey(newKeys, k)
+		oldKeys = removeKey(oldKeys, k)
+	}
+	s.Set(flashNewKey, newKeys)
+	s.Set(flashOldKey, oldKeys)
+}
+
+// AgeFlashData removes the data of the previous request and ages the current
+// one. The Manager calls it when a session starts.
+func AgeFlashData(s Session) {
+	oldKeys, newKeys := flashKeys(s, flashOldKey), flashKeys(s, flashNewKey)
+	if len(oldKeys) == 0 && len(newKeys) == 0 {
+		return
+	}
+	for _, k := range oldKeys {
+		s.Delete(k)
+	}
+	s.Set(flashOldKey, newKeys)
+	s.Set(flashNewKey, []string{})
+}
+
+func flashKeys(s Session, key string) []string {
+	return toKeys(s.Get(key))
+}
+
+func toKeys(value interface{}) []string {
+	switch v := value.(type) {
+	case []string:
+		return v
+	case []interface{}: // decoded by a JSON store
+		keys := make([]string, 0, len(v))
+		for _, k := range v {
+			if str, ok := k.(string); ok {
+				keys = append(keys, str)
+			}
+		}
+		return keys
+	}
+	return nil
+}
+
+func hasKey(keys []string, key string) bool {
+	for _, k := range keys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+func addKey(keys []string, key string) []string {
+	if hasKey(keys, key) {
+		return keys
+	}
+	return append(append([]string{}, keys...), key)
+}
+
+func removeKey(keys []string, key string) []string {
+	out := make([]string, 0, len(keys))
+	for _, k := range keys {
+		if k != key {
+			out = append(out, k)
+		}
+	}
+	return out
+}
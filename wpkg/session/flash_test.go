@@ -0,0 +1,113 @@
+// pkg/session/flash_test.go
+package session
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+// visit runs one request of the session with cookie id ("" starts a new
+// session) and returns the session ID for the next request
+func visit(t *testing.T, m *Manager, id string, fn func(s Session)) string {
+	t.Helper()
+	r := httptest.NewRequest("GET", "/", nil)
+	if id != "" {
+		r.AddCookie(&http.Cookie{Name: m.cookieName, Value: id})
+	}
+	s, err := m.Start(httptest.NewRecorder(), r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fn != nil {
+		fn(s)
+	}
+	if err := s.Release(); err != nil {
+		t.Fatal(err)
+	}
+	return s.ID()
+}
+
+func TestFlashAging(t *testing.T) {
+	saved := FlashMessage{Type: FlashSuccess, Message: "Saved"}
+	failed := FlashMessage{Type: FlashError, Message: "Failed"}
+
+	type request struct {
+		want []FlashMessage // messages the request sees when it starts
+		do   func(s Session)
+	}
+	tests := []struct {
+		name     string
+		requests []request
+	}{
+		{"lives one request", []request{
+			{nil, func(s Session) { AddFlash(s, saved.Type, saved.Message) }},
+			{[]FlashMessage{saved}, nil},
+			{nil, nil},
+		}},
+		{"several messages", []request{
+			{nil, func(s Session) {
+				AddFlash(s, saved.Type, saved.Message)
+				AddFlash(s, failed.Type, failed.Message)
+			}},
+			{[]FlashMessage{saved, failed}, nil},
+		}},
+		{"shown messages are not carried over", []request{
+			{nil, func(s Session) { AddFlash(s, saved.Type, saved.Message) }},
+			{[]FlashMessage{saved}, func(s Session) { AddFlash(s, failed.Type, failed.Message) }},
+			{[]FlashMessage{failed}, nil},
+			{nil, nil},
+		}},
+		{"reflash keeps them once more", []request{
+			{nil, func(s Session) { AddFlash(s, saved.Type, saved.Message) }},
+			{[]FlashMessage{saved}, Reflash},
+			{[]FlashMessage{saved}, nil},
+			{nil, nil},
+		}},
+		{"keep", []request{
+			{nil, func(s Session) { AddFlash(s, saved.Type, saved.Message) }},
+			{[]FlashMessage{saved}, func(s Session) { Keep(s, flashMessagesKey) }},
+			{[]FlashMessage{saved}, nil},
+			{nil, nil},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewManager(NewMemoryStore(), "go_session")
+			id := ""
+			for i, req := range tt.requests {
+				id = visit(t, m, id, func(s Session) {
+					if got := FlashMessages(s); !reflect.DeepEqual(got, req.want) {
+						t.Fatalf("request %d: messages = %v, want %v", i+1, got, req.want)
+					}
+					if req.do != nil {
+						req.do(s)
+					}
+				})
+			}
+		})
+	}
+}
+
+func TestFlashData(t *testing.T) {
+	m := NewManager(NewMemoryStore(), "go_session")
+	id := visit(t, m, "", func(s Session) {
+		Flash(s, "status", "sent")
+		Flash(s, "tab", "billing")
+	})
+	id = visit(t, m, id, func(s Session) {
+		if s.Get("status") != "sent" || s.Get("tab") != "billing" {
+			t.Fatalf("flashed data = %v, %v", s.Get("status"), s.Get("tab"))
+		}
+		Keep(s, "tab")
+	})
+	visit(t, m, id, func(s Session) {
+		if s.Get("status") != nil {
+			t.Fatalf("status = %v, want it removed", s.Get("status"))
+		}
+		if s.Get("tab") != "billing" {
+			t.Fatalf("kept tab = %v", s.Get("tab"))
+		}
+	})
+}
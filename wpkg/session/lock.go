@@ -0,0 +1,77 @@
+// pkg/session/lock.go
+package session
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"time"
+)
+
+// ErrLockTimeout is returned by Start when another request holds the session
+// longer than the lock wait
+var ErrLockTimeout = errors.New("session: timed out waiting for the session lock")
+
+// Locker is implemented by stores that can lock a session ID across
+// requests and app instances. ttl bounds how long a crashed request can
+// hold the lock, wait how long Start blocks for it.
+type Locker interface {
+	Lock(sessionID string, ttl, wait time.Duration) (unlock func(), err error)
+}
+
+// lockPollInterval is how often a blocked request retries the lock
+const lockPollInterval = 25 * time.Millisecond
+
+// pollLock retries try until it succeeds or wait passes
+func pollLock(wait time.Duration, try func() (bool, error)) error {
+	deadline := time.Now().Add(wait)
+	for {
+		ok, err := try()
+		if err != nil {
+			return err
+		}
+		if ok {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return ErrLockTimeout
+		}
+		time.Sleep(lockPollInterval)
+	}
+}
+
+// lockToken identifies the lock owner so only it can release the lock
+func lockToken() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// Lock implements Locker for a single process
+func (s *MemoryStore) Lock(sessionID string, ttl, wait time.Duration) (func(), error) {
+	token := lockToken()
+	err := pollLock(wait, func() (bool, error) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if l, held := s.locks[sessionID]; held && time.Now().Before(l.expires) {
+			return false, nil
+		}
+		s.locks[sessionID] = memoryLock{token: token, expires: time.Now().Add(ttl)}
+		return true, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.locks[sessionID].token == token {
+			delete(s.locks, sessionID)
+		}
+	}, nil
+}
+
+type memoryLock struct {
+	token   string
+	expires time.Time
+}
@@ -0,0 +1,75 @@
+// pkg/session/merge.go
+package session
+
+// Keys holding per-field maps that concurrent requests may both write
+const (
+	oldInputKey = "_old"
+	errorsKey   = "_errors"
+)
+
+// mergeData applies the keys this request set or deleted onto the data
+// saved meanwhile by concurrent requests of the same session
+func mergeData(fresh, ours map[string]interface{}, changed map[string]bool) map[string]interface{} {
+	for key, set := range changed {
+		if !set {
+			delete(fresh, key)
+			continue
+		}
+		fresh[key] = mergeValue(key, fresh, ours[key])
+	}
+	return fresh
+}
+
+// mergeValue combines flash data and input maps; any other key takes ours
+func mergeValue(key string, fresh map[string]interface{}, ours interface{}) interface{} {
+	theirs, ok := fresh[key]
+	if !ok {
+		return ours
+	}
+	switch key {
+	case flashNewKey:
+		keys := toKeys(ours)
+		for _, k := range toKeys(theirs) {
+			keys = addKey(keys, k)
+		}
+		return keys
+	case flashMessagesKey:
+		// only messages flashed for the next request, not ones already shown
+		if !hasKey(toKeys(fresh[flashNewKey]), flashMessagesKey) {
+			return ours
+		}
+		messages, _ := theirs.([]FlashMessage)
+		mine, _ := ours.([]FlashMessage)
+		out := append([]FlashMessage{}, messages...)
+		for _, m := range mine {
+			if !hasMessage(out, m) {
+				out = append(out, m)
+			}
+		}
+		return out
+	case oldInputKey, errorsKey:
+		a, okA := theirs.(map[string]string)
+		b, okB := ours.(map[string]string)
+		if !okA || !okB {
+			return ours
+		}
+		out := make(map[string]string, len(a)+len(b))
+		for k, v := range a {
+			out[k] = v
+		}
+		for k, v := range b {
+			out[k] = v
+		}
+		return out
+	}
+	return ours
+}
+
+func hasMessage(messages []FlashMessage, m FlashMessage) bool {
+	for _, x := range messages {
+		if x == m {
+			return true
+		}
+	}
+	return false
+}
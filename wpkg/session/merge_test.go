@@ -0,0 +1,122 @@
+// pkg/session/merge_test.go
+package session
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestMergeOnSave(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(s Session) // request before the two overlapping ones
+		a, b  func(s Session) // a saves first, b saves later from stale data
+		check func(t *testing.T, s Session)
+	}{
+		{
+			name: "flash messages of both requests",
+			a:    func(s Session) { AddFlash(s, FlashSuccess, "Saved") },
+			b:    func(s Session) { AddFlash(s, FlashInfo, "Synced") },
+			check: func(t *testing.T, s Session) {
+				want := []FlashMessage{{FlashSuccess, "Saved"}, {FlashInfo, "Synced"}}
+				if got := FlashMessages(s); !reflect.DeepEqual(got, want) {
+					t.Fatalf("messages = %v, want %v", got, want)
+				}
+			},
+		},
+		{
+			name: "old input and errors maps",
+			a: func(s Session) {
+				s.Set(oldInputKey, map[string]string{"name": "Ann"})
+				s.Set(errorsKey, map[string]string{"email": "taken"})
+			},
+			b: func(s Session) {
+				s.Set(oldInputKey, map[string]string{"city": "Dhaka"})
+				s.Set(errorsKey, map[string]string{"email": "invalid"})
+			},
+			check: func(t *testing.T, s Session) {
+				if got := s.Get(oldInputKey); !reflect.DeepEqual(got, map[string]string{"name": "Ann", "city": "Dhaka"}) {
+					t.Fatalf("old input = %v", got)
+				}
+				if got := s.Get(errorsKey); !reflect.DeepEqual(got, map[string]string{"email": "invalid"}) {
+					t.Fatalf("errors = %v, want the later request's", got)
+				}
+			},
+		},
+		{
+			name: "keys the other request did not touch survive",
+			a:    func(s Session) { s.Set("cart", 3) },
+			b:    func(s Session) { s.Set("theme", "dark") },
+			check: func(t *testing.T, s Session) {
+				if s.Get("cart") != 3 || s.Get("theme") != "dark" {
+					t.Fatalf("cart = %v, theme = %v", s.Get("cart"), s.Get("theme"))
+				}
+			},
+		},
+		{
+			name:  "later write of the same key wins",
+			setup: func(s Session) { s.Set("theme", "light") },
+			a:     func(s Session) { s.Set("theme", "dark") },
+			b:     func(s Session) { s.Set("theme", "blue") },
+			check: func(t *testing.T, s Session) {
+				if s.Get("theme") != "blue" {
+					t.Fatalf("theme = %v, want blue", s.Get("theme"))
+				}
+			},
+		},
+		{
+			name:  "deletes are kept",
+			setup: func(s Session) { s.Set("cart", 3) },
+			a:     func(s Session) { s.Set("theme", "dark") },
+			b:     func(s Session) { s.Delete("cart") },
+			check: func(t *testing.T, s Session) {
+				if s.Get("cart") != nil || s.Get("theme") != "dark" {
+					t.Fatalf("cart = %v, theme = %v", s.Get("cart"), s.Get("theme"))
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewManager(NewMemoryStore(), "go_session")
+			opts := m.Options()
+			opts.MergeOnSave = true
+			m.SetOptions(opts)
+
+			id := visit(t, m, "", func(s Session) {
+				s.Set("user", 1)
+				if tt.setup != nil {
+					tt.setup(s)
+				}
+			})
+			start := func() Session {
+				r := httptest.NewRequest("GET", "/", nil)
+				r.AddCookie(&http.Cookie{Name: "go_session", Value: id})
+				s, err := m.Start(httptest.NewRecorder(), r)
+				if err != nil {
+					t.Fatal(err)
+				}
+				return s
+			}
+			// both requests load the session before either saves
+			a, b := start(), start()
+			tt.a(a)
+			tt.b(b)
+			if err := a.Release(); err != nil {
+				t.Fatal(err)
+			}
+			if err := b.Release(); err != nil {
+				t.Fatal(err)
+			}
+
+			data, err := m.store.Get(id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			s := &session{data: data, changed: map[string]bool{}}
+			tt.check(t, s)
+		})
+	}
+}
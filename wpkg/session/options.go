@@ -0,0 +1,132 @@
+// pkg/session/options.go
+package session
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Session keys used to enforce the idle and absolute timeouts
+const (
+	createdKey  = "_created"
+	activityKey = "_last_activity"
+)
+
+// Options configures the session cookie and timeouts
+type Options struct {
+	Lifetime        time.Duration // idle timeout, slides with activity
+	AbsoluteTimeout time.Duration // max age since the session started, 0 = none
+	ExpireOnClose   bool          // browser session cookie, no Expires
+	Domain          string
+	Path            string
+	Secure          bool // always set on TLS requests
+	SameSite        http.SameSite
+	Partitioned     bool // CHIPS, needs Secure
+
+	Lock        bool          // serialize requests of one session (stores implementing Locker)
+	LockWait    time.Duration // how long Start blocks for the lock
+	LockTTL     time.Duration // lock expiry if a request never releases it
+	MergeOnSave bool          // re-read on save and apply only this request's changes
+}
+
+// DefaultOptions match the previous hard-coded cookie, minus Secure on plain HTTP
+func DefaultOptions() Options {
+	return Options{
+		Lifetime: sessionExpiration,
+		Path:     "/",
+		SameSite: http.SameSiteLaxMode,
+		LockWait: 10 * time.Second,
+		LockTTL:  30 * time.Second,
+	}
+}
+
+// SetOptions replaces the cookie and timeout options
+func (m *Manager) SetOptions(opts Options) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if opts.Lifetime <= 0 {
+		opts.Lifetime = sessionExpiration
+	}
+	if opts.Path == "" {
+		opts.Path = "/"
+	}
+	if opts.LockWait <= 0 {
+		opts.LockWait = 10 * time.Second
+	}
+	if opts.LockTTL <= 0 {
+		opts.LockTTL = 30 * time.Second
+	}
+	m.opts = opts
+}
+
+// Options returns the current options
+func (m *Manager) Options() Options {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.opts
+}
+
+// ParseSameSite maps lax, strict, none to http.SameSite
+func ParseSameSite(v string) http.SameSite {
+	switch strings.ToLower(v) {
+	case "strict":
+		return http.SameSiteStrictMode
+	case "none":
+		return http.SameSiteNoneMode
+	case "default":
+		return http.SameSiteDefaultMode
+	}
+	return http.SameSiteLaxMode
+}
+
+// cookie builds the session cookie for value
+func (o Options) cookie(name, value string, secure bool) *http.Cookie {
+	c := &http.Cookie{
+		Name:        name,
+		Value:       value,
+		Path:        o.Path,
+		Domain:      o.Domain,
+		HttpOnly:    true,
+		Secure:      o.Secure || secure,
+		SameSite:    o.SameSite,
+		Partitioned: o.Partitioned,
+	}
+	if !o.ExpireOnClose {
+		c.Expires = time.Now().Add(o.Lifetime)
+		c.MaxAge = int(o.Lifetime / time.Second)
+	}
+	return c
+}
+
+// expired reports whether data passed the idle or absolute timeout
+func (o Options) expired(data map[string]interface{}, now time.Time) bool {
+	if last := unixValue(data[activityKey]); last > 0 && now.Sub(time.Unix(last, 0)) > o.Lifetime {
+		return true
+	}
+	if o.AbsoluteTimeout > 0 {
+		if created := unixValue(data[createdKey]); created > 0 && now.Sub(time.Unix(created, 0)) > o.AbsoluteTimeout {
+			return true
+		}
+	}
+	return false
+}
+
+// needsTouch reports whether the last activity is old enough to extend the
+// expiry; writing at most once a minute keeps idle reads from saving
+func (o Options) needsTouch(data map[string]interface{}, now time.Time) bool {
+	last := unixValue(data[activityKey])
+	return now.Sub(time.Unix(last, 0)) >= min(time.Minute, o.Lifetime/2)
+}
+
+func unixValue(v interface{}) int64 {
+	switch n := v.(type) {
+	case int64:
+		return n
+	case int:
+		return int64(n)
+	case float64:
+		return int64(n)
+	}
+	return 0
+}
@@ -0,0 +1,69 @@
+// pkg/session/redis_store.go
+package session
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisStore keeps sessions in redis with the lifetime as TTL, so several
+// app instances can share them
+type RedisStore struct {
+	client *redis.Client
+	prefix string
+}
+
+func NewRedisStore(client *redis.Client, prefix string) *RedisStore {
+	if prefix == "" {
+		prefix = "session:"
+	}
+	return &RedisStore{client: client, prefix: prefix}
+}
+
+func (s *RedisStore) Get(sessionID string) (map[string]interface{}, error) {
+	b, err := s.client.Get(context.Background(), s.prefix+sessionID).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return make(map[string]interface{}), nil
+	} else if err != nil {
+		return nil, err
+	}
+	return decode(b)
+}
+
+func (s *RedisStore) Save(sessionID string, data map[string]interface{}, expiration time.Duration) error {
+	b, err := encode(data)
+	if err != nil {
+		return err
+	}
+	return s.client.Set(context.Background(), s.prefix+sessionID, b, expiration).Err()
+}
+
+func (s *RedisStore) Delete(sessionID string) error {
+	return s.client.Del(context.Background(), s.prefix+sessionID).Err()
+}
+
+// unlockScript deletes the lock only if this request still owns it
+var unlockScript = redis.NewScript(`
+if redis.call("get", KEYS[1]) == ARGV[1] then
+	return redis.call("del", KEYS[1])
+end
+return 0`)
+
+// Lock implements Locker with SET NX and a TTL, shared by all app instances
+func (s *RedisStore) Lock(sessionID string, ttl, wait time.Duration) (func(), error) {
+	key := s.prefix + "lock:" + sessionID
+	token := lockToken()
+	ctx := context.Background()
+	err := pollLock(wait, func() (bool, error) {
+		return s.client.SetNX(ctx, key, token, ttl).Result()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return func() {
+		unlockScript.Run(ctx, s.client, []string{key}, token)
+	}, nil
+}
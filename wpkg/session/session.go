@@ -5,16 +5,17 @@ import (
 	"crypto/rand"
 	"encoding/base64"
 	"errors"
+	"net"
 	"net/http"
+	"strings"
 	"sync"
 	"time"
-
-	"github.com/gorilla/sessions"
 )
 
 const sessionExpiration = 10 * time.Minute // <-- Session 10 মিনিট
 
-var store = sessions.NewCookieStore([]byte("your-secret-key"))
+// ErrHeadersSent is returned when the session cookie is written after the response started
+var ErrHeadersSent = errors.New("session: response headers already sent")
 
 type Session interface {
 	Get(key string) interface{}
@@ -22,6 +23,16 @@ type Session interface {
 	Delete(key string)
 	Save() error
 	ID() string
+
+	// Regenerate moves the data to a new ID (call it after login);
+	// destroyOld removes the old ID from the store
+	Regenerate(destroyOld bool) error
+	// Invalidate drops all data and the old ID (call it on logout)
+	Invalidate() error
+	// Flush removes all data but keeps the ID
+	Flush()
+	// Release saves the session and frees its lock; call it when the request ends
+	Release() error
 }
 
 type Store interface {
@@ -33,71 +44,126 @@ type Store interface {
 type Manager struct {
 	store      Store
 	cookieName string
-	mu         sync.Mutex
+	opts       Options
+	mu         sync.RWMutex // guards opts; sessions lock per ID, see Options.Lock
 }
 
 func NewManager(store Store, cookieName string) *Manager {
 	return &Manager{
 		store:      store,
 		cookieName: cookieName,
+		opts:       DefaultOptions(),
 	}
 }
 
-func Default() *sessions.Session {
-	// Dummy function, actual implementation depends on your framework
-	return &sessions.Session{}
-}
-
 func (m *Manager) Start(w http.ResponseWriter, r *http.Request) (Session, error) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+	opts := m.Options()
+	cookieStore, isCookie := m.store.(cookieDriver)
 
-	// Get session ID from cookie
+	// Get session ID (or the whole payload for the cookie driver) from cookie
 	var sessionID string
+	var data map[string]interface{}
 	cookie, err := r.Cookie(m.cookieName)
-	if errors.Is(err, http.ErrNoCookie) {
-		// Create new session
-		sessionID = generateSessionID()
-	} else if err != nil {
+	switch {
+	case errors.Is(err, http.ErrNoCookie):
+	case err != nil:
 		return nil, err
-	} else {
+	case isCookie:
+		// a cookie that can't be decrypted starts a new session
+		if data, err = cookieStore.Decode(m.cookieName, cookie.Value); err == nil {
+			sessionID, _ = data[cookieIDKey].(string)
+		}
+	case validID(cookie.Value):
 		sessionID = cookie.Value
 	}
 
+	// Block concurrent requests of the same session until Release
+	var unlock func()
+	if locker, ok := m.store.(Locker); ok && opts.Lock && validID(sessionID) {
+		if unlock, err = locker.Lock(sessionID, opts.LockTTL, opts.LockWait); err != nil {
+			return nil, err
+		}
+	}
+
 	// Get session data from store
-	data, err := m.store.Get(sessionID)
-	if err != nil {
-		return nil, err
+	if !isCookie && validID(sessionID) {
+		if data, err = m.store.Get(sessionID); err != nil {
+			if unlock != nil {
+				unlock()
+			}
+			return nil, err
+		}
+	}
+
+	now := time.Now()
+	if len(data) > 0 && opts.expired(data, now) {
+		if !isCookie {
+			_ = m.store.Delete(sessionID)
+		}
+		data = nil
+	}
+
+	isNew := len(data) == 0 || !validID(sessionID)
+	if isNew {
+		// Create new session
+		sessionID = generateSessionID()
+		data = map[string]interface{}{createdKey: now.Unix()}
 	}
 
-	// Create session
 	session := &session{
-		id:      sessionID,
-		data:    data,
-		store:   m.store,
-		written: false,
-	}
-
-	// Set cookie with 10 মিনিট expiration
-	http.SetCookie(w, &http.Cookie{
-		Name:     m.cookieName,
-		Value:    sessionID,
-		Path:     "/",
-		HttpOnly: true,
-		Secure:   true,
-		SameSite: http.SameSiteLaxMode,
-		Expires:  time.Now().Add(sessionExpiration),
-	})
+		id:       sessionID,
+		loadedID: sessionID,
+		data:     data,
+		changed:  make(map[string]bool),
+		store:    m.store,
+		manager:  m,
+		opts:     opts,
+		w:        w,
+		secure:   r.TLS != nil,
+		unlock:   unlock,
+		meta: Meta{
+			IPAddress: clientIP(r),
+			UserAgent: r.UserAgent(),
+		},
+	}
+
+	// new sessions get a cookie; existing ones only when the expiry must slide
+	if isNew || opts.needsTouch(data, now) {
+		session.Set(activityKey, now.Unix())
+	}
+	AgeFlashData(session)
+
+	// save just before headers are sent, the cookie can't be set later
+	if b, ok := w.(interface{ Before(func()) }); ok {
+		b.Before(func() {
+			_ = session.Save()
+			session.mu.Lock()
+			_ = session.writeCookie()
+			session.mu.Unlock()
+		})
+	} else if !isCookie {
+		_ = session.writeCookie()
+	}
 
 	return session, nil
 }
 
 type session struct {
-	id      string
-	data    map[string]interface{}
-	store   Store
-	written bool
-	mu      sync.Mutex
+	id         string
+	loadedID   string // ID the data was read under, merging needs the same ID
+	data       map[string]interface{}
+	changed    map[string]bool // keys set (true) or deleted (false) by this request
+	flushed    bool            // all data replaced, nothing to merge
+	store      Store
+	manager    *Manager
+	opts       Options
+	w          http.ResponseWriter
+	secure     bool // request came over TLS
+	meta       Meta
+	unlock     func()
+	written    bool // data changed since the last save
+	sendCookie bool // cookie must be (re)sent: new, modified or extended
+	mu         sync.Mutex
 }
 
 func (s *session) Get(key string) interface{} {
@@ -110,43 +176,180 @@ func (s *session) Set(key string, value interface{}) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	s.data[key] = value
+	s.changed[key] = true
 	s.written = true
+	s.sendCookie = true
 }
 
 func (s *session) Delete(key string) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	delete(s.data, key)
+	s.changed[key] = false
 	s.written = true
+	s.sendCookie = true
 }
 
 func (s *session) Save() error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	if s.written {
-		err := s.store.Save(s.id, s.data, sessionExpiration) // <-- Save 10 মিনিট
-		if err != nil {
+	if !s.written {
+		return nil
+	}
+
+	if _, ok := s.store.(cookieDriver); !ok && s.opts.MergeOnSave && !s.flushed && s.id == s.loadedID {
+		// re-read so writes of concurrent requests aren't overwritten
+		if fresh, err := s.store.Get(s.id); err == nil && len(fresh) > 0 {
+			s.data = mergeData(fresh, s.data, s.changed)
+		}
+	}
+
+	var err error
+	switch store := s.store.(type) {
+	case cookieDriver:
+		err = s.writeCookie()
+	case MetaStore:
+		meta := s.meta
+		meta.UserID = userID(s.data[UserIDKey])
+		err = store.SaveMeta(s.id, s.data, s.opts.Lifetime, meta)
+	default:
+		err = s.store.Save(s.id, s.data, s.opts.Lifetime)
+	}
+	if err != nil {
+		return err
+	}
+	s.written = false
+	clear(s.changed)
+	return nil
+}
+
+func (s *session) Release() error {
+	err := s.Save()
+	s.mu.Lock()
+	unlock := s.unlock
+	s.unlock = nil
+	s.mu.Unlock()
+	if unlock != nil {
+		unlock()
+	}
+	return err
+}
+
+// writeCookie sends the session cookie if it is new, modified or extended;
+// the cookie driver puts the whole encrypted session in it. s.mu must be held.
+func (s *session) writeCookie() error {
+	if !s.sendCookie {
+		return nil
+	}
+	if rw, ok := s.w.(interface{ Written() bool }); ok && rw.Written() {
+		return ErrHeadersSent
+	}
+	value := s.id
+	if store, ok := s.store.(cookieDriver); ok {
+		s.data[cookieIDKey] = s.id
+		var err error
+		if value, err = store.Encode(s.manager.cookieName, s.data, s.opts.Lifetime); err != nil {
 			return err
 		}
-		s.written = false
 	}
+	setCookie(s.w, s.opts.cookie(s.manager.cookieName, value, s.secure))
+	s.sendCookie = false
 	return nil
 }
 
 func (s *session) ID() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	return s.id
 }
 
+func (s *session) Regenerate(destroyOld bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.regenerate(destroyOld)
+}
+
+func (s *session) regenerate(destroyOld bool) error {
+	old := s.id
+	s.id = generateSessionID()
+	s.written = true
+	s.sendCookie = true
+	if _, ok := s.store.(cookieDriver); destroyOld && !ok {
+		return s.store.Delete(old)
+	}
+	return nil
+}
+
+func (s *session) Invalidate() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.flush()
+	return s.regenerate(true)
+}
+
+func (s *session) Flush() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.flush()
+}
+
+func (s *session) flush() {
+	now := time.Now().Unix()
+	s.data = map[string]interface{}{createdKey: now, activityKey: now}
+	s.flushed = true
+	s.written = true
+	s.sendCookie = true
+}
+
 func generateSessionID() string {
 	b := make([]byte, 32)
 	rand.Read(b)
 	return base64.URLEncoding.EncodeToString(b)
 }
 
+// validID accepts only IDs shaped like generateSessionID output, so a
+// crafted cookie can't reach outside the file or redis namespace
+func validID(id string) bool {
+	if len(id) != 44 {
+		return false
+	}
+	_, err := base64.URLEncoding.DecodeString(id)
+	return err == nil
+}
+
+// setCookie replaces an earlier Set-Cookie of the same name, so saving
+// twice doesn't send two cookies
+func setCookie(w http.ResponseWriter, c *http.Cookie) {
+	h := w.Header()
+	prefix := c.Name + "="
+	cookies := h.Values("Set-Cookie")
+	kept := cookies[:0:0]
+	for _, v := range cookies {
+		if !strings.HasPrefix(v, prefix) {
+			kept = append(kept, v)
+		}
+	}
+	h.Del("Set-Cookie")
+	for _, v := range kept {
+		h.Add("Set-Cookie", v)
+	}
+	http.SetCookie(w, c)
+}
+
+// clientIP returns the remote address without the port
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
 // Memory store implementation
 type MemoryStore struct {
 	sessions map[string]memorySession
+	locks    map[string]memoryLock
 	mu       sync.RWMutex
 }
 
@@ -158,6 +361,7 @@ type memorySession struct {
 func NewMemoryStore() *MemoryStore {
 	store := &MemoryStore{
 		sessions: make(map[string]memorySession),
+		locks:    make(map[string]memoryLock),
 	}
 
 	// Start cleanup goroutine
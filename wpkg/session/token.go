@@ -0,0 +1,27 @@
+// pkg/session/token.go
+package session
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+)
+
+// TokenKey is the session key of the CSRF token
+const TokenKey = "csrf_token"
+
+// Token returns the session's CSRF token, creating one if needed
+func Token(s Session) string {
+	if token, ok := s.Get(TokenKey).(string); ok && token != "" {
+		return token
+	}
+	return RegenerateToken(s)
+}
+
+// RegenerateToken issues a new CSRF token, e.g. after login or logout
+func RegenerateToken(s Session) string {
+	b := make([]byte, 30)
+	rand.Read(b)
+	token := base64.RawURLEncoding.EncodeToString(b)
+	s.Set(TokenKey, token)
+	return token
+}
@@ -0,0 +1,214 @@
+// pkg/validation/file_rules.go
+package validation
+
+import (
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/aasoft24/golara/wpkg/gola"
+	"github.com/aasoft24/golara/wpkg/helpers"
+	_ "golang.org/x/image/bmp"
+	_ "golang.org/x/image/webp"
+)
+
+// Image types accepted by the "image" rule (sniffed, not client supplied)
+var imageMimes = []string{"image/jpeg", "image/png", "image/gif", "image/bmp", "image/webp"}
+
+// asFile returns the uploaded file behind a validation value
+func asFile(value interface{}) (*gola.UploadedFile, bool) {
+	f, ok := value.(*gola.UploadedFile)
+	return f, ok && f.Exists()
+}
+
+// isEmptyUpload reports whether no file was sent for the field
+func isEmptyUpload(value interface{}) bool {
+	if value == nil {
+		return true
+	}
+	str, ok := value.(string)
+	return ok && strings.TrimSpace(str) == ""
+}
+
+func (v *Validator) validateFile(value interface{}) bool {
+	_, ok := asFile(value)
+	return ok || isEmptyUpload(value)
+}
+
+func (v *Validator) validateImage(value interface{}) bool {
+	if isEmptyUpload(value) {
+		return true
+	}
+	fh, ok := asFile(value)
+	if !ok {
+		return false
+	}
+	mimeType, err := fh.MIME()
+	if err != nil {
+		return false
+	}
+	for _, m := range imageMimes {
+		if m == mimeType {
+			return true
+		}
+	}
+	return false
+}
+
+// validateMimes checks the sniffed content against a list of extensions ("pdf,docx")
+func (v *Validator) validateMimes(value interface{}, ruleValue string) bool {
+	if isEmptyUpload(value) {
+		return true
+	}
+	fh, ok := asFile(value)
+	if !ok {
+		return false
+	}
+	mimeType, err := fh.MIME()
+	if err != nil {
+		return false
+	}
+	for _, ext := range strings.Split(ruleValue, ",") {
+		if helpers.MimeMatchesExtension(mimeType, strings.TrimSpace(ext)) {
+			return true
+		}
+	}
+	return false
+}
+
+// validateMimeTypes checks the sniffed content against MIME types ("image/png,image/*")
+func (v *Validator) validateMimeTypes(value interface{}, ruleValue string) bool {
+	if isEmptyUpload(value) {
+		return true
+	}
+	fh, ok := asFile(value)
+	if !ok {
+		return false
+	}
+	mimeType, err := fh.MIME()
+	if err != nil {
+		return false
+	}
+	for _, allowed := range strings.Split(ruleValue, ",") {
+		allowed = strings.TrimSpace(allowed)
+		if allowed == mimeType {
+			return true
+		}
+		if strings.HasSuffix(allowed, "/*") && strings.HasPrefix(mimeType, strings.TrimSuffix(allowed, "*")) {
+			return true
+		}
+	}
+	return false
+}
+
+// validateDimensions checks image size, e.g. "min_width=100,max_height=800,ratio=16/9"
+func (v *Validator) validateDimensions(value interface{}, ruleValue string) bool {
+	if isEmptyUpload(value) {
+		return true
+	}
+	fh, ok := asFile(value)
+	if !ok {
+		return false
+	}
+	src, err := fh.Open()
+	if err != nil {
+		return false
+	}
+	defer src.Close()
+
+	cfg, _, err := image.DecodeConfig(src)
+	if err != nil {
+		return false
+	}
+
+	for _, constraint := range strings.Split(ruleValue, ",") {
+		parts := strings.SplitN(strings.TrimSpace(constraint), "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		name, param := parts[0], parts[1]
+
+		if name == "ratio" {
+			if !matchesRatio(cfg.Width, cfg.Height, param) {
+				return false
+			}
+			continue
+		}
+
+		n := parseInt(param)
+		switch name {
+		case "width":
+			if cfg.Width != n {
+				return false
+			}
+		case "height":
+			if cfg.Height != n {
+				return false
+			}
+		case "min_width":
+			if cfg.Width < n {
+				return false
+			}
+		case "max_width":
+			if cfg.Width > n {
+				return false
+			}
+		case "min_height":
+			if cfg.Height < n {
+				return false
+			}
+		case "max_height":
+			if cfg.Height > n {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// matchesRatio compares width/height with "16/9" or "1.5"
+func matchesRatio(width, height int, ratio string) bool {
+	if height == 0 {
+		return false
+	}
+	var want float64
+	if num, den, found := strings.Cut(ratio, "/"); found {
+		n, err1 := strconv.ParseFloat(num, 64)
+		d, err2 := strconv.ParseFloat(den, 64)
+		if err1 != nil || err2 != nil || d == 0 {
+			return false
+		}
+		want = n / d
+	} else {
+		f, err := strconv.ParseFloat(ratio, 64)
+		if err != nil {
+			return false
+		}
+		want = f
+	}
+	return math.Abs(float64(width)/float64(height)-want) < 0.01
+}
+
+// fileSizeKB returns the upload size in kilobytes, as used by min/max on files
+func fileSizeKB(fh *gola.UploadedFile) int64 {
+	return int64(math.Ceil(float64(fh.Size()) / 1024))
+}
+
+// requestFiles returns the uploaded file(s) for a field of a multipart request
+func requestFiles(c *gola.Context, field string) (interface{}, bool) {
+	if !strings.HasPrefix(c.Request.Header.Get("Content-Type"), "multipart/form-data") {
+		return nil, false
+	}
+	files := c.Files(field)
+	switch len(files) {
+	case 0:
+		return nil, false
+	case 1:
+		return files[0], true
+	}
+	return files, true
+}
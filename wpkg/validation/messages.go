@@ -0,0 +1,232 @@
+// pkg/validation/messages.go
+package validation
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/aasoft24/golara/wpkg/gola"
+)
+
+// DefaultLocale is used when a validator has no locale or a message is missing
+var DefaultLocale = "en"
+
+var (
+	catalogsMu sync.RWMutex
+
+	// catalogs holds messages per locale, keyed by rule ("min", "min.file", "unique")
+	catalogs = map[string]map[string]string{
+		"en": {
+			"required":            "The :attribute field is required",
+			"email":               "The :attribute must be a valid email address",
+			"min":                 "The :attribute must be at least :min characters",
+			"min.file":            "The :attribute must be at least :min kilobytes",
+			"max":                 "The :attribute may not be greater than :max characters",
+			"max.file":            "The :attribute may not be greater than :max kilobytes",
+			"len":                 "The :attribute must be :size characters",
+			"numeric":             "The :attribute must be a number",
+			"same":                "The :attribute and :other must match",
+			"alpha":               "The :attribute may only contain letters",
+			"alpha_num":           "The :attribute may only contain letters and numbers",
+			"in":                  "The selected :attribute is invalid",
+			"not_in":              "The selected :attribute is invalid",
+			"regex":               "The :attribute format is invalid",
+			"unique":              "The :attribute has already been taken",
+			"unique_multi":        "The :attribute has already been taken",
+			"unique_except":       "The :attribute has already been taken",
+			"unique_multi_except": "The :attribute has already been taken",
+			"file":                "The :attribute must be a file",
+			"image":               "The :attribute must be an image",
+			"mimes":               "The :attribute must be a file of type: :values",
+			"mimetypes":           "The :attribute must be a file of type: :values",
+			"dimensions":          "The :attribute has invalid image dimensions",
+			"invalid":             "The :attribute is invalid",
+		},
+		"bn": {
+			"required":            ":attribute ফিল্ডটি আবশ্যক",
+			"email":               ":attribute অবশ্যই একটি বৈধ ইমেইল ঠিকানা হতে হবে",
+			"min":                 ":attribute কমপক্ষে :min অক্ষরের হতে হবে",
+			"min.file":            ":attribute কমপক্ষে :min কিলোবাইট হতে হবে",
+			"max":                 ":attribute :max অক্ষরের বেশি হতে পারবে না",
+			"max.file":            ":attribute :max কিলোবাইটের বেশি হতে পারবে না",
+			"len":                 ":attribute অবশ্যই :size অক্ষরের হতে হবে",
+			"numeric":             ":attribute অবশ্যই একটি সংখ্যা হতে হবে",
+			"same":                ":attribute এবং :other অবশ্যই মিলতে হবে",
+			"alpha":               ":attribute-এ শুধুমাত্র অক্ষর থাকতে পারবে",
+			"alpha_num":           ":attribute-এ শুধুমাত্র অক্ষর ও সংখ্যা থাকতে পারবে",
+			"in":                  "নির্বাচিত :attribute টি অবৈধ",
+			"not_in":              "নির্বাচিত :attribute টি অবৈধ",
+			"regex":               ":attribute এর ফরম্যাট অবৈধ",
+			"unique":              ":attribute ইতিমধ্যে ব্যবহৃত হয়েছে",
+			"unique_multi":        ":attribute ইতিমধ্যে ব্যবহৃত হয়েছে",
+			"unique_except":       ":attribute ইতিমধ্যে ব্যবহৃত হয়েছে",
+			"unique_multi_except": ":attribute ইতিমধ্যে ব্যবহৃত হয়েছে",
+			"file":                ":attribute অবশ্যই একটি ফাইল হতে হবে",
+			"image":               ":attribute অবশ্যই একটি ছবি হতে হবে",
+			"mimes":               ":attribute অবশ্যই :values ধরনের ফাইল হতে হবে",
+			"mimetypes":           ":attribute অবশ্যই :values ধরনের ফাইল হতে হবে",
+			"dimensions":          ":attribute এর ছবির মাপ অবৈধ",
+			"invalid":             ":attribute অবৈধ",
+		},
+	}
+
+	// attributes holds friendly field names per locale
+	attributes = map[string]map[string]string{}
+)
+
+// AddMessages merges rule messages into a locale catalog
+func AddMessages(locale string, messages map[string]string) {
+	catalogsMu.Lock()
+	defer catalogsMu.Unlock()
+	if catalogs[locale] == nil {
+		catalogs[locale] = make(map[string]string)
+	}
+	for k, msg := range messages {
+		catalogs[locale][k] = msg
+	}
+}
+
+// SetAttributes registers friendly attribute names for a locale
+func SetAttributes(locale string, names map[string]string) {
+	catalogsMu.Lock()
+	defer catalogsMu.Unlock()
+	if attributes[locale] == nil {
+		attributes[locale] = make(map[string]string)
+	}
+	for field, name := range names {
+		attributes[locale][field] = name
+	}
+}
+
+// HasLocale reports whether a message catalog exists for locale
+func HasLocale(locale string) bool {
+	catalogsMu.RLock()
+	defer catalogsMu.RUnlock()
+	_, ok := catalogs[locale]
+	return ok
+}
+
+func catalogMessage(locale, key string) (string, bool) {
+	catalogsMu.RLock()
+	defer catalogsMu.RUnlock()
+	msg, ok := catalogs[locale][key]
+	return msg, ok
+}
+
+func (v *Validator) locale() string {
+	if v.Locale != "" {
+		return v.Locale
+	}
+	return DefaultLocale
+}
+
+// attributeName returns the friendly name of a field
+func (v *Validator) attributeName(field string) string {
+	if name, ok := v.Attributes[field]; ok {
+		return name
+	}
+	catalogsMu.RLock()
+	defer catalogsMu.RUnlock()
+	if name, ok := attributes[v.locale()][field]; ok {
+		return name
+	}
+	if name, ok := attributes[DefaultLocale][field]; ok {
+		return name
+	}
+	return strings.ReplaceAll(field, "_", " ")
+}
+
+// param is a named message placeholder (":min"); older "%s" messages take
+// the values in the order they are passed
+type param struct {
+	name, value string
+}
+
+// fail adds the catalog message for a built-in rule
+func (v *Validator) fail(field, rule string, params ...param) {
+	v.failWith(field, rule, "", params)
+}
+
+// failWith resolves and adds an error message. Lookup order:
+// CustomMessages["field.rule"], CustomMessages["rule"], the locale catalog,
+// fallback, then the DefaultLocale catalog.
+func (v *Validator) failWith(field, rule, fallback string, params []param) {
+	message, found := "", false
+
+	if rule != "" {
+		base := strings.SplitN(rule, ".", 2)[0]
+		for _, key := range []string{field + "." + rule, field + "." + base, rule} {
+			if msg, ok := v.CustomMessages[key]; ok {
+				message, found = msg, true
+				break
+			}
+		}
+		if !found {
+			message, found = catalogMessage(v.locale(), rule)
+		}
+	}
+	if !found && fallback != "" {
+		message, found = fallback, true
+	}
+	if !found && rule != "" {
+		message, found = catalogMessage(DefaultLocale, rule)
+	}
+	if !found {
+		if msg, ok := catalogMessage(v.locale(), "invalid"); ok {
+			message = msg
+		} else {
+			message, _ = catalogMessage(DefaultLocale, "invalid")
+		}
+	}
+
+	attribute := v.attributeName(field)
+
+	// Older custom messages used fmt verbs: "The %s must be at least %s characters"
+	if strings.Contains(message, "%s") {
+		args := []interface{}{attribute}
+		for _, p := range params {
+			args = append(args, p.value)
+		}
+		if n := strings.Count(message, "%s"); n < len(args) {
+			args = args[:n]
+		}
+		message = fmt.Sprintf(message, args...)
+	}
+
+	v.Errors[field] = append(v.Errors[field], replacePlaceholders(message, attribute, params))
+}
+
+// replacePlaceholders swaps :attribute and :param placeholders, longest names first
+func replacePlaceholders(message, attribute string, params []param) string {
+	sorted := append([]param(nil), params...)
+	sort.SliceStable(sorted, func(i, j int) bool { return len(sorted[i].name) > len(sorted[j].name) })
+
+	pairs := []string{":attribute", attribute}
+	for _, p := range sorted {
+		pairs = append(pairs, ":"+p.name, p.value)
+	}
+	return strings.NewReplacer(pairs...).Replace(message)
+}
+
+// RequestLocale picks the validation locale for a request: a "locale" value set
+// by middleware first, then the first Accept-Language tag with a catalog.
+func RequestLocale(c *gola.Context) string {
+	if locale := c.Locale(); locale != "" {
+		return locale
+	}
+	for _, part := range strings.Split(c.Request.Header.Get("Accept-Language"), ",") {
+		tag := strings.TrimSpace(strings.SplitN(part, ";", 2)[0])
+		if tag == "" {
+			continue
+		}
+		if HasLocale(tag) {
+			return tag
+		}
+		if base := strings.SplitN(tag, "-", 2)[0]; HasLocale(base) {
+			return base
+		}
+	}
+	return DefaultLocale
+}
@@ -0,0 +1,35 @@
+// pkg/validation/messages_test.go
+package validation
+
+import "testing"
+
+func TestFailWithMessages(t *testing.T) {
+	tests := []struct {
+		name     string
+		custom   map[string]string
+		rule     string
+		fallback string
+		params   []param
+		want     string
+	}{
+		{"catalog", nil, "min", "", []param{{"min", "3"}}, "The user name must be at least 3 characters"},
+		{"field custom", map[string]string{"user_name.min": "Too short"}, "min", "", []param{{"min", "3"}}, "Too short"},
+		{"legacy verbs", map[string]string{"min": "The %s needs %s characters"}, "min", "", []param{{"min", "3"}}, "The user name needs 3 characters"},
+		{"legacy verbs in order", nil, "", "%s: %s to %s, not %s", []param{{"min", "1"}, {"max", "9"}, {"other", "0"}}, "user name: 1 to 9, not 0"},
+		{"longest placeholder first", nil, "", ":attribute :max_size :max", []param{{"max", "1"}, {"max_size", "2"}}, "user name 2 1"},
+		{"unknown rule", nil, "nope", "", nil, "The user name is invalid"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// params came from a map before, so repeat to catch random order
+			for i := 0; i < 20; i++ {
+				v := NewValidator(nil, nil)
+				v.CustomMessages = tt.custom
+				v.failWith("user_name", tt.rule, tt.fallback, tt.params)
+				if got := v.Errors["user_name"][0]; got != tt.want {
+					t.Fatalf("message = %q, want %q", got, tt.want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+// pkg/validation/rules.go
+package validation
+
+import (
+	"sync"
+)
+
+// RuleFunc is the signature of rules registered through Extend.
+// params holds the comma separated values after the colon ("digits_between:4,6").
+type RuleFunc func(v *Validator, field string, value interface{}, params []string) bool
+
+// Rule is an object rule that can carry its own dependencies,
+// e.g. a phone rule that reads the country code from another field via v.Data.
+type Rule interface {
+	Passes(v *Validator, field string, value interface{}) bool
+	Message() string
+}
+
+// ImplicitRule is a Rule that also runs when the field is absent
+type ImplicitRule interface {
+	Rule
+	Implicit() bool
+}
+
+// ClosureRule is an inline rule; call fail with a message to reject the value
+type ClosureRule func(field string, value interface{}, fail func(message string))
+
+// ImplicitClosureRule is a ClosureRule that also runs when the field is absent
+// (value is then nil), e.g. "required unless another field is set"
+type ImplicitClosureRule func(field string, value interface{}, fail func(message string))
+
+type extension struct {
+	fn       RuleFunc
+	message  string
+	implicit bool
+}
+
+var (
+	extensions   = map[string]extension{}
+	extensionsMu sync.RWMutex
+)
+
+// Extend registers a global rule usable in rule strings, e.g. "phone:BD".
+// message may use :attribute and :params placeholders and can be
+// translated per locale with AddMessages.
+func Extend(name string, fn RuleFunc, message string) {
+	extensionsMu.Lock()
+	defer extensionsMu.Unlock()
+	extensions[name] = extension{fn: fn, message: message}
+}
+
+// ExtendImplicit registers a global rule that runs even when the field is absent
+func ExtendImplicit(name string, fn RuleFunc, message string) {
+	extensionsMu.Lock()
+	defer extensionsMu.Unlock()
+	extensions[name] = extension{fn: fn, message: message, implicit: true}
+}
+
+func getExtension(name string) (extension, bool) {
+	extensionsMu.RLock()
+	defer extensionsMu.RUnlock()
+	ext, ok := extensions[name]
+	return ext, ok
+}
+
+// applyObjectRule runs a Rule object against a value
+func (v *Validator) applyObjectRule(field string, rule Rule, value interface{}, exists bool) {
+	if !exists {
+		implicit, ok := rule.(ImplicitRule)
+		if !ok || !implicit.Implicit() {
+			return
+		}
+	}
+	if !rule.Passes(v, field, value) {
+		v.failWith(field, "", rule.Message(), nil)
+	}
+}
+
+// applyClosureRule runs an inline closure rule against a value; implicit
+// closures also run for absent fields
+func (v *Validator) applyClosureRule(field string, rule ClosureRule, value interface{}, exists, implicit bool) {
+	if !exists && !implicit {
+		return
+	}
+	rule(field, value, func(message string) {
+		v.failWith(field, "", message, nil)
+	})
+}
@@ -1,7 +1,10 @@
 package validation
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
+	"io"
 	"regexp"
 	"strings"
 	"unicode/utf8"
@@ -18,7 +21,9 @@ type Validator struct {
 	Data           map[string]interface{}
 	Errors         map[string][]string
 	DB             *gorm.DB
-	CustomMessages map[string]string // Custom error messages
+	CustomMessages map[string]string // Custom error messages ("email.unique" or "unique")
+	Attributes     map[string]string // Friendly field names, e.g. "dob" => "date of birth"
+	Locale         string            // Message catalog locale, defaults to DefaultLocale
 }
 
 // Create new validator
@@ -33,13 +38,24 @@ func NewValidator(data map[string]interface{}, db *gorm.DB) *Validator {
 // Validate function with Laravel-style rules
 
 func (v *Validator) Validate(rules map[string]string, customMessages ...map[string]string) bool {
+	ruleSet := make(map[string][]interface{}, len(rules))
+	for field, ruleStr := range rules {
+		for _, rule := range strings.Split(ruleStr, "|") {
+			ruleSet[field] = append(ruleSet[field], rule)
+		}
+	}
+	return v.ValidateRules(ruleSet, customMessages...)
+}
+
+// ValidateRules validates a rule set where string rules ("required", "min:3"),
+// Rule objects and ClosureRule funcs can be mixed for the same field.
+func (v *Validator) ValidateRules(rules map[string][]interface{}, customMessages ...map[string]string) bool {
 	// Set custom messages if provided
 	if len(customMessages) > 0 {
 		v.CustomMessages = customMessages[0]
 	}
 
-	for field, ruleStr := range rules {
-		rulesArr := strings.Split(ruleStr, "|")
+	for field, rulesArr := range rules {
 		value, exists := v.Data[field]
 
 		// Handle []string values
@@ -49,6 +65,10 @@ func (v *Validator) Validate(rules map[string]string, customMessages ...map[stri
 			for _, v := range val {
 				values = append(values, v)
 			}
+		case []*gola.UploadedFile:
+			for _, fh := range val {
+				values = append(values, fh)
+			}
 		case []interface{}:
 			values = val
 		default:
@@ -58,100 +78,152 @@ func (v *Validator) Validate(rules map[string]string, customMessages ...map[stri
 		// Validate each value (for multi or single)
 		for _, singleVal := range values {
 			for _, rule := range rulesArr {
-				if rule == "" {
-					continue
+				switch r := rule.(type) {
+				case string:
+					v.applyStringRule(field, r, singleVal, exists)
+				case Rule:
+					v.applyObjectRule(field, r, singleVal, exists)
+				case ClosureRule:
+					v.applyClosureRule(field, r, singleVal, exists, false)
+				case ImplicitClosureRule:
+					v.applyClosureRule(field, ClosureRule(r), singleVal, exists, true)
+				case func(string, interface{}, func(string)):
+					v.applyClosureRule(field, r, singleVal, exists, false)
 				}
+			}
+		}
+	}
 
-				// Check for sometimes rule
-				if rule == "sometimes" {
-					if !exists {
-						continue
-					}
-					continue
-				}
+	return len(v.Errors) == 0
+}
 
-				parts := strings.SplitN(rule, ":", 2)
-				ruleName := parts[0]
-				ruleValue := ""
-				if len(parts) > 1 {
-					ruleValue = parts[1]
-				}
+// applyStringRule runs a single "name:params" rule against a value
+func (v *Validator) applyStringRule(field, rule string, singleVal interface{}, exists bool) {
+	if rule == "" {
+		return
+	}
 
-				if !exists && ruleName != "required" {
-					continue
-				}
+	// Check for sometimes rule
+	if rule == "sometimes" {
+		return
+	}
 
-				switch ruleName {
-				case "required":
-					if !v.validateRequired(singleVal) {
-						v.addError(field, "The %s field is required", field)
-					}
-				case "email":
-					if exists && !v.validateEmail(singleVal) {
-						v.addError(field, "The %s must be a valid email address", field)
-					}
-				case "min":
-					if exists && !v.validateMin(singleVal, ruleValue) {
-						v.addError(field, "The %s must be at least %s characters", field, ruleValue)
-					}
-				case "max":
-					if exists && !v.validateMax(singleVal, ruleValue) {
-						v.addError(field, "The %s may not be greater than %s characters", field, ruleValue)
-					}
-				case "len":
-					if exists && !v.validateLen(singleVal, ruleValue) {
-						v.addError(field, "The %s must be %s characters", field, ruleValue)
-					}
-				case "numeric":
-					if exists && !v.validateNumeric(singleVal) {
-						v.addError(field, "The %s must be a number", field)
-					}
-				case "same":
-					if exists && !v.validateSame(singleVal, v.Data[ruleValue]) {
-						v.addError(field, "The %s and %s must match", field, ruleValue)
-					}
-				case "alpha":
-					if exists && !v.validateAlpha(fmt.Sprintf("%v", singleVal)) {
-						v.addError(field, "The %s may only contain letters", field)
-					}
-				case "alpha_num":
-					if exists && !v.validateAlphaNum(fmt.Sprintf("%v", singleVal)) {
-						v.addError(field, "The %s may only contain letters and numbers", field)
-					}
-				case "in":
-					if exists && !v.validateIn(singleVal, ruleValue) {
-						v.addError(field, "The selected %s is invalid", field)
-					}
-				case "not_in":
-					if exists && !v.validateNotIn(singleVal, ruleValue) {
-						v.addError(field, "The selected %s is invalid", field)
-					}
-				case "regex":
-					if exists && !v.validateRegex(singleVal, ruleValue) {
-						v.addError(field, "The %s format is invalid", field)
-					}
-				case "unique":
-					if exists && !v.validateUnique(singleVal, ruleValue) {
-						v.addError(field, "The %s has already been taken", field)
-					}
-				case "unique_multi":
-					if exists && !v.validateUniqueMulti(singleVal, ruleValue) {
-						v.addError(field, "The %s has already been taken", field)
-					}
-				case "unique_except":
-					if exists && !v.validateUniqueExcept(singleVal, ruleValue) {
-						v.addError(field, "The %s has already been taken", field)
-					}
-				case "unique_multi_except":
-					if exists && !v.validateUniqueMultiExcept(singleVal, ruleValue) {
-						v.addError(field, "The %s has already been taken", field)
-					}
-				}
-			}
+	parts := strings.SplitN(rule, ":", 2)
+	ruleName := parts[0]
+	ruleValue := ""
+	if len(parts) > 1 {
+		ruleValue = parts[1]
+	}
+
+	// Rules registered through Extend
+	if ext, ok := getExtension(ruleName); ok {
+		if !exists && !ext.implicit {
+			return
 		}
+		params := []string{}
+		if ruleValue != "" {
+			params = strings.Split(ruleValue, ",")
+		}
+		if !ext.fn(v, field, singleVal, params) {
+			v.failWith(field, ruleName, ext.message, []param{{"params", strings.Join(params, ", ")}})
+		}
+		return
 	}
 
-	return len(v.Errors) == 0
+	if !exists && ruleName != "required" {
+		return
+	}
+
+	switch ruleName {
+	case "required":
+		if !v.validateRequired(singleVal) {
+			v.fail(field, "required")
+		}
+	case "email":
+		if exists && !v.validateEmail(singleVal) {
+			v.fail(field, "email")
+		}
+	case "min":
+		if _, isFile := asFile(singleVal); isFile && !v.validateMin(singleVal, ruleValue) {
+			v.fail(field, "min.file", param{"min", ruleValue})
+		} else if !isFile && exists && !v.validateMin(singleVal, ruleValue) {
+			v.fail(field, "min", param{"min", ruleValue})
+		}
+	case "max":
+		if _, isFile := asFile(singleVal); isFile && !v.validateMax(singleVal, ruleValue) {
+			v.fail(field, "max.file", param{"max", ruleValue})
+		} else if !isFile && exists && !v.validateMax(singleVal, ruleValue) {
+			v.fail(field, "max", param{"max", ruleValue})
+		}
+	case "len":
+		if exists && !v.validateLen(singleVal, ruleValue) {
+			v.fail(field, "len", param{"size", ruleValue})
+		}
+	case "numeric":
+		if exists && !v.validateNumeric(singleVal) {
+			v.fail(field, "numeric")
+		}
+	case "same":
+		if exists && !v.validateSame(singleVal, v.Data[ruleValue]) {
+			v.fail(field, "same", param{"other", v.attributeName(ruleValue)})
+		}
+	case "alpha":
+		if exists && !v.validateAlpha(fmt.Sprintf("%v", singleVal)) {
+			v.fail(field, "alpha")
+		}
+	case "alpha_num":
+		if exists && !v.validateAlphaNum(fmt.Sprintf("%v", singleVal)) {
+			v.fail(field, "alpha_num")
+		}
+	case "in":
+		if exists && !v.validateIn(singleVal, ruleValue) {
+			v.fail(field, "in")
+		}
+	case "not_in":
+		if exists && !v.validateNotIn(singleVal, ruleValue) {
+			v.fail(field, "not_in")
+		}
+	case "regex":
+		if exists && !v.validateRegex(singleVal, ruleValue) {
+			v.fail(field, "regex")
+		}
+	case "unique":
+		if exists && !v.validateUnique(singleVal, ruleValue) {
+			v.fail(field, "unique")
+		}
+	case "unique_multi":
+		if exists && !v.validateUniqueMulti(singleVal, ruleValue) {
+			v.fail(field, "unique_multi")
+		}
+	case "unique_except":
+		if exists && !v.validateUniqueExcept(singleVal, ruleValue) {
+			v.fail(field, "unique_except")
+		}
+	case "unique_multi_except":
+		if exists && !v.validateUniqueMultiExcept(singleVal, ruleValue) {
+			v.fail(field, "unique_multi_except")
+		}
+	case "file":
+		if exists && !v.validateFile(singleVal) {
+			v.fail(field, "file")
+		}
+	case "image":
+		if exists && !v.validateImage(singleVal) {
+			v.fail(field, "image")
+		}
+	case "mimes":
+		if exists && !v.validateMimes(singleVal, ruleValue) {
+			v.fail(field, "mimes", param{"values", ruleValue})
+		}
+	case "mimetypes":
+		if exists && !v.validateMimeTypes(singleVal, ruleValue) {
+			v.fail(field, "mimetypes", param{"values", ruleValue})
+		}
+	case "dimensions":
+		if exists && !v.validateDimensions(singleVal, ruleValue) {
+			v.fail(field, "dimensions")
+		}
+	}
 }
 
 // func (v *Validator) Validate(rules map[string]string, customMessages ...map[string]string) bool {
@@ -283,6 +355,8 @@ func (v *Validator) validateEmail(value interface{}) bool {
 func (v *Validator) validateMin(value interface{}, minStr string) bool {
 	min := parseInt(minStr)
 	switch val := value.(type) {
+	case *gola.UploadedFile:
+		return fileSizeKB(val) >= int64(min)
 	case string:
 		return utf8.RuneCountInString(val) >= min
 	case int:
@@ -297,6 +371,8 @@ func (v *Validator) validateMin(value interface{}, minStr string) bool {
 func (v *Validator) validateMax(value interface{}, maxStr string) bool {
 	max := parseInt(maxStr)
 	switch val := value.(type) {
+	case *gola.UploadedFile:
+		return fileSizeKB(val) <= int64(max)
 	case string:
 		return utf8.RuneCountInString(val) <= max
 	case int:
@@ -497,18 +573,6 @@ func (v *Validator) validateUniqueMultiExcept(value interface{}, ruleValue strin
 	return true
 }
 
-// Add error
-func (v *Validator) addError(field, format string, args ...interface{}) {
-	// Custom message check korun
-	key := field + "." + strings.Split(format, " ")[0] // field.required, field.email, etc.
-	if customMsg, exists := v.CustomMessages[key]; exists {
-		message := fmt.Sprintf(customMsg, args...)
-		v.Errors[field] = append(v.Errors[field], message)
-	} else {
-		message := fmt.Sprintf(format, args...)
-		v.Errors[field] = append(v.Errors[field], message)
-	}
-}
 func (v *Validator) GetErrors() map[string][]string {
 	return v.Errors
 }
@@ -544,25 +608,76 @@ func notwork(c *gola.Context, db *gorm.DB, rules map[string]string) (map[string]
 	return nil, old
 }
 
+// requestField copies field into data (and its old input into old, if
+// given) when the request carries it: uploaded files, a JSON body value,
+// repeated form values or a single form, query or route value. Absent fields
+// are left out, so only required and implicit rules run for them.
+func requestField(c *gola.Context, field string, data map[string]interface{}, old map[string]string) {
+	// Uploaded files are validated as *gola.UploadedFile
+	if files, ok := requestFiles(c, field); ok {
+		data[field] = files
+		return
+	}
+	if body, ok := jsonBody(c); ok {
+		if value, ok := body[field]; ok {
+			data[field] = value
+			if old != nil && value != nil {
+				old[field] = fmt.Sprintf("%v", value)
+			}
+		}
+		return
+	}
+	if !c.HasInput(field) {
+		return
+	}
+	// 🔹 Check if field has multiple values
+	if arr := c.PostFormArray(field); len(arr) > 0 {
+		data[field] = arr
+		if old != nil {
+			old[field] = strings.Join(arr, ",") // old value as comma-separated string
+		}
+		return
+	}
+	value := c.Input(field)
+	data[field] = value
+	if old != nil {
+		old[field] = value
+	}
+}
+
+// jsonBodyKey caches the decoded JSON object of the request
+var jsonBodyKey = gola.NewKey[map[string]interface{}]("validation.json_body")
+
+// jsonBody decodes a JSON object body once and puts the bytes back, so
+// handlers can still BindJSON. ok is false for other content types.
+func jsonBody(c *gola.Context) (map[string]interface{}, bool) {
+	if !strings.HasPrefix(c.Request.Header.Get("Content-Type"), "application/json") {
+		return nil, false
+	}
+	if body, ok := gola.GetValue(c, jsonBodyKey); ok {
+		return body, true
+	}
+	raw, err := io.ReadAll(c.Request.Body)
+	c.Request.Body = io.NopCloser(bytes.NewReader(raw))
+	body := map[string]interface{}{}
+	if err == nil && len(raw) > 0 {
+		_ = json.Unmarshal(raw, &body)
+	}
+	gola.SetValue(c, jsonBodyKey, body)
+	return body, true
+}
+
 func ValidateRequest(c *gola.Context, rules map[string]string) (map[string]string, map[string]string) {
 	data := make(map[string]interface{})
 	old := make(map[string]string)
 
 	for field := range rules {
-		// 🔹 Check if field has multiple values
-		if arr := c.PostFormArray(field); len(arr) > 0 {
-			data[field] = arr
-			old[field] = strings.Join(arr, ",") // old value as comma-separated string
-		} else {
-			// fallback single value
-			value := c.Input(field)
-			data[field] = value
-			old[field] = value
-		}
+		requestField(c, field, data, old)
 	}
 
 	db := database.DB
 	v := NewValidator(data, db)
+	v.Locale = RequestLocale(c)
 	errors := make(map[string]string)
 
 	if !v.Validate(rules) {
@@ -608,14 +723,13 @@ func ValidateCustom(c *gola.Context, rules map[string]string, customMessages map
 	old := make(map[string]string)
 
 	for field := range rules {
-		value := c.Input(field)
-		data[field] = value
-		old[field] = value
+		requestField(c, field, data, old)
 	}
 
 	db := database.DB
 
 	v := NewValidator(data, db)
+	v.Locale = RequestLocale(c)
 	errors := make(map[string]string)
 
 	if !v.Validate(rules, customMessages) {
@@ -632,17 +746,12 @@ func ValidateCustom(c *gola.Context, rules map[string]string, customMessages map
 func ValidateRequestJSON(c *gola.Context, rules map[string]string) bool {
 	data := make(map[string]interface{})
 	for field := range rules {
-		// প্রথমে check করো multi value
-		if arr := c.PostFormArray(field); len(arr) > 0 {
-			data[field] = arr
-		} else {
-			// fallback single value
-			data[field] = c.Input(field)
-		}
+		requestField(c, field, data, nil)
 	}
 
 	db := database.DB
 	v := NewValidator(data, db)
+	v.Locale = RequestLocale(c)
 	if !v.Validate(rules) {
 		c.JSON(422, map[string]interface{}{
 			"errors":  v.GetErrors(),
@@ -698,3 +807,27 @@ func ValidateRequestJSON(c *gola.Context, rules map[string]string) bool {
 // 	}
 // 	return true
 // }
+
+// ValidateRequestRules is ValidateRequest for rule sets mixing strings, Rule objects and closures
+func ValidateRequestRules(c *gola.Context, rules map[string][]interface{}) (map[string]string, map[string]string) {
+	data := make(map[string]interface{})
+	old := make(map[string]string)
+
+	for field := range rules {
+		requestField(c, field, data, old)
+	}
+
+	v := NewValidator(data, database.DB)
+	v.Locale = RequestLocale(c)
+	errors := make(map[string]string)
+
+	if !v.ValidateRules(rules) {
+		for field, msgs := range v.GetErrors() {
+			if len(msgs) > 0 {
+				errors[field] = msgs[0]
+			}
+		}
+	}
+
+	return errors, old
+}
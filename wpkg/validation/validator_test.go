@@ -0,0 +1,125 @@
+// pkg/validation/validator_test.go
+package validation
+
+import (
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/aasoft24/golara/wpkg/gola"
+)
+
+func init() {
+	Extend("even", func(v *Validator, field string, value interface{}, params []string) bool {
+		n, err := strconv.Atoi(value.(string))
+		return err == nil && n%2 == 0
+	}, "The :attribute must be even")
+	ExtendImplicit("present_with", func(v *Validator, field string, value interface{}, params []string) bool {
+		_, other := v.Data[params[0]]
+		return value != nil || !other
+	}, "The :attribute is needed with :params")
+}
+
+func TestValidateRules(t *testing.T) {
+	closure := ClosureRule(func(field string, value interface{}, fail func(string)) {
+		if value != "ok" {
+			fail("The " + field + " is not ok")
+		}
+	})
+	implicitClosure := ImplicitClosureRule(func(field string, value interface{}, fail func(string)) {
+		if value == nil {
+			fail("The " + field + " is missing")
+		}
+	})
+
+	tests := []struct {
+		name  string
+		data  map[string]interface{}
+		rules map[string][]interface{}
+		want  string // first error of the field, "" for valid
+	}{
+		{"extend passes", map[string]interface{}{"n": "4"}, map[string][]interface{}{"n": {"even"}}, ""},
+		{"extend fails", map[string]interface{}{"n": "3"}, map[string][]interface{}{"n": {"even"}}, "The n must be even"},
+		{"extend skips absent", nil, map[string][]interface{}{"n": {"even"}}, ""},
+		{"implicit runs for absent", map[string]interface{}{"a": "1"}, map[string][]interface{}{"b": {"present_with:a"}}, "The b is needed with a"},
+		{"implicit passes", nil, map[string][]interface{}{"b": {"present_with:a"}}, ""},
+		{"required absent", nil, map[string][]interface{}{"n": {"required"}}, "The n field is required"},
+		{"rule skips absent", nil, map[string][]interface{}{"mail": {"email"}}, ""},
+		{"rule checks empty", map[string]interface{}{"mail": ""}, map[string][]interface{}{"mail": {"email"}}, "The mail must be a valid email address"},
+		{"closure passes", map[string]interface{}{"c": "ok"}, map[string][]interface{}{"c": {closure}}, ""},
+		{"closure fails", map[string]interface{}{"c": "no"}, map[string][]interface{}{"c": {closure}}, "The c is not ok"},
+		{"closure skips absent", nil, map[string][]interface{}{"c": {closure}}, ""},
+		{"implicit closure runs for absent", nil, map[string][]interface{}{"c": {implicitClosure}}, "The c is missing"},
+		{"string, extend and closure rules", map[string]interface{}{"n": "5"}, map[string][]interface{}{"n": {"required", "even", closure}}, "The n must be even"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := tt.data
+			if data == nil {
+				data = map[string]interface{}{}
+			}
+			v := NewValidator(data, nil)
+			v.ValidateRules(tt.rules)
+			for field := range tt.rules {
+				got := ""
+				if msgs := v.Errors[field]; len(msgs) > 0 {
+					got = msgs[0]
+				}
+				if got != tt.want {
+					t.Fatalf("%s error = %q, want %q", field, got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateRequestPresence(t *testing.T) {
+	rules := map[string]string{
+		"name":     "required|alpha",
+		"nickname": "alpha",
+		"email":    "email",
+		"age":      "numeric",
+	}
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		query       string
+		want        map[string]string // field => error; fields not listed must pass
+	}{
+		{
+			name:        "form",
+			contentType: "application/x-www-form-urlencoded",
+			body:        url.Values{"name": {"Ann"}, "email": {""}}.Encode(),
+			want:        map[string]string{"email": "The email must be a valid email address"},
+		},
+		{
+			name:        "query",
+			contentType: "application/x-www-form-urlencoded",
+			query:       "name=Ann&age=x",
+			want:        map[string]string{"age": "The age must be a number"},
+		},
+		{
+			name:        "json",
+			contentType: "application/json",
+			body:        `{"nickname": "b0b", "age": 30}`,
+			want:        map[string]string{"name": "The name field is required", "nickname": "The nickname may only contain letters"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("POST", "/?"+tt.query, strings.NewReader(tt.body))
+			r.Header.Set("Content-Type", tt.contentType)
+			c := gola.NewContext(httptest.NewRecorder(), r)
+
+			errs, _ := ValidateRequest(c, rules)
+			for field := range rules {
+				if errs[field] != tt.want[field] {
+					t.Errorf("%s error = %q, want %q", field, errs[field], tt.want[field])
+				}
+			}
+		})
+	}
+}
@@ -3,63 +3,44 @@ package view
 
 import (
 	"html/template"
-	"net/http"
 
-	"github.com/aasoft24/golara/wpkg/helpers"
 	"github.com/aasoft24/golara/wpkg/session"
 )
 
-// CSRF functions for templates
-func CSRFunctions() template.FuncMap {
+// CSRFunctions returns the csrf template funcs for a session. Without a
+// session (while parsing) they render an empty token; gola binds them to
+// the request session before rendering.
+func CSRFunctions(s session.Session) template.FuncMap {
 	return template.FuncMap{
 		"csrf": func() string {
-			return helpers.GenerateRandomString(32)
+			return CSRFToken(s)
 		},
 		"csrf_field": func() template.HTML {
-			token := helpers.GenerateRandomString(32)
-			return template.HTML(`<input type="hidden" name="_token" value="` + token + `">`)
+			return CSRFField(s)
 		},
 		"csrf_meta": func() template.HTML {
-			token := helpers.GenerateRandomString(32)
-			return template.HTML(`<meta name="csrf-token" content="` + token + `">`)
+			return CSRFMetaTag(s)
 		},
 	}
 }
 
-// CSRF token generate korar function
-func CSRFToken(r *http.Request, w http.ResponseWriter) string {
-	// Session manager create korun
-	mgr := session.NewManager(session.NewMemoryStore(), "myapp-session")
-
-	// Session start korun
-	sess, err := mgr.Start(w, r)
-	if err != nil {
+// CSRFToken returns the session's CSRF token, creating it on first use
+func CSRFToken(s session.Session) string {
+	if s == nil {
 		return ""
 	}
-
-	// Existing token check korun
-	token := sess.Get("csrf_token")
-	if token != nil {
-		return token.(string)
-	}
-
-	// New token generate korun
-	newToken := helpers.GenerateRandomString(32)
-	sess.Set("csrf_token", newToken)
-	sess.Save()
-
-	return newToken
+	return session.Token(s)
 }
 
 // CSRF hidden field
-func CSRFField(r *http.Request, w http.ResponseWriter) template.HTML {
-	token := CSRFToken(r, w)
+func CSRFField(s session.Session) template.HTML {
+	token := template.HTMLEscapeString(CSRFToken(s))
 	return template.HTML(`<input type="hidden" name="_token" value="` + token + `">`)
 }
 
 // CSRF meta tag
-func CSRFMetaTag(r *http.Request, w http.ResponseWriter) template.HTML {
-	token := CSRFToken(r, w)
+func CSRFMetaTag(s session.Session) template.HTML {
+	token := template.HTMLEscapeString(CSRFToken(s))
 	return template.HTML(`<meta name="csrf-token" content="` + token + `">`)
 }
 
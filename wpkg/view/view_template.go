@@ -9,6 +9,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 )
 
 // ----------------------------
@@ -19,13 +20,80 @@ import (
 var templatesFS embed.FS
 
 type TemplateEngine struct {
-	templates     map[string]*template.Template
+	templates     map[string]*template.Template // parsed views, only cloned, never executed
+	views         *boundViews                   // this engine's clones, the ones executed
 	DefaultLayout string
 	viewsPath     string
 	useEmbed      bool
 	request       *http.Request
 	response      http.ResponseWriter
 	stacks        map[string][]template.HTML // push/stack system
+	renderFuncs   template.FuncMap           // request-bound funcs, see WithFuncs
+}
+
+// boundViews holds an engine's clones of the parsed views. html/template
+// can't clone a set once it has executed and Funcs changes the whole set,
+// so each engine executes its own clones with its funcs bound.
+type boundViews struct {
+	mu    sync.RWMutex
+	views map[string]*template.Template
+}
+
+// globalFuncs are registered by other packages (i18n, auth, ...) and added to every template
+var globalFuncs = template.FuncMap{}
+
+// AddFuncs registers template functions for engines created afterwards.
+// Call it before NewTemplateEngine, usually from a package init.
+func AddFuncs(funcs template.FuncMap) {
+	for name, fn := range funcs {
+		globalFuncs[name] = fn
+	}
+}
+
+// WithFuncs returns a copy of the engine that overrides funcs for its renders,
+// e.g. translation helpers bound to the request locale.
+// The names must already be registered through AddFuncs.
+func (e *TemplateEngine) WithFuncs(funcs template.FuncMap) *TemplateEngine {
+	clone := *e
+	clone.views = &boundViews{views: make(map[string]*template.Template)}
+	clone.renderFuncs = template.FuncMap{}
+	for name, fn := range e.renderFuncs {
+		clone.renderFuncs[name] = fn
+	}
+	for name, fn := range funcs {
+		clone.renderFuncs[name] = fn
+	}
+	return &clone
+}
+
+// lookup returns the engine's clone of the view, cloned on first use with
+// renderFuncs bound
+func (e *TemplateEngine) lookup(name string) (*template.Template, error) {
+	e.views.mu.RLock()
+	tmpl, ok := e.views.views[name]
+	e.views.mu.RUnlock()
+	if ok {
+		return tmpl, nil
+	}
+
+	e.views.mu.Lock()
+	defer e.views.mu.Unlock()
+	if tmpl, ok := e.views.views[name]; ok {
+		return tmpl, nil
+	}
+	parsed, ok := e.templates[name]
+	if !ok {
+		return nil, fmt.Errorf("template not found: %s", name)
+	}
+	tmpl, err := parsed.Clone()
+	if err != nil {
+		return nil, err
+	}
+	if len(e.renderFuncs) > 0 {
+		tmpl = tmpl.Funcs(e.renderFuncs)
+	}
+	e.views.views[name] = tmpl
+	return tmpl, nil
 }
 
 // ----------------------------
@@ -34,6 +102,7 @@ type TemplateEngine struct {
 func NewTemplateEngine(viewsPath, defaultLayout string) *TemplateEngine {
 	engine := &TemplateEngine{
 		templates:     make(map[string]*template.Template),
+		views:         &boundViews{views: make(map[string]*template.Template)},
 		DefaultLayout: defaultLayout,
 		viewsPath:     viewsPath,
 		useEmbed:      false,
@@ -46,6 +115,9 @@ func NewTemplateEngine(viewsPath, defaultLayout string) *TemplateEngine {
 	}
 
 	engine.loadTemplates()
+	for name := range engine.templates {
+		_, _ = engine.lookup(name) // clone now, so requests without funcs never clone
+	}
 	return engine
 }
 
@@ -130,6 +202,10 @@ func (e *TemplateEngine) loadTemplates() {
 		"dict": dict,
 	}
 
+	for name, fn := range globalFuncs {
+		funcs[name] = fn
+	}
+
 	if e.useEmbed {
 		entries, _ := templatesFS.ReadDir("resources/views")
 		for _, f := range entries {
@@ -148,7 +224,7 @@ func (e *TemplateEngine) loadTemplates() {
 	} else {
 
 		// Add CSRF functions
-		csrfFuncs := CSRFunctions()
+		csrfFuncs := CSRFunctions(nil)
 		for k, v := range csrfFuncs {
 			funcs[k] = v
 		}
@@ -180,18 +256,18 @@ func (e *TemplateEngine) loadTemplates() {
 // Render with default layout
 // ----------------------------
 func (e *TemplateEngine) Render(w io.Writer, name string, data interface{}) error {
-	tmpl, ok := e.templates[name]
-	if !ok {
-		return fmt.Errorf("template not found: %s", name)
+	tmpl, err := e.lookup(name)
+	if err != nil {
+		return err
 	}
 	return tmpl.ExecuteTemplate(w, e.DefaultLayout, data)
 }
 
 // Render with specific layout
 func (e *TemplateEngine) RenderWithLayout(w io.Writer, viewName, layoutName string, data interface{}) error {
-	tmpl, ok := e.templates[viewName]
-	if !ok {
-		return fmt.Errorf("template not found: %s", viewName)
+	tmpl, err := e.lookup(viewName)
+	if err != nil {
+		return err
 	}
 	layout := layoutName
 	if layout == "" {
@@ -202,9 +278,9 @@ func (e *TemplateEngine) RenderWithLayout(w io.Writer, viewName, layoutName stri
 
 // Render only content (HTMX style)
 func (e *TemplateEngine) RenderWithoutLayout(w io.Writer, viewName string, data interface{}) error {
-	tmpl, ok := e.templates[viewName]
-	if !ok {
-		return fmt.Errorf("template not found: %s", viewName)
+	tmpl, err := e.lookup(viewName)
+	if err != nil {
+		return err
 	}
 	return tmpl.ExecuteTemplate(w, "content", data)
 }